@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/server"
+)
+
+// authFlags are the serve flags for route group authentication.
+type authFlags struct {
+	tokens   *string
+	users    *string
+	clientCA *string
+	groups   map[string]*string
+}
+
+func addAuthFlags(fs *flag.FlagSet) *authFlags {
+	return &authFlags{
+		tokens:   fs.String("auth-tokens", "", "Bearer token file: name:sha256-hex per line (see sb29guard auth token)"),
+		users:    fs.String("auth-users", "", "Basic auth users file: user:bcrypt-hash per line (htpasswd -B or sb29guard auth passwd)"),
+		clientCA: fs.String("auth-client-ca", "", "CA certificates (PEM) accepted for client-certificate auth (needs TLS)"),
+		groups: map[string]*string{
+			server.GroupPublic: fs.String("auth-public", server.AuthNone, "Auth for /, /explain, /law, /health, /classify: comma list of none|loopback|token|basic|cert|auto"),
+			server.GroupOps:    fs.String("auth-ops", server.AuthAuto, "Auth for /metrics, /metrics/history, /domain-list (auto: any configured credentials, else loopback only)"),
+			server.GroupAdmin:  fs.String("auth-admin", server.AuthAuto, "Auth for /admin/* (auto: any configured credentials, else loopback only)"),
+		},
+	}
+}
+
+// apply builds the route group authentication and attaches it to srv.
+func (f *authFlags) apply(srv *server.Server, tlsEnabled bool) error {
+	a := server.DefaultAuth()
+	if *f.tokens != "" {
+		if err := a.LoadTokens(*f.tokens); err != nil {
+			return err
+		}
+	}
+	if *f.users != "" {
+		if err := a.LoadUsers(*f.users); err != nil {
+			return err
+		}
+	}
+	if *f.clientCA != "" {
+		if !tlsEnabled {
+			return errors.New("--auth-client-ca needs TLS (--tls-cert, --tls-self-signed or --tls-ca-cert)")
+		}
+		if err := a.LoadClientCA(*f.clientCA); err != nil {
+			return err
+		}
+	}
+	for _, g := range []string{server.GroupPublic, server.GroupOps, server.GroupAdmin} {
+		methods, err := server.ParseAuthMethods(*f.groups[g])
+		if err != nil {
+			return fmt.Errorf("--auth-%s: %w", g, err)
+		}
+		if err := a.SetGroup(g, methods); err != nil {
+			return err
+		}
+	}
+	srv.SetAuth(a)
+	fmt.Printf("{\"event\":\"auth.configured\",\"public\":%q,\"ops\":%q,\"admin\":%q}\n",
+		strings.Join(a.Methods(server.GroupPublic), ","), strings.Join(a.Methods(server.GroupOps), ","), strings.Join(a.Methods(server.GroupAdmin), ","))
+	return nil
+}
+
+// setAdminAuth adds credentials for a running server's protected routes: SB29_ADMIN_TOKEN as
+// a bearer token, else SB29_ADMIN_USER/SB29_ADMIN_PASS as basic auth.
+func setAdminAuth(req *http.Request) {
+	if tok := os.Getenv("SB29_ADMIN_TOKEN"); tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+		return
+	}
+	if u, pw := os.Getenv("SB29_ADMIN_USER"), os.Getenv("SB29_ADMIN_PASS"); u != "" && pw != "" {
+		req.SetBasicAuth(u, pw)
+	}
+}
+
+func cmdAuth(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: sb29guard auth token --name <name> | passwd --user <user> (password on stdin)")
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "token":
+		cmdAuthToken(args[1:])
+	case "passwd":
+		cmdAuthPasswd(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown auth command: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// cmdAuthToken generates a random bearer token and prints it with its token file line; only
+// the line (a hash) needs to be stored.
+func cmdAuthToken(args []string) {
+	fs := flag.NewFlagSet("auth token", flag.ExitOnError)
+	name := fs.String("name", "", "Name recorded next to the token hash (e.g. prometheus)")
+	_ = fs.Parse(args)
+	if *name == "" || strings.ContainsAny(*name, ":\n") {
+		fmt.Fprintln(os.Stderr, "--name required (no ':')")
+		os.Exit(1)
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	tok := base64.RawURLEncoding.EncodeToString(b)
+	fmt.Printf("{\"name\":%q,\"token\":%q,\"line\":%q}\n", *name, tok, *name+":"+server.HashToken(tok))
+}
+
+// cmdAuthPasswd reads a password from the first line of stdin and prints a bcrypt users file line.
+func cmdAuthPasswd(args []string) {
+	fs := flag.NewFlagSet("auth passwd", flag.ExitOnError)
+	user := fs.String("user", "", "User name")
+	cost := fs.Int("cost", bcrypt.DefaultCost, "bcrypt cost")
+	_ = fs.Parse(args)
+	if *user == "" || strings.ContainsAny(*user, ":\n") {
+		fmt.Fprintln(os.Stderr, "--user required (no ':')")
+		os.Exit(1)
+	}
+	pw, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	pw = strings.TrimRight(pw, "\r\n")
+	if pw == "" {
+		fmt.Fprintln(os.Stderr, "password required on stdin")
+		os.Exit(1)
+	}
+	h, err := bcrypt.GenerateFromPassword([]byte(pw), *cost)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s:%s\n", *user, h)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/server"
+)
+
+func TestCmdAuthTokenAndPasswdFeedServeFlags(t *testing.T) {
+	out := captureOutput(t, func() { cmdAuthToken([]string{"--name", "prometheus"}) })
+	var tok struct{ Name, Token, Line string }
+	if err := json.Unmarshal([]byte(out), &tok); err != nil {
+		t.Fatalf("decode %q: %v", out, err)
+	}
+	if tok.Name != "prometheus" || len(tok.Token) < 40 || tok.Line != "prometheus:"+server.HashToken(tok.Token) || strings.Contains(tok.Line, tok.Token) {
+		t.Fatalf("unexpected token output: %+v", tok)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.WriteString("s3cret\n")
+	_ = w.Close()
+	oldStdin := os.Stdin
+	os.Stdin = r
+	out = captureOutput(t, func() { cmdAuthPasswd([]string{"--user", "admin", "--cost", "4"}) })
+	os.Stdin = oldStdin
+	if !strings.HasPrefix(out, "admin:$2a$04$") {
+		t.Fatalf("unexpected passwd line: %q", out)
+	}
+
+	d := t.TempDir()
+	tokens, users := filepath.Join(d, "tokens"), filepath.Join(d, "users")
+	if err := os.WriteFile(tokens, []byte(tok.Line+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(users, []byte(out), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	af := addAuthFlags(fs)
+	if err := fs.Parse([]string{"--auth-tokens", tokens, "--auth-users", users, "--auth-ops", "token"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := server.New(":0", &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "exampletool.com", Classification: "NO_DPA", Rationale: "r", LastReview: "2025-08-01", Status: "active"},
+	}})
+	out = captureOutput(t, func() {
+		if err := af.apply(srv, false); err != nil {
+			t.Errorf("apply: %v", err)
+		}
+	})
+	if !strings.Contains(out, `"event":"auth.configured","public":"none","ops":"token","admin":"token,basic"`) {
+		t.Fatalf("unexpected event: %s", out)
+	}
+	h := srv.Handler()
+	check := func(target string, set func(*http.Request), want int) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		set(req)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != want {
+			t.Fatalf("%s: expected %d got %d", target, want, rr.Code)
+		}
+	}
+	none := func(*http.Request) {}
+	check("/metrics", none, http.StatusUnauthorized)
+	check("/metrics", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+tok.Token) }, http.StatusOK)
+	check("/metrics", func(r *http.Request) { r.SetBasicAuth("admin", "s3cret") }, http.StatusUnauthorized)
+	check("/admin/summary", func(r *http.Request) { r.SetBasicAuth("admin", "s3cret") }, http.StatusNotFound)
+
+	// setAdminAuth prefers the bearer token.
+	t.Setenv("SB29_ADMIN_TOKEN", tok.Token)
+	check("/admin/summary", setAdminAuth, http.StatusNotFound)
+
+	fs = flag.NewFlagSet("serve", flag.ContinueOnError)
+	af = addAuthFlags(fs)
+	_ = fs.Parse([]string{"--auth-client-ca", tokens})
+	if err := af.apply(server.New(":0", srv.CurrentPolicy()), false); err == nil || !strings.Contains(err.Error(), "needs TLS") {
+		t.Fatalf("expected TLS error, got %v", err)
+	}
+	fs = flag.NewFlagSet("serve", flag.ContinueOnError)
+	af = addAuthFlags(fs)
+	_ = fs.Parse([]string{"--auth-ops", "basic"})
+	if err := af.apply(server.New(":0", srv.CurrentPolicy()), false); err == nil || !strings.Contains(err.Error(), "users file") {
+		t.Fatalf("expected missing users file error, got %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/config"
+)
+
+// parseFlags parses args, then fills every flag the user did not pass from its environment
+// variable and then from the --config file (or $SB29_CONFIG): flags > env > file > defaults.
+// Config errors exit 1 (2 when the file cannot be read).
+func parseFlags(fs *flag.FlagSet, args []string) {
+	cfgPath := fs.String("config", "", "Application config file (app.yaml); defaults to $"+config.EnvConfig)
+	_ = fs.Parse(args)
+	if err := applyConfig(fs, *cfgPath, os.Getenv); err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		if isReadError(err) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+// applyConfig fills unset flags of fs from getenv and the config file at path.
+func applyConfig(fs *flag.FlagSet, path string, getenv func(string) string) error {
+	f, err := loadConfig(path, getenv)
+	if err != nil {
+		return err
+	}
+	given := map[string]bool{}
+	fs.Visit(func(fl *flag.Flag) { given[fl.Name] = true })
+	fill := func(fl *flag.Flag, preset string) error {
+		if given[fl.Name] {
+			return nil
+		}
+		k, ok := config.ForFlag(fs.Name(), fl.Name)
+		if !ok {
+			return nil
+		}
+		v, src, ok := config.Resolve(k, f, preset, getenv)
+		if !ok {
+			return nil
+		}
+		err := config.Check(k, v)
+		if err == nil {
+			err = fs.Set(fl.Name, v)
+		}
+		if err != nil {
+			if src == config.SourceEnv {
+				return fmt.Errorf("%s: %w", k.Env, err)
+			}
+			return fmt.Errorf("%s: %s: %w", f.Path, k.Name, err)
+		}
+		return nil
+	}
+	// The DNS preset decides which file values the other flags get, so resolve it first.
+	preset := ""
+	if fl := fs.Lookup("preset"); fl != nil {
+		if err := fill(fl, ""); err != nil {
+			return err
+		}
+		preset = fl.Value.String()
+		if preset != "" && !f.HasPreset(preset) {
+			return fmt.Errorf("unknown dns preset %s", preset)
+		}
+	}
+	fs.VisitAll(func(fl *flag.Flag) {
+		if err == nil && fl.Name != "preset" {
+			err = fill(fl, preset)
+		}
+	})
+	return err
+}
+
+// loadConfig loads and validates the config file at path (or $SB29_CONFIG); nil when neither is set.
+func loadConfig(path string, getenv func(string) string) (*config.File, error) {
+	if path == "" {
+		path = getenv(config.EnvConfig)
+	}
+	if path == "" {
+		return nil, nil
+	}
+	f, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+func cmdConfig(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: sb29guard config validate|print [--config app.yaml]")
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "validate":
+		cmdConfigValidate(args[1:])
+	case "print":
+		cmdConfigPrint(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown config command: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// cmdConfigValidate checks the config file and any SB29_* environment overrides.
+func cmdConfigValidate(args []string) {
+	fs := flag.NewFlagSet("config validate", flag.ExitOnError)
+	cfgPath := fs.String("config", "", "Application config file (app.yaml); defaults to $"+config.EnvConfig)
+	_ = fs.Parse(args)
+	path := *cfgPath
+	if path == "" {
+		path = os.Getenv(config.EnvConfig)
+	}
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "--config required (or set "+config.EnvConfig+")")
+		os.Exit(1)
+	}
+	f, err := config.Load(path)
+	if isReadError(err) {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	if err == nil {
+		err = f.Validate()
+	}
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, k := range config.Keys {
+		if v := os.Getenv(k.Env); v != "" {
+			if err := config.Check(k, v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", k.Env, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		fmt.Printf("{\"status\":\"error\",\"message\":%q}\n", strings.ReplaceAll(errors.Join(errs...).Error(), "\n", "; "))
+		os.Exit(1)
+	}
+	presets, _ := json.Marshal(append([]string{}, f.Presets()...))
+	fmt.Printf("{\"status\":\"ok\",\"config\":%q,\"presets\":%s}\n", path, presets)
+}
+
+// cmdConfigPrint writes the effective configuration as app.yaml, annotating values that
+// come from the environment or the config file.
+func cmdConfigPrint(args []string) {
+	fs := flag.NewFlagSet("config print", flag.ExitOnError)
+	cfgPath := fs.String("config", "", "Application config file (app.yaml); defaults to $"+config.EnvConfig)
+	preset := fs.String("preset", "", "Show the dns section with this preset applied")
+	_ = fs.Parse(args)
+	f, err := loadConfig(*cfgPath, os.Getenv)
+	if isReadError(err) {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		os.Exit(1)
+	}
+	settings, err := config.Effective(f, *preset, os.Getenv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		os.Exit(1)
+	}
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(configNode(settings, f)); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	_ = enc.Close()
+}
+
+// configNode renders settings as nested YAML. The applied preset is folded into the dns
+// values, so the output loads back as a config file without it.
+func configNode(settings []config.Setting, f *config.File) *yaml.Node {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := map[string]*yaml.Node{}
+	for _, s := range settings {
+		section, field, _ := strings.Cut(s.Key.Name, ".")
+		m := sections[section]
+		if m == nil {
+			m = &yaml.Node{Kind: yaml.MappingNode}
+			sections[section] = m
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: section}, m)
+		}
+		if s.Key.Name == "dns.preset" {
+			if s.Value != "" {
+				root.Content[len(root.Content)-2].LineComment = "preset " + s.Value + " applied"
+			}
+			continue
+		}
+		tag := "!!str" // keep strings such as 23:59 or "" quoted as strings
+		switch s.Key.Kind {
+		case config.Bool, config.Int, config.Float:
+			tag = ""
+		}
+		val := &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: s.Value}
+		switch s.Source {
+		case config.SourceEnv:
+			val.LineComment = "env " + s.Key.Env
+		case config.SourceFile:
+			val.LineComment = "from " + f.Path
+		}
+		m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: field}, val)
+	}
+	return root
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	content := "policy:\n  file: from-file.yaml\n" +
+		"dns:\n  ttl: 120\n  redirect_ipv4: 10.0.0.1\n  redirect_host: file.guard.local\n" +
+		"  presets:\n    lab:\n      ttl: 30\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"SB29_DNS_REDIRECT_IPV4": "10.0.0.2", "SB29_DNS_REDIRECT_HOST": "env.guard.local"}
+	newFlags := func() (*flag.FlagSet, map[string]*string, *int) {
+		fs := flag.NewFlagSet("generate-dns", flag.ContinueOnError)
+		s := map[string]*string{
+			"policy":        fs.String("policy", "policy/domains.yaml", ""),
+			"redirect-ipv4": fs.String("redirect-ipv4", "", ""),
+			"redirect-host": fs.String("redirect-host", "blocked.guard.local", ""),
+			"format":        fs.String("format", "hosts", ""),
+			"preset":        fs.String("preset", "", ""),
+		}
+		return fs, s, fs.Int("ttl", 300, "")
+	}
+	fs, s, ttl := newFlags()
+	_ = fs.Parse([]string{"--redirect-host", "flag.guard.local"})
+	if err := applyConfig(fs, path, func(k string) string { return env[k] }); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if *s["redirect-host"] != "flag.guard.local" || *s["redirect-ipv4"] != "10.0.0.2" || *s["policy"] != "from-file.yaml" || *ttl != 120 || *s["format"] != "hosts" {
+		t.Fatalf("unexpected precedence: host=%s ip=%s policy=%s ttl=%d format=%s", *s["redirect-host"], *s["redirect-ipv4"], *s["policy"], *ttl, *s["format"])
+	}
+	fs, _, ttl = newFlags()
+	_ = fs.Parse([]string{"--preset", "lab"})
+	if err := applyConfig(fs, path, func(string) string { return "" }); err != nil {
+		t.Fatalf("apply preset: %v", err)
+	}
+	if *ttl != 30 {
+		t.Fatalf("preset ttl not applied: %d", *ttl)
+	}
+	fs, _, _ = newFlags()
+	_ = fs.Parse([]string{"--preset", "missing"})
+	if err := applyConfig(fs, path, func(string) string { return "" }); err == nil {
+		t.Fatalf("expected unknown preset error")
+	}
+	fs, _, _ = newFlags()
+	_ = fs.Parse(nil)
+	if err := applyConfig(fs, "", func(k string) string { return map[string]string{"SB29_DNS_TTL": "soon"}[k] }); err == nil || !strings.Contains(err.Error(), "SB29_DNS_TTL") {
+		t.Fatalf("expected env error naming the variable, got %v", err)
+	}
+}
+
+func TestCmdConfigValidateAndPrint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte("serve:\n  listen: \"127.0.0.1:9090\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := captureOutput(t, func() { cmdConfigValidate([]string{"--config", path}) })
+	if !strings.Contains(out, "\"status\":\"ok\"") {
+		t.Fatalf("unexpected validate output: %s", out)
+	}
+	out = captureOutput(t, func() { cmdConfigPrint([]string{"--config", path}) })
+	if !strings.Contains(out, "listen: 127.0.0.1:9090 # from "+path) || !strings.Contains(out, "listen: :53\n") {
+		t.Fatalf("unexpected print output: %s", out)
+	}
+	printed := filepath.Join(t.TempDir(), "printed.yaml")
+	if err := os.WriteFile(printed, []byte(out), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out = captureOutput(t, func() { cmdConfigValidate([]string{"--config", printed}) })
+	if !strings.Contains(out, "\"status\":\"ok\"") {
+		t.Fatalf("printed config should validate: %s", out)
+	}
+}
+
+func TestCLICommandsUseConfigFile(t *testing.T) {
+	bin := buildTestBinary(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.yaml")
+	if err := os.WriteFile(path, []byte("policy:\n  file: "+filepath.Join(dir, "missing.yaml")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(bin, "hash", "--config", path).CombinedOutput()
+	if err == nil || !strings.Contains(string(out), "missing.yaml") {
+		t.Fatalf("hash should read policy.file from the config: err=%v out=%s", err, out)
+	}
+	if err := os.WriteFile(path, []byte("serve:\n  listen: nope\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err = exec.Command(bin, "serve", "--config", path).CombinedOutput()
+	if err == nil || !strings.Contains(string(out), "serve.listen") {
+		t.Fatalf("serve should reject an invalid config: err=%v out=%s", err, out)
+	}
+}
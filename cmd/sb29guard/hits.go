@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/hits"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/server"
+)
+
+// hitsFlags are the serve flags for the daily explain hit counts.
+type hitsFlags struct {
+	enabled       *bool
+	retentionDays *int
+	flushInterval *time.Duration
+
+	agg *hits.Aggregator
+}
+
+func addHitsFlags(fs *flag.FlagSet) *hitsFlags {
+	return &hitsFlags{
+		enabled:       fs.Bool("hits", true, "Count explain page views per day by domain and classification under <cache-dir>/hits (no IPs or user agents)"),
+		retentionDays: fs.Int("hits-retention-days", 30, "Delete daily hit files older than this many days (0 = keep)"),
+		flushInterval: fs.Duration("hits-flush-interval", time.Minute, "How often hit counts are written to disk"),
+	}
+}
+
+// apply opens the aggregator under cacheDir, attaches it to srv and flushes it periodically
+// until srv shuts down. Failures are logged and counting is skipped.
+func (f *hitsFlags) apply(srv *server.Server, cacheDir string) {
+	if !*f.enabled {
+		return
+	}
+	a, err := hits.Open(cacheDir, *f.retentionDays)
+	if err != nil {
+		fmt.Printf("{\"event\":\"hits.error\",\"message\":%q}\n", err.Error())
+		return
+	}
+	f.agg = a
+	srv.SetHits(a)
+	go func() {
+		interval := *f.flushInterval
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-srv.Done():
+				return
+			case <-t.C:
+				logHitsFlushError(a.Flush())
+			}
+		}
+	}()
+}
+
+// close writes the remaining counts after the server has stopped.
+func (f *hitsFlags) close() {
+	if f.agg != nil {
+		logHitsFlushError(f.agg.Flush())
+	}
+}
+
+func logHitsFlushError(err error) {
+	if err != nil {
+		fmt.Printf("{\"event\":\"hits.flush.error\",\"message\":%q}\n", err.Error())
+	}
+}
+
+// cmdReport prints one day's explain hit counts, read from the hits files under --cache-dir
+// or from a running server's /admin/summary.
+func cmdReport(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	date := fs.String("date", time.Now().Format(hits.DateLayout), "Day to report (YYYY-MM-DD, local time)")
+	cacheDir := fs.String("cache-dir", "cache", "Cache directory holding the daily hit files")
+	serverURL := fs.String("server", "", "Base URL of a running serve instance (includes hits not yet flushed)")
+	format := fs.String("format", "text", "Output format: text|json")
+	parseFlags(fs, args)
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format: %s (use text|json)\n", *format)
+		os.Exit(1)
+	}
+	if _, err := time.Parse(hits.DateLayout, *date); err != nil {
+		fmt.Fprintln(os.Stderr, "--date must be YYYY-MM-DD")
+		os.Exit(1)
+	}
+	var sum hits.Summary
+	var err error
+	if *serverURL != "" {
+		sum, err = fetchSummary(*serverURL, *date)
+	} else {
+		sum, err = hits.ReadSummary(*cacheDir, *date)
+	}
+	if errors.Is(err, hits.ErrNoData) {
+		sum, err = hits.Summary{Date: *date, Entries: []hits.Entry{}}, nil
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	if *format == "json" {
+		_ = json.NewEncoder(os.Stdout).Encode(sum)
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DOMAIN\tCLASSIFICATION\tCOUNT")
+	total := 0
+	for _, e := range sum.Entries {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", e.Domain, e.Classification, e.Count)
+		total += e.Count
+	}
+	_ = tw.Flush()
+	fmt.Printf("%s: %d hits across %d domains (policy %s)\n", sum.Date, total, len(sum.Entries), dashIfEmpty(sum.PolicyVersion))
+	if sum.Dropped > 0 {
+		fmt.Printf("%d hits not itemized (daily entry limit reached)\n", sum.Dropped)
+	}
+}
+
+// fetchSummary asks a running server, authenticating as setAdminAuth does.
+func fetchSummary(base, date string) (hits.Summary, error) {
+	req, _ := http.NewRequest(http.MethodGet, strings.TrimRight(base, "/")+"/admin/summary?date="+url.QueryEscape(date), nil)
+	setAdminAuth(req)
+	resp, err := (&http.Client{Timeout: 15 * time.Second}).Do(req)
+	if err != nil {
+		return hits.Summary{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if resp.StatusCode == http.StatusNotFound && strings.Contains(string(body), `"not_found"`) {
+		return hits.Summary{}, hits.ErrNoData
+	}
+	if resp.StatusCode != http.StatusOK {
+		return hits.Summary{}, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var sum hits.Summary
+	if err := json.Unmarshal(body, &sum); err != nil {
+		return hits.Summary{}, fmt.Errorf("decode summary: %w", err)
+	}
+	return sum, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/hits"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/server"
+)
+
+func TestCmdReportFromFilesAndServer(t *testing.T) {
+	cacheDir := t.TempDir()
+	a, err := hits.Open(cacheDir, 30)
+	if err != nil {
+		t.Fatalf("open hits: %v", err)
+	}
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "exampletool.com", Classification: "NO_DPA", Rationale: "Vendor has not signed", LastReview: "2025-08-01", Status: "active"},
+	}}
+	srv := server.New(":0", p)
+	srv.SetHits(a)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	for i := 0; i < 3; i++ {
+		resp, err := ts.Client().Get(ts.URL + "/explain?d=exampletool.com")
+		if err != nil {
+			t.Fatalf("explain: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+	today := time.Now().Format(hits.DateLayout)
+
+	// The server reports hits that are not on disk yet.
+	out := captureOutput(t, func() { cmdReport([]string{"--server", ts.URL, "--format", "json"}) })
+	var sum hits.Summary
+	if err := json.Unmarshal([]byte(out), &sum); err != nil {
+		t.Fatalf("decode %q: %v", out, err)
+	}
+	if sum.Date != today || sum.PolicyVersion != "0.1.0" || len(sum.Entries) != 1 || sum.Entries[0].Count != 3 {
+		t.Fatalf("unexpected summary: %#v", sum)
+	}
+	out = captureOutput(t, func() { cmdReport([]string{"--cache-dir", cacheDir, "--date", today}) })
+	if !strings.Contains(out, "0 hits across 0 domains") {
+		t.Fatalf("unflushed hits should not be on disk: %s", out)
+	}
+
+	if err := a.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	out = captureOutput(t, func() { cmdReport([]string{"--cache-dir", cacheDir, "--date", today}) })
+	if !strings.Contains(out, "exampletool.com") || !strings.Contains(out, "NO_DPA") || !strings.Contains(out, "3 hits across 1 domains (policy 0.1.0)") {
+		t.Fatalf("unexpected report: %s", out)
+	}
+}
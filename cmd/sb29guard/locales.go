@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/i18n"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+)
+
+// loadLocales returns the embedded message catalogs overlaid by the <lang>.json files in dir.
+// Without dir, a locales directory next to the --templates files is used when present.
+func loadLocales(dir, templatesDir string) (*i18n.Bundle, error) {
+	if dir == "" && templatesDir != "" {
+		if st, err := os.Stat(filepath.Join(templatesDir, "locales")); err == nil && st.IsDir() {
+			dir = filepath.Join(templatesDir, "locales")
+		}
+	}
+	b, err := i18n.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		tags := []string{}
+		for _, l := range b.Languages() {
+			tags = append(tags, l.Tag)
+		}
+		langs, _ := json.Marshal(tags)
+		fmt.Printf("{\"event\":\"locales.loaded\",\"dir\":%q,\"languages\":%s}\n", dir, langs)
+	}
+	return b, nil
+}
+
+// staticLocales is the catalog data embedded in the static explain bundle, which picks the
+// language in the browser.
+type staticLocales struct {
+	Languages []i18n.Language          `json:"languages"`
+	Catalogs  map[string]*i18n.Catalog `json:"catalogs"`
+	// Taxonomy holds the built-in English labels, shown when a catalog has no translation.
+	Taxonomy map[string]i18n.ClassText `json:"taxonomy"`
+}
+
+// staticLocalesJSON encodes b for a <script type="application/json"> element; json.Marshal
+// escapes <, > and &, so the text cannot close the element.
+func staticLocalesJSON(b *i18n.Bundle) string {
+	data := staticLocales{Languages: b.Languages(), Catalogs: map[string]*i18n.Catalog{}, Taxonomy: map[string]i18n.ClassText{}}
+	for _, l := range data.Languages {
+		data.Catalogs[l.Tag] = b.Catalog(l.Tag)
+	}
+	for _, c := range policy.DefaultTaxonomy {
+		data.Taxonomy[c.Key] = i18n.ClassText{Label: c.DisplayLabel(), Description: c.Description}
+	}
+	out, _ := json.Marshal(data)
+	return string(out)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLoadLocalesAndStaticBundle(t *testing.T) {
+	tmpl := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmpl, "locales"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpl, "locales", "es.json"), []byte(`{"messages":{"contact":"Llame a la oficina </script> del distrito."}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out string
+	var html string
+	out = captureOutput(t, func() {
+		b, err := loadLocales("", tmpl)
+		if err != nil {
+			t.Errorf("loadLocales: %v", err)
+			return
+		}
+		if got := b.Catalog("es").Messages["contact"]; !strings.HasPrefix(got, "Llame a la oficina") {
+			t.Errorf("override next to templates not used: %q", got)
+		}
+		html = renderStaticExplainHTML("Blocked <site>", "https://law.example/sb29", "", b)
+	})
+	if !strings.Contains(out, `"event":"locales.loaded"`) || !strings.Contains(out, `"languages":["en","es","ne","so"]`) {
+		t.Fatalf("unexpected event: %s", out)
+	}
+	if _, err := loadLocales(filepath.Join(tmpl, "missing"), ""); err == nil {
+		t.Fatalf("expected error for a missing locales directory")
+	}
+
+	for _, want := range []string{
+		"<title>Blocked &lt;site&gt;</title>",
+		`<span class="muted" data-i18n="access_to">Access to</span>`,
+		`data-i18n="contact">Contact your instructional technology`,
+		`<nav id="languages" class="languages"></nav>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Fatalf("static page missing %q", want)
+		}
+	}
+	m := regexp.MustCompile(`<script type="application/json" id="sb29-locales">(.*?)</script>`).FindStringSubmatch(html)
+	if m == nil {
+		t.Fatalf("catalog data missing")
+	}
+	var data staticLocales
+	if err := json.Unmarshal([]byte(m[1]), &data); err != nil {
+		t.Fatalf("catalog data: %v", err)
+	}
+	if len(data.Languages) != 4 || data.Catalogs["es"].Messages["contact"] != "Llame a la oficina </script> del distrito." ||
+		data.Catalogs["so"].Classifications["NO_DPA"].Label == "" || data.Taxonomy["NO_DPA"].Label != "No Data Privacy Agreement" {
+		t.Fatalf("unexpected catalog data: %+v", data)
+	}
+}
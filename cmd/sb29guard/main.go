@@ -3,16 +3,36 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"html/template"
+	"io"
+	"io/fs"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/RiceC-at-MasonHS/SB29-guard/internal/dnsgen"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/dnsserve"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/hash"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/i18n"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/journal"
 	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
 	"github.com/RiceC-at-MasonHS/SB29-guard/internal/server"
 	"github.com/RiceC-at-MasonHS/SB29-guard/internal/sheets"
@@ -36,14 +56,28 @@ func main() {
 		cmdValidate(os.Args[2:])
 	case "hash":
 		cmdHash(os.Args[2:])
+	case "diff":
+		cmdDiff(os.Args[2:])
+	case "rollback":
+		cmdRollback(os.Args[2:])
 	case "serve":
 		cmdServe(os.Args[2:])
+	case "dns-serve":
+		cmdDNSServe(os.Args[2:])
 	case "generate-dns":
 		cmdGenerateDNS(os.Args[2:])
 	case "generate-proxy":
 		cmdGenerateProxy(os.Args[2:])
 	case "generate-explain-static":
 		cmdGenerateExplainStatic(os.Args[2:])
+	case "auth":
+		cmdAuth(os.Args[2:])
+	case "report":
+		cmdReport(os.Args[2:])
+	case "requests":
+		cmdRequests(os.Args[2:])
+	case "config":
+		cmdConfig(os.Args[2:])
 	case "version":
 		cmdVersion()
 	default:
@@ -55,7 +89,7 @@ func main() {
 
 func usage() {
 	fmt.Println("sb29guard <command> [flags]")
-	fmt.Println("commands: validate, hash, serve, generate-dns, generate-proxy, generate-explain-static, version")
+	fmt.Println("commands: validate, hash, diff, rollback, serve, dns-serve, generate-dns, generate-proxy, generate-explain-static, report, requests, auth, config, version")
 	fmt.Println("generate-dns formats: hosts|bind|unbound|rpz|dnsmasq|domain-list|winps")
 }
 
@@ -90,7 +124,18 @@ func cmdValidate(args []string) {
 	policyPath := fs.String("policy", "policy/domains.yaml", "Path to policy file")
 	sheetCSV := fs.String("sheet-csv", "", "Published Google Sheet CSV URL (overrides --policy)")
 	strict := fs.Bool("strict", true, "Enforce JSON Schema validation")
-	_ = fs.Parse(args)
+	warnExpiring := fs.String("warn-expiring", "", "Report records expiring within this window (e.g. 30d, 72h) and those already expired")
+	failOnLint := fs.Bool("fail-on-lint", false, "Exit 1 when lint reports warning-level overlaps or conflicts")
+	parseFlags(fs, args)
+	var window time.Duration
+	if *warnExpiring != "" {
+		w, err := parseDays(*warnExpiring)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: --warn-expiring: %v\n", err)
+			os.Exit(1)
+		}
+		window = w
+	}
 	var p *policy.Policy
 	var err error
 	if *sheetCSV != "" {
@@ -100,13 +145,12 @@ func cmdValidate(args []string) {
 			os.Exit(1)
 		}
 	} else {
-		data, rerr := os.ReadFile(*policyPath)
-		if rerr != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", rerr)
+		policy.StrictValidation = *strict
+		p, err = loadPolicyFile(*policyPath)
+		if isReadError(err) {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(2)
 		}
-		policy.StrictValidation = *strict
-		p, err = policy.Load(data)
 		if err != nil {
 			fmt.Printf("{\"status\":\"error\",\"message\":%q}\n", err.Error())
 			os.Exit(1)
@@ -116,7 +160,122 @@ func cmdValidate(args []string) {
 		fmt.Printf("{\"status\":\"error\",\"message\":%q}\n", err.Error())
 		os.Exit(1)
 	}
-	fmt.Printf("{\"status\":\"ok\",\"records\":%d,\"version\":%q}\n", len(p.Records), p.Version)
+	findings := p.Lint()
+	lintWarnings := 0
+	for _, f := range findings {
+		if f.Severity == "warning" {
+			lintWarnings++
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s %s: %s\n", f.Severity, f.Kind, f.Domain, f.Detail)
+	}
+	lintJSON := ""
+	if len(findings) > 0 {
+		b, _ := json.Marshal(findings)
+		lintJSON = ",\"lint\":" + string(b)
+	}
+	exitLint := func() {
+		if *failOnLint && lintWarnings > 0 {
+			os.Exit(1)
+		}
+	}
+	if *warnExpiring == "" {
+		fmt.Printf("{\"status\":\"ok\",\"records\":%d,\"version\":%q%s}\n", len(p.Records), p.Version, lintJSON)
+		exitLint()
+		return
+	}
+	now := time.Now()
+	expiring, expired := p.ExpiringWithin(now, window)
+	type expiryItem struct {
+		Domain         string `json:"domain"`
+		Classification string `json:"classification"`
+		Expires        string `json:"expires"`
+		DaysLeft       int    `json:"days_left"`
+	}
+	toItems := func(rs []policy.Record) []expiryItem {
+		items := []expiryItem{}
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		for _, r := range rs {
+			t, _ := r.ExpiresTime(now.Location())
+			days := int(math.Round(t.Sub(today).Hours() / 24))
+			items = append(items, expiryItem{r.Domain, r.Classification, r.Expires, days})
+			if days >= 0 {
+				fmt.Fprintf(os.Stderr, "warning: %s expires %s (%d day(s) left)\n", r.Domain, r.Expires, days)
+			} else {
+				fmt.Fprintf(os.Stderr, "warning: %s expired %s\n", r.Domain, r.Expires)
+			}
+		}
+		return items
+	}
+	expiringJSON, _ := json.Marshal(toItems(expiring))
+	expiredJSON, _ := json.Marshal(toItems(expired))
+	fmt.Printf("{\"status\":\"ok\",\"records\":%d,\"version\":%q,\"expiring\":%s,\"expired\":%s%s}\n", len(p.Records), p.Version, expiringJSON, expiredJSON, lintJSON)
+	exitLint()
+}
+
+// parseDays parses a duration that may use a day suffix ("30d") in addition to time.ParseDuration units.
+func parseDays(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "d") {
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid day count %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative window %q", s)
+	}
+	return d, nil
+}
+
+// checkExpiredClassification returns the --expired-classification grace class, exiting when it is not
+// a well-formed key. Whether it is defined is checked against each policy's taxonomy when grace mode
+// is applied (see policy.Policy.SetExpiredClassification).
+func checkExpiredClassification(class string) string {
+	class = strings.TrimSpace(class)
+	if class != "" && !policy.ValidClassificationKey(class) {
+		fmt.Fprintf(os.Stderr, "error: --expired-classification: invalid classification %q\n", class)
+		os.Exit(1)
+	}
+	return class
+}
+
+// reevaluateExpiryAtMidnight drops the records whose expires date just passed from the served policy
+// after each local midnight (see dropExpiredRecords), even when the policy source itself did not change.
+// Closing stop ends the loop.
+func reevaluateExpiryAtMidnight(srv policyTarget, j *journal.Journal, stop <-chan struct{}) {
+	for {
+		t := time.NewTimer(time.Until(nextDailyTime(0, 0)))
+		select {
+		case <-stop:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		dropExpiredRecords(srv, j, time.Now())
+	}
+}
+
+// dropExpiredRecords replaces the served policy with one rebuilt without the records that expired by
+// now (kept and reclassified in grace mode), so the index, /domain-list and record counts stop
+// carrying them. The rebuilt policy is journaled (source "expiry") so its hash can be looked up and
+// rolled back to like any other; when nothing was dropped the served policy is left alone.
+func dropExpiredRecords(srv policyTarget, j *journal.Journal, now time.Time) {
+	p := srv.CurrentPolicy()
+	pruned := p.WithoutExpired(now)
+	dropped := len(p.Records) - len(pruned.Records)
+	if dropped > 0 {
+		srv.UpdatePolicy(pruned)
+		journalPolicy(j, pruned, "expiry")
+	} else if w, ok := srv.(expiryWatcher); ok {
+		// Nothing to re-apply, but records still move into the notification window day by day.
+		w.checkExpiring(p, now)
+	}
+	fmt.Printf("{\"event\":\"policy.expiry.reevaluated\",\"active\":%d,\"dropped\":%d,\"grace\":%t}\n", len(pruned.ActiveRecords(now)), dropped, p.ExpiredClassification() != "")
 }
 
 func cmdHash(args []string) {
@@ -124,7 +283,7 @@ func cmdHash(args []string) {
 	policyPath := fs.String("policy", "policy/domains.yaml", "Path to policy file")
 	sheetCSV := fs.String("sheet-csv", "", "Published Google Sheet CSV URL (overrides --policy)")
 	strict := fs.Bool("strict", true, "Enforce JSON Schema validation")
-	_ = fs.Parse(args)
+	parseFlags(fs, args)
 	var p *policy.Policy
 	var err error
 	if *sheetCSV != "" {
@@ -134,13 +293,12 @@ func cmdHash(args []string) {
 			os.Exit(1)
 		}
 	} else {
-		b, rerr := os.ReadFile(*policyPath)
-		if rerr != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", rerr)
+		policy.StrictValidation = *strict
+		p, err = loadPolicyFile(*policyPath)
+		if isReadError(err) {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(2)
 		}
-		policy.StrictValidation = *strict
-		p, err = policy.Load(b)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(1)
@@ -154,6 +312,196 @@ func cmdHash(args []string) {
 	fmt.Printf("{\"hash\":%q,\"records\":%d}\n", h, len(p.Records))
 }
 
+func cmdDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "text", "Output format: text|json")
+	strict := fs.Bool("strict", true, "Enforce JSON Schema validation for YAML sources")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: sb29guard diff [--format text|json] <old-source> <new-source>")
+		fmt.Fprintln(os.Stderr, "sources: policy YAML path, published sheet CSV URL, or a CSV file on disk")
+		fs.PrintDefaults()
+	}
+	parseFlags(fs, args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "error: unsupported format %q (text|json)\n", *format)
+		os.Exit(1)
+	}
+	policy.StrictValidation = *strict
+	oldSrc, newSrc := fs.Arg(0), fs.Arg(1)
+	oldP, err := loadPolicySource(oldSrc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid policy: %s: %v\n", oldSrc, err)
+		os.Exit(1)
+	}
+	newP, err := loadPolicySource(newSrc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid policy: %s: %v\n", newSrc, err)
+		os.Exit(1)
+	}
+	changes := policy.Diff(oldP, newP)
+	summary := map[string]int{}
+	for _, c := range changes {
+		summary[c.Kind]++
+	}
+	if *format == "json" {
+		type side struct {
+			Source  string `json:"source"`
+			Version string `json:"version"`
+			Hash    string `json:"hash"`
+			Records int    `json:"records"`
+		}
+		if changes == nil {
+			changes = []policy.Change{}
+		}
+		out := struct {
+			Old     side            `json:"old"`
+			New     side            `json:"new"`
+			Summary map[string]int  `json:"summary"`
+			Changes []policy.Change `json:"changes"`
+		}{
+			Old:     side{oldSrc, oldP.Version, oldP.CanonicalHash(), len(oldP.Records)},
+			New:     side{newSrc, newP.Version, newP.CanonicalHash(), len(newP.Records)},
+			Summary: summary,
+			Changes: changes,
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(out)
+		return
+	}
+	fmt.Printf("old: %s (version %s, %d records, hash %s)\n", oldSrc, oldP.Version, len(oldP.Records), oldP.CanonicalHash()[:12])
+	fmt.Printf("new: %s (version %s, %d records, hash %s)\n", newSrc, newP.Version, len(newP.Records), newP.CanonicalHash()[:12])
+	if len(changes) == 0 {
+		fmt.Println("no record changes")
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DOMAIN\tCHANGE\tOLD\tNEW")
+	for _, c := range changes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Domain, c.Kind, dashIfEmpty(c.Old), dashIfEmpty(c.New))
+	}
+	_ = tw.Flush()
+	var parts []string
+	for _, k := range []string{policy.ChangeAdded, policy.ChangeRemoved, policy.ChangeSuspended, policy.ChangeUnsuspended, policy.ChangeReclassified, policy.ChangeRationale, policy.ChangeExpires} {
+		if n := summary[k]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, k))
+		}
+	}
+	fmt.Printf("%d change(s): %s\n", len(changes), strings.Join(parts, ", "))
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// loadPolicySource loads and validates a policy from a YAML path, a published sheet CSV URL
+// (http/https, fetched with the usual cache) or a CSV file on disk (e.g. a cached export).
+func loadPolicySource(src string) (*policy.Policy, error) {
+	var p *policy.Policy
+	var err error
+	switch {
+	case strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://"):
+		p, _, err = sheets.FetchCSVPolicyCached(src, "", &http.Client{Timeout: 15 * 1e9})
+	case strings.EqualFold(filepath.Ext(src), ".csv"):
+		b, rerr := os.ReadFile(src)
+		if rerr != nil {
+			return nil, rerr
+		}
+		p, err = sheets.ParseCSVPolicy(b)
+	default:
+		p, err = loadPolicyFile(src)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// cmdRollback lists journaled policy snapshots or re-activates one, either on a running
+// server (via POST /admin/rollback) or by writing it to a policy file picked up by --watch.
+func cmdRollback(args []string) {
+	fs := flag.NewFlagSet("rollback", flag.ExitOnError)
+	to := fs.String("to", "", "Snapshot hash (or unique prefix of at least 6 characters) to re-activate")
+	cacheDir := fs.String("cache-dir", "cache", "Cache directory holding the policy journal")
+	list := fs.Bool("list", false, "List journaled snapshots and exit")
+	serverURL := fs.String("server", "", "Base URL of a running serve instance (e.g. http://127.0.0.1:8080)")
+	out := fs.String("out", "", "Write the snapshot as policy YAML to this path (atomic replace)")
+	parseFlags(fs, args)
+	if *list {
+		j, err := journal.Open(*cacheDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		entries, err := j.History()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		if entries == nil {
+			entries = []journal.Entry{}
+		}
+		_ = json.NewEncoder(os.Stdout).Encode(map[string]interface{}{"status": "ok", "entries": entries})
+		return
+	}
+	if *to == "" || (*serverURL == "") == (*out == "") {
+		fmt.Fprintln(os.Stderr, "usage: sb29guard rollback --to <hash> (--server <url> | --out <policy.yaml>) [--cache-dir cache]")
+		fmt.Fprintln(os.Stderr, "       sb29guard rollback --list [--cache-dir cache]")
+		os.Exit(1)
+	}
+	if *serverURL != "" {
+		endpoint := strings.TrimRight(*serverURL, "/") + "/admin/rollback?to=" + url.QueryEscape(*to)
+		req, _ := http.NewRequest(http.MethodPost, endpoint, nil)
+		setAdminAuth(req)
+		resp, err := (&http.Client{Timeout: 15 * time.Second}).Do(req)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		fmt.Println(strings.TrimSpace(string(body)))
+		if resp.StatusCode != http.StatusOK {
+			os.Exit(1)
+		}
+		return
+	}
+	j, err := journal.Open(*cacheDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	p, full, err := j.Load(*to)
+	if err != nil {
+		fmt.Printf("{\"status\":\"error\",\"message\":%q}\n", err.Error())
+		os.Exit(1)
+	}
+	b, err := yaml.Marshal(p)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	tmp := *out + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err == nil {
+		err = os.Rename(tmp, *out)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	fmt.Printf("{\"status\":\"ok\",\"hash\":%q,\"records\":%d,\"out\":%q}\n", full, len(p.Records), *out)
+}
+
 func cmdServe(args []string) {
 	fs := flag.NewFlagSet("serve", flag.ExitOnError)
 	policyPath := fs.String("policy", "policy/domains.yaml", "Path to policy file")
@@ -162,15 +510,27 @@ func cmdServe(args []string) {
 	refreshAt := fs.String("refresh-at", "23:59", "Daily refresh time local (HH:MM), only with --sheet-csv")
 	refreshEvery := fs.Duration("refresh-every", 0, "If >0, refresh policy at this interval instead of daily time (only with --sheet-csv)")
 	templatesDir := fs.String("templates", "", "Optional templates directory to override embedded templates")
-	_ = fs.Parse(args)
-	var p *policy.Policy
-	var err error
-	if *sheetCSV != "" {
-		p, fromCache, err := sheets.FetchCSVPolicyCached(*sheetCSV, "", &http.Client{Timeout: 15 * 1e9})
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "invalid sheet csv: %v\n", err)
-			os.Exit(1)
-		}
+	localesDir := fs.String("locales", "", "Directory of <lang>.json message catalogs overriding or adding to the embedded ones (default: <templates>/locales when present)")
+	watch := fs.Bool("watch", false, "Reload the policy file when it changes on disk (only with --policy)")
+	watchInterval := fs.Duration("watch-interval", 2*time.Second, "Polling interval for --watch")
+	cacheDir := fs.String("cache-dir", "cache", "Directory for the sheet CSV cache and policy journal")
+	useJournal := fs.Bool("journal", true, "Record every accepted policy snapshot under <cache-dir>/journal")
+	gf := addGuardFlags(fs)
+	expiredClass := fs.String("expired-classification", "", "Grace mode: keep expired records enforced under this classification instead of dropping them")
+	lawURL := fs.String("law-url", "", "Target of the /law link (default: Ohio SB29 text; env SB29_LAW_URL)")
+	allowHost := fs.Bool("allow-host-fallback", false, "Use the Host header as a last-resort original domain on /explain (env SB29_ALLOW_HOST_FALLBACK)")
+	lf := addLimitFlags(fs)
+	tf := addTLSFlags(fs)
+	hf := addHitsFlags(fs)
+	af := addAuthFlags(fs)
+	rf := addReviewFlags(fs)
+	nf := addNotifyFlags(fs)
+	parseFlags(fs, args)
+	grace := checkExpiredClassification(*expiredClass)
+	j := openJournal(*cacheDir, *useJournal)
+	// run serves the loaded policy p until a signal arrives; updates, when set, keeps the policy
+	// current (sheet refresh or file watch) until the server stops.
+	run := func(p *policy.Policy, src string, updates func(target policyTarget, stop <-chan struct{})) {
 		// Build server with optional template overrides
 		var srv *server.Server
 		if *templatesDir != "" {
@@ -179,68 +539,284 @@ func cmdServe(args []string) {
 		} else {
 			srv = server.New(*listen, p)
 		}
-		src := "csv"
-		if fromCache {
-			src = "csv-cache"
+		locales, err := loadLocales(*localesDir, *templatesDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "locales error: %v\n", err)
+			os.Exit(1)
+		}
+		srv.SetLocales(locales)
+		srv.SetLawURL(*lawURL)
+		srv.SetAllowHostFallback(*allowHost)
+		srv.SetLimits(lf.limits())
+		if err := tf.apply(srv, *cacheDir); err != nil {
+			fmt.Fprintf(os.Stderr, "tls error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := af.apply(srv, tf.enabled()); err != nil {
+			fmt.Fprintf(os.Stderr, "auth error: %v\n", err)
+			os.Exit(1)
+		}
+		d, err := nf.build()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "notify error: %v\n", err)
+			os.Exit(1)
 		}
+		srv.SetNotifier(d)
+		target := nf.wrap(srv, d)
+		srv.SetJournal(j)
+		srv.SetPolicyTarget(target)
+		hf.apply(srv, *cacheDir)
+		rf.apply(srv, *cacheDir)
+		journalPolicy(j, p, src)
 		fmt.Printf("{\"event\":\"server.start\",\"listen\":%q,\"records\":%d,\"source\":%q}\n", *listen, len(p.Records), src)
-		// Start background refresh
-		go scheduleCSVRefresh(srv, *sheetCSV, *refreshAt, *refreshEvery)
-		if err := srv.Start(); err != nil {
+		if updates != nil {
+			go updates(target, srv.Done())
+		}
+		go reevaluateExpiryAtMidnight(target, j, srv.Done())
+		err = serveUntilSignal(srv, *lf.shutdownTimeout)
+		hf.close()
+		closeNotifier(d, *lf.shutdownTimeout)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "server error: %v\n", err)
 			os.Exit(1)
 		}
+	}
+	if *sheetCSV != "" {
+		guard := gf.build(*cacheDir)
+		p, src, err := loadSheetAtStartup(j, guard, *sheetCSV, *cacheDir, grace)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid sheet csv: %v\n", err)
+			os.Exit(1)
+		}
+		// Start background refresh
+		run(p, src, func(target policyTarget, stop <-chan struct{}) {
+			scheduleCSVRefresh(target, j, guard, *sheetCSV, *cacheDir, *refreshAt, *refreshEvery, stop)
+		})
 		return
 	}
-	data, rerr := os.ReadFile(*policyPath)
-	if rerr != nil {
-		fmt.Fprintf(os.Stderr, "error reading policy: %v\n", rerr)
+	p, err := loadPolicyFile(*policyPath)
+	if isReadError(err) {
+		fmt.Fprintf(os.Stderr, "error reading policy: %v\n", err)
 		os.Exit(2)
 	}
-	p, err = policy.Load(data)
+	if err == nil {
+		err = p.SetExpiredClassification(grace)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "invalid policy: %v\n", err)
 		os.Exit(1)
 	}
-	var srv *server.Server
-	if *templatesDir != "" {
-		t := templateFromDir(*templatesDir)
-		srv = server.NewWithTemplates(*listen, p, t, cssFromDir(*templatesDir))
-	} else {
-		srv = server.New(*listen, p)
+	var updates func(target policyTarget, stop <-chan struct{})
+	if *watch {
+		updates = func(target policyTarget, stop <-chan struct{}) {
+			watchPolicyFile(target, j, *policyPath, *watchInterval, stop)
+		}
 	}
-	fmt.Printf("{\"event\":\"server.start\",\"listen\":%q,\"records\":%d,\"source\":%q}\n", *listen, len(p.Records), "file")
-	if err := srv.Start(); err != nil {
-		fmt.Fprintf(os.Stderr, "server error: %v\n", err)
-		os.Exit(1)
+	run(p, "file", updates)
+}
+
+// limitFlags are the serve flags for connection limits and the shutdown grace period.
+type limitFlags struct {
+	readHeaderTimeout *time.Duration
+	readTimeout       *time.Duration
+	writeTimeout      *time.Duration
+	idleTimeout       *time.Duration
+	maxHeaderBytes    *int
+	shutdownTimeout   *time.Duration
+}
+
+func addLimitFlags(fs *flag.FlagSet) *limitFlags {
+	d := server.DefaultLimits
+	return &limitFlags{
+		readHeaderTimeout: fs.Duration("read-header-timeout", d.ReadHeaderTimeout, "Max time to read request headers (0 = no limit)"),
+		readTimeout:       fs.Duration("read-timeout", d.ReadTimeout, "Max time to read a whole request (0 = no limit)"),
+		writeTimeout:      fs.Duration("write-timeout", d.WriteTimeout, "Max time to write a response (0 = no limit)"),
+		idleTimeout:       fs.Duration("idle-timeout", d.IdleTimeout, "Max keep-alive idle time (0 = no limit)"),
+		maxHeaderBytes:    fs.Int("max-header-bytes", d.MaxHeaderBytes, "Max request header size in bytes (0 = net/http default)"),
+		shutdownTimeout:   fs.Duration("shutdown-timeout", 20*time.Second, "On SIGTERM/SIGINT, wait this long for in-flight requests before exiting"),
 	}
 }
 
-// scheduleCSVRefresh refreshes the policy either at a daily HH:MM time or every interval if provided.
-func scheduleCSVRefresh(srv *server.Server, csvURL, at string, every time.Duration) {
-	client := &http.Client{Timeout: 15 * time.Second}
-	// helper to perform one refresh
-	doRefresh := func() {
-		fmt.Printf("{\"event\":\"policy.refresh.start\",\"time\":%q}\n", time.Now().Format(time.RFC3339))
-		p, fromCache, err := sheets.FetchCSVPolicyCached(csvURL, "", client)
-		if err != nil {
-			fmt.Printf("{\"event\":\"policy.refresh.error\",\"message\":%q}\n", err.Error())
-			srv.RecordRefreshError(err.Error())
-			return
+func (f *limitFlags) limits() server.Limits {
+	return server.Limits{
+		ReadHeaderTimeout: *f.readHeaderTimeout,
+		ReadTimeout:       *f.readTimeout,
+		WriteTimeout:      *f.writeTimeout,
+		IdleTimeout:       *f.idleTimeout,
+		MaxHeaderBytes:    *f.maxHeaderBytes,
+	}
+}
+
+// serveUntilSignal runs srv until it fails or the process receives SIGINT/SIGTERM, then
+// drains in-flight requests for up to grace before returning.
+func serveUntilSignal(srv *server.Server, grace time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Start() }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	stop() // a second signal kills the process
+	fmt.Printf("{\"event\":\"server.shutdown\",\"grace\":%q}\n", grace.String())
+	sctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	err := srv.Shutdown(sctx)
+	if serr := <-errc; err == nil {
+		err = serr
+	}
+	if err == nil {
+		fmt.Printf("{\"event\":\"server.stopped\"}\n")
+	}
+	return err
+}
+
+// policyTarget is anything that can hot-swap its policy and record refresh outcomes
+// (the HTTP server and the DNS responder).
+type policyTarget interface {
+	UpdatePolicy(p *policy.Policy)
+	CurrentPolicy() *policy.Policy
+	RecordRefreshSuccess(source string)
+	RecordRefreshError(reason, msg string)
+}
+
+// Refresh error reasons, as counted by RecordRefreshError.
+const (
+	refreshFetchError    = "fetch_error"    // the sheet could not be fetched or parsed
+	refreshGuardRejected = "guard_rejected" // the refresh guard held a fetched sheet back
+	refreshWatchError    = "watch_error"    // a watched policy file could not be read
+	refreshInvalidPolicy = "invalid_policy" // a reloaded policy file failed to load or validate
+)
+
+// openJournal opens the snapshot journal under cacheDir; failures are logged and journaling is skipped.
+func openJournal(cacheDir string, enabled bool) *journal.Journal {
+	if !enabled {
+		return nil
+	}
+	j, err := journal.Open(cacheDir)
+	if err != nil {
+		fmt.Printf("{\"event\":\"policy.journal.error\",\"message\":%q}\n", err.Error())
+		return nil
+	}
+	return j
+}
+
+// journalPolicy records p in j (when set); a new entry is logged, repeats of the current hash are not.
+func journalPolicy(j *journal.Journal, p *policy.Policy, source string) {
+	if j == nil {
+		return
+	}
+	e, added, err := j.Record(p, source)
+	if err != nil {
+		fmt.Printf("{\"event\":\"policy.journal.error\",\"message\":%q}\n", err.Error())
+		return
+	}
+	if added {
+		fmt.Printf("{\"event\":\"policy.journal.record\",\"hash\":%q,\"source\":%q,\"records\":%d}\n", e.Hash, e.Source, e.Records)
+	}
+}
+
+// acceptPolicy activates a freshly loaded policy on srv, records the refresh and journals the snapshot.
+func acceptPolicy(srv policyTarget, j *journal.Journal, p *policy.Policy, source string) {
+	srv.UpdatePolicy(p)
+	srv.RecordRefreshSuccess(source)
+	journalPolicy(j, p, source)
+}
+
+// guardFlags holds the refresh guardrail flags shared by serve and dns-serve.
+type guardFlags struct {
+	maxDropPercent  *float64
+	maxDrop         *int
+	maxReclassified *int
+	requireTags     *string
+	ackHash         *string
+	ackFile         *string
+}
+
+func addGuardFlags(fs *flag.FlagSet) *guardFlags {
+	return &guardFlags{
+		maxDropPercent:  fs.Float64("guard-max-drop-percent", 50, "Reject a sheet refresh that shrinks active records by more than this percent (0 = off)"),
+		maxDrop:         fs.Int("guard-max-drop", 0, "Reject a sheet refresh that removes more than this many active records (0 = off)"),
+		maxReclassified: fs.Int("guard-max-reclassified", 0, "Reject a sheet refresh that reclassifies more than this many domains (0 = off)"),
+		requireTags:     fs.String("guard-require-tags", "", "Comma-separated tags that at least one active record must carry"),
+		ackHash:         fs.String("guard-ack", "", "Accept a guard-rejected refresh whose policy hash starts with this value"),
+		ackFile:         fs.String("guard-ack-file", "", "Acknowledgement file that lets the next rejected refresh through (default <cache-dir>/guard-ack)"),
+	}
+}
+
+func (f *guardFlags) build(cacheDir string) *refreshGuard {
+	g := &refreshGuard{
+		rails: policy.Guardrails{
+			MaxDropPercent:  *f.maxDropPercent,
+			MaxDrop:         *f.maxDrop,
+			MaxReclassified: *f.maxReclassified,
+		},
+		ackHash: strings.ToLower(strings.TrimSpace(*f.ackHash)),
+		ackFile: *f.ackFile,
+	}
+	for _, t := range strings.Split(*f.requireTags, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			g.rails.RequiredTags = append(g.rails.RequiredTags, t)
 		}
-		srv.UpdatePolicy(p)
-		src := "csv"
-		if fromCache {
-			src = "csv-cache"
+	}
+	if g.ackFile == "" {
+		g.ackFile = filepath.Join(cacheDir, "guard-ack")
+	}
+	return g
+}
+
+// refreshGuard applies policy.Guardrails to sheet refreshes. A rejected policy can still be
+// accepted deliberately: via --guard-ack <hash prefix>, or by creating the acknowledgement file
+// (empty to accept the next rejected refresh, or holding a hash prefix). The file is removed once used.
+type refreshGuard struct {
+	rails   policy.Guardrails
+	ackHash string
+	ackFile string
+}
+
+// admit returns nil when p may replace active, or the guard violation otherwise.
+func (g *refreshGuard) admit(active, p *policy.Policy) error {
+	if g == nil {
+		return nil
+	}
+	err := g.rails.Check(active, p)
+	if err == nil {
+		return nil
+	}
+	h := p.CanonicalHash()
+	if g.ackHash != "" && strings.HasPrefix(h, g.ackHash) {
+		fmt.Printf("{\"event\":\"policy.guard.acknowledged\",\"via\":\"flag\",\"hash\":%q,\"message\":%q}\n", h, err.Error())
+		return nil
+	}
+	if b, rerr := os.ReadFile(g.ackFile); rerr == nil {
+		want := strings.ToLower(strings.TrimSpace(string(b)))
+		if want == "" || strings.HasPrefix(h, want) {
+			_ = os.Remove(g.ackFile)
+			fmt.Printf("{\"event\":\"policy.guard.acknowledged\",\"via\":\"file\",\"hash\":%q,\"message\":%q}\n", h, err.Error())
+			return nil
 		}
-		srv.RecordRefreshSuccess(src)
-		fmt.Printf("{\"event\":\"policy.refresh.success\",\"records\":%d,\"source\":%q,\"version\":%q}\n", len(p.Records), src, p.Version)
 	}
+	return err
+}
+
+// scheduleCSVRefresh refreshes the policy either at a daily HH:MM time or every interval if provided.
+// Closing stop ends the loop.
+func scheduleCSVRefresh(srv policyTarget, j *journal.Journal, g *refreshGuard, csvURL, cacheDir, at string, every time.Duration, stop <-chan struct{}) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	doRefresh := func() { refreshCSVOnce(srv, j, g, client, csvURL, cacheDir) }
 
 	if every > 0 {
 		fmt.Printf("{\"event\":\"policy.refresh.mode\",\"interval\":%q}\n", every.String())
 		ticker := time.NewTicker(every)
-		for range ticker.C {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
 			doRefresh()
 		}
 	} else {
@@ -254,12 +830,104 @@ func scheduleCSVRefresh(srv *server.Server, csvURL, at string, every time.Durati
 		for {
 			next := nextDailyTime(hour, minute)
 			fmt.Printf("{\"event\":\"policy.refresh.scheduled\",\"next\":%q}\n", next.Format(time.RFC3339))
-			time.Sleep(time.Until(next))
+			t := time.NewTimer(time.Until(next))
+			select {
+			case <-stop:
+				t.Stop()
+				return
+			case <-t.C:
+			}
 			doRefresh()
 		}
 	}
 }
 
+// refreshCSVOnce fetches the sheet, runs it past the guard and activates it on success. The new
+// policy keeps the grace class of the one it replaces.
+func refreshCSVOnce(srv policyTarget, j *journal.Journal, g *refreshGuard, client *http.Client, csvURL, cacheDir string) {
+	fmt.Printf("{\"event\":\"policy.refresh.start\",\"time\":%q}\n", time.Now().Format(time.RFC3339))
+	// Admission happens inside the fetch so a rejected sheet never reaches the CSV cache.
+	var rejected *policy.Policy
+	p, fromCache, err := sheets.FetchCSVPolicyAdmitted(csvURL, cacheDir, client, func(np *policy.Policy) error {
+		active := srv.CurrentPolicy()
+		if err := np.SetExpiredClassification(active.ExpiredClassification()); err != nil {
+			return err
+		}
+		err := g.admit(active, np)
+		if err != nil {
+			rejected = np
+		}
+		return err
+	})
+	if rejected != nil {
+		logGuardRejection(rejected, err)
+		srv.RecordRefreshError(refreshGuardRejected, err.Error())
+		return
+	}
+	if err != nil {
+		fmt.Printf("{\"event\":\"policy.refresh.error\",\"message\":%q}\n", err.Error())
+		srv.RecordRefreshError(refreshFetchError, err.Error())
+		return
+	}
+	src := "csv"
+	if fromCache {
+		src = "csv-cache"
+	}
+	acceptPolicy(srv, j, p, src)
+	fmt.Printf("{\"event\":\"policy.refresh.success\",\"records\":%d,\"source\":%q,\"version\":%q}\n", len(p.Records), src, p.Version)
+}
+
+// loadSheetAtStartup fetches the sheet that serve and dns-serve start with. When the journal
+// holds an earlier accepted snapshot the sheet must pass the refresh guard against it, so a
+// restart cannot activate a refresh the guard rejected; a rejected sheet leaves that snapshot active.
+// Either policy gets the grace class.
+func loadSheetAtStartup(j *journal.Journal, g *refreshGuard, csvURL, cacheDir, grace string) (*policy.Policy, string, error) {
+	var last *policy.Policy
+	if j != nil {
+		var err error
+		if last, err = j.Latest(); err == nil && last != nil {
+			err = last.SetExpiredClassification(grace)
+		}
+		if err != nil {
+			fmt.Printf("{\"event\":\"policy.journal.error\",\"message\":%q}\n", err.Error())
+			last = nil
+		}
+	}
+	var rejected *policy.Policy
+	p, fromCache, err := sheets.FetchCSVPolicyAdmitted(csvURL, cacheDir, &http.Client{Timeout: 15 * time.Second}, func(np *policy.Policy) error {
+		if err := np.SetExpiredClassification(grace); err != nil {
+			return err
+		}
+		if last == nil {
+			return nil
+		}
+		err := g.admit(last, np)
+		if err != nil {
+			rejected = np
+		}
+		return err
+	})
+	switch {
+	case rejected != nil:
+		logGuardRejection(rejected, err)
+		return last, "journal", nil
+	case err != nil:
+		return nil, "", err
+	case fromCache:
+		return p, "csv-cache", nil
+	}
+	return p, "csv", nil
+}
+
+// logGuardRejection reports a sheet the refresh guard refused.
+func logGuardRejection(p *policy.Policy, err error) {
+	reason := ""
+	if v, ok := err.(*policy.GuardViolation); ok {
+		reason = v.Reason
+	}
+	fmt.Printf("{\"event\":\"policy.refresh.rejected\",\"reason\":%q,\"hash\":%q,\"message\":%q}\n", reason, p.CanonicalHash(), err.Error())
+}
+
 func nextDailyTime(hour, minute int) time.Time {
 	now := time.Now()
 	loc := now.Location()
@@ -270,6 +938,246 @@ func nextDailyTime(hour, minute int) time.Time {
 	return candidate.Add(24 * time.Hour)
 }
 
+// policyFileState captures what the watcher knows about the policy files between polls.
+type policyFileState struct {
+	files []string               // root policy first, then the local files it includes
+	infos map[string]os.FileInfo // by path; nil until every file was stat'ed once
+	sum   string                 // content hash over all files
+}
+
+// watchPolicyFile polls path and every local file it includes each interval, and hot-swaps the server
+// policy when any of their content changes. os.Stat follows symlinks and os.SameFile compares inodes, so
+// atomic rename-style saves from editors and Kubernetes ConfigMap symlink swaps are detected as well as
+// in-place writes. A policy that fails to load or validate is reported via RecordRefreshError and the
+// last good policy stays active. Closing stop ends the loop.
+func watchPolicyFile(srv policyTarget, j *journal.Journal, path string, interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	last := policyFileState{files: watchedFiles(path, srv.CurrentPolicy())}
+	if infos, err := statPolicyFiles(last.files); err == nil {
+		last.infos = infos
+		last.sum, _ = sumPolicyFiles(last.files)
+	}
+	fmt.Printf("{\"event\":\"policy.watch.mode\",\"path\":%q,\"files\":%d,\"interval\":%q}\n", path, len(last.files), interval.String())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			last = pollPolicyFile(srv, j, path, last)
+		}
+	}
+}
+
+// pollPolicyFile performs one watch iteration and returns the state to compare against next time.
+func pollPolicyFile(srv policyTarget, j *journal.Journal, path string, last policyFileState) policyFileState {
+	files := last.files
+	if len(files) == 0 {
+		files = []string{path}
+	}
+	infos, err := statPolicyFiles(files)
+	if err != nil {
+		// Mid-swap a path can briefly disappear; only report once per outage.
+		if last.infos != nil {
+			fmt.Printf("{\"event\":\"policy.watch.error\",\"message\":%q}\n", err.Error())
+			srv.RecordRefreshError(refreshWatchError, err.Error())
+		}
+		return policyFileState{files: files, sum: last.sum}
+	}
+	if last.infos != nil && sameFileInfos(last.infos, infos) {
+		return last
+	}
+	sum, err := sumPolicyFiles(files)
+	if err != nil {
+		fmt.Printf("{\"event\":\"policy.watch.error\",\"message\":%q}\n", err.Error())
+		srv.RecordRefreshError(refreshWatchError, err.Error())
+		return policyFileState{files: files, infos: infos, sum: last.sum}
+	}
+	if sum == last.sum {
+		return policyFileState{files: files, infos: infos, sum: sum}
+	}
+	p, err := loadPolicyFile(path)
+	if err == nil {
+		err = p.SetExpiredClassification(srv.CurrentPolicy().ExpiredClassification())
+	}
+	if err == nil {
+		err = p.Validate()
+	}
+	if err != nil {
+		fmt.Printf("{\"event\":\"policy.refresh.error\",\"source\":\"file\",\"message\":%q}\n", err.Error())
+		srv.RecordRefreshError(refreshInvalidPolicy, err.Error())
+		// Remember the bad content so it is reported once, not on every tick.
+		return policyFileState{files: files, infos: infos, sum: sum}
+	}
+	acceptPolicy(srv, j, p, "file")
+	fmt.Printf("{\"event\":\"policy.refresh.success\",\"records\":%d,\"source\":\"file\",\"version\":%q}\n", len(p.Records), p.Version)
+	next := policyFileState{files: watchedFiles(path, p), infos: infos, sum: sum}
+	if !slices.Equal(next.files, files) {
+		// Includes were added or removed: start tracking the new set.
+		next.infos, _ = statPolicyFiles(next.files)
+		next.sum, _ = sumPolicyFiles(next.files)
+	}
+	return next
+}
+
+// watchedFiles returns the files behind p (see policy.Policy.Files), or just path when unknown.
+func watchedFiles(path string, p *policy.Policy) []string {
+	if p == nil || len(p.Files) == 0 {
+		return []string{path}
+	}
+	return p.Files
+}
+
+func statPolicyFiles(files []string) (map[string]os.FileInfo, error) {
+	infos := make(map[string]os.FileInfo, len(files))
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return nil, err
+		}
+		infos[f] = fi
+	}
+	return infos, nil
+}
+
+func sameFileInfos(a, b map[string]os.FileInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for f, x := range a {
+		y, ok := b[f]
+		if !ok || !os.SameFile(x, y) || !x.ModTime().Equal(y.ModTime()) || x.Size() != y.Size() {
+			return false
+		}
+	}
+	return true
+}
+
+// sumPolicyFiles hashes the content of every file, in order.
+func sumPolicyFiles(files []string) (string, error) {
+	var sb strings.Builder
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "%s\x00%s\n", f, hash.SHA256Hex(b))
+	}
+	return hash.SHA256Hex([]byte(sb.String())), nil
+}
+
+// cmdDNSServe runs the built-in DNS responder: policy-matched names get the redirect answer,
+// everything else is forwarded to --upstream (or refused when no upstream is set).
+func cmdDNSServe(args []string) {
+	fs := flag.NewFlagSet("dns-serve", flag.ExitOnError)
+	policyPath := fs.String("policy", "policy/domains.yaml", "Path to policy file")
+	sheetCSV := fs.String("sheet-csv", "", "Published Google Sheet CSV URL (overrides --policy)")
+	listen := fs.String("listen", ":53", "Listen address host:port (UDP and TCP)")
+	upstream := fs.String("upstream", "", "Upstream resolver host[:port] for names not in the policy (empty = REFUSED)")
+	mode := fs.String("mode", "a-record", "Mode a-record|cname")
+	redirectIPv4 := fs.String("redirect-ipv4", "", "Redirect IPv4 address (a-record mode needs this and/or --redirect-ipv6)")
+	redirectIPv6 := fs.String("redirect-ipv6", "", "Redirect IPv6 address for AAAA answers (optional)")
+	redirectHost := fs.String("redirect-host", "blocked.guard.local", "Redirect host (for cname mode)")
+	ttl := fs.Int("ttl", 300, "Answer TTL seconds")
+	refreshAt := fs.String("refresh-at", "23:59", "Daily refresh time local (HH:MM), only with --sheet-csv")
+	refreshEvery := fs.Duration("refresh-every", 0, "If >0, refresh policy at this interval instead of daily time (only with --sheet-csv)")
+	watch := fs.Bool("watch", false, "Reload the policy file when it changes on disk (only with --policy)")
+	watchInterval := fs.Duration("watch-interval", 2*time.Second, "Polling interval for --watch")
+	cacheDir := fs.String("cache-dir", "cache", "Directory for the sheet CSV cache and policy journal")
+	useJournal := fs.Bool("journal", true, "Record every accepted policy snapshot under <cache-dir>/journal")
+	gf := addGuardFlags(fs)
+	expiredClass := fs.String("expired-classification", "", "Grace mode: keep expired records enforced under this classification instead of dropping them")
+	scope := fs.String("scope", "", "Only answer for this audience's records (e.g. students), one responder per VLAN")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 20*time.Second, "On SIGTERM/SIGINT, wait this long for queued notifications before exiting")
+	nf := addNotifyFlags(fs)
+	parseFlags(fs, args)
+	*scope = policy.NormalizeScope(*scope)
+	grace := checkExpiredClassification(*expiredClass)
+	j := openJournal(*cacheDir, *useJournal)
+	guard := gf.build(*cacheDir)
+	var p *policy.Policy
+	var err error
+	src := "file"
+	if *sheetCSV != "" {
+		p, src, err = loadSheetAtStartup(j, guard, *sheetCSV, *cacheDir, grace)
+	} else {
+		p, err = loadPolicyFromInputs(*policyPath, "")
+	}
+	if err == nil {
+		err = p.SetExpiredClassification(grace)
+	}
+	if err == nil {
+		err = p.Validate()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid policy: %v\n", err)
+		os.Exit(1)
+	}
+	opts := dnsgen.Options{Mode: *mode, RedirectIPv4: *redirectIPv4, RedirectIPv6: *redirectIPv6, RedirectHost: *redirectHost, TTL: *ttl, Scope: *scope}
+	srv, err := dnsserve.New(*listen, p, opts, *upstream)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dns-serve error: %v\n", err)
+		os.Exit(2)
+	}
+	journalPolicy(j, p, src)
+	d, err := nf.build()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "notify error: %v\n", err)
+		os.Exit(1)
+	}
+	target := nf.wrap(srv, d)
+	// The policy loops run until the responder stops, and must be done before the notifier closes.
+	stop := make(chan struct{})
+	var loops sync.WaitGroup
+	startLoop := func(loop func()) {
+		loops.Add(1)
+		go func() {
+			defer loops.Done()
+			loop()
+		}()
+	}
+	if *sheetCSV != "" {
+		startLoop(func() { scheduleCSVRefresh(target, j, guard, *sheetCSV, *cacheDir, *refreshAt, *refreshEvery, stop) })
+	} else if *watch {
+		startLoop(func() { watchPolicyFile(target, j, *policyPath, *watchInterval, stop) })
+	}
+	startLoop(func() { reevaluateExpiryAtMidnight(target, j, stop) })
+	fmt.Printf("{\"event\":\"dns.start\",\"listen\":%q,\"records\":%d,\"source\":%q,\"mode\":%q,\"upstream\":%q}\n", *listen, len(p.Records), src, *mode, *upstream)
+	err = dnsServeUntilSignal(srv)
+	close(stop)
+	loops.Wait()
+	closeNotifier(d, *shutdownTimeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dns server error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// dnsServeUntilSignal runs srv until it fails or the process receives SIGINT/SIGTERM, then
+// closes its listeners and returns.
+func dnsServeUntilSignal(srv *dnsserve.Server) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Start() }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	stop() // a second signal kills the process
+	fmt.Printf("{\"event\":\"dns.shutdown\"}\n")
+	_ = srv.Close()
+	err := <-errc
+	if err == nil {
+		fmt.Printf("{\"event\":\"dns.stopped\"}\n")
+	}
+	return err
+}
+
 func cmdGenerateDNS(args []string) {
 	fs := flag.NewFlagSet("generate-dns", flag.ExitOnError)
 	policyPath := fs.String("policy", "policy/domains.yaml", "Path to policy file")
@@ -277,12 +1185,19 @@ func cmdGenerateDNS(args []string) {
 	out := fs.String("out", "", "Output file path (required unless --dry-run)")
 	format := fs.String("format", "hosts", "Output format: hosts|bind|unbound|rpz|dnsmasq|domain-list|winps")
 	mode := fs.String("mode", "a-record", "Mode a-record|cname")
-	redirectIPv4 := fs.String("redirect-ipv4", "", "Redirect IPv4 address (required for a-record/hosts)")
+	redirectIPv4 := fs.String("redirect-ipv4", "", "Redirect IPv4 address (a-record/hosts need this and/or --redirect-ipv6)")
+	redirectIPv6 := fs.String("redirect-ipv6", "", "Redirect IPv6 address; emits AAAA/IPv6 entries alongside or instead of IPv4")
 	redirectHost := fs.String("redirect-host", "blocked.guard.local", "Redirect host (for cname mode)")
 	ttl := fs.Int("ttl", 300, "Record TTL seconds")
 	serialStrategy := fs.String("serial-strategy", "date", "Serial strategy for bind/rpz: date|epoch|hash")
 	dryRun := fs.Bool("dry-run", false, "Print to stdout instead of writing file")
-	_ = fs.Parse(args)
+	hostsExpand := fs.String("hosts-expand", "", "File of known subdomains (one FQDN per line) to list explicitly for wildcard records in hosts format")
+	expiredClass := fs.String("expired-classification", "", "Grace mode: keep expired records enforced under this classification instead of dropping them")
+	scope := fs.String("scope", "", "Only emit records for this audience (e.g. students); records without scopes apply to all")
+	fs.String("preset", "", "Apply the named dns.presets entry from the --config file")
+	parseFlags(fs, args)
+	*scope = policy.NormalizeScope(*scope)
+	grace := checkExpiredClassification(*expiredClass)
 	var p *policy.Policy
 	var err error
 	if *sheetCSV != "" {
@@ -296,18 +1211,36 @@ func cmdGenerateDNS(args []string) {
 			fmt.Fprintln(os.Stderr, "--policy required (or use --sheet-csv)")
 			os.Exit(2)
 		}
-		data, rerr := os.ReadFile(*policyPath)
-		if rerr != nil {
-			fmt.Fprintf(os.Stderr, "error reading policy: %v\n", rerr)
+		p, err = loadPolicyFile(*policyPath)
+		if isReadError(err) {
+			fmt.Fprintf(os.Stderr, "error reading policy: %v\n", err)
 			os.Exit(2)
 		}
-		p, err = policy.Load(data)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "invalid policy: %v\n", err)
 			os.Exit(1)
 		}
 	}
-	opts := dnsgen.Options{Format: *format, Mode: *mode, RedirectIPv4: *redirectIPv4, RedirectHost: *redirectHost, TTL: *ttl, SerialStrategy: *serialStrategy}
+	if err := p.SetExpiredClassification(grace); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid policy: %v\n", err)
+		os.Exit(1)
+	}
+	opts := dnsgen.Options{Format: *format, Mode: *mode, RedirectIPv4: *redirectIPv4, RedirectIPv6: *redirectIPv6, RedirectHost: *redirectHost, TTL: *ttl, SerialStrategy: *serialStrategy, Scope: *scope}
+	if *hostsExpand != "" {
+		opts.HostsExpand, err = readLines(*hostsExpand)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading hosts-expand: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if *format == "hosts" {
+		for _, r := range p.ActiveRecords(time.Now()) {
+			if strings.HasPrefix(r.Domain, "*.") {
+				fmt.Fprintln(os.Stderr, "warning: hosts format cannot express wildcards; wildcard records only cover their base name plus --hosts-expand entries")
+				break
+			}
+		}
+	}
 	content, err := dnsgen.Generate(p, opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "generation error: %v\n", err)
@@ -332,6 +1265,23 @@ func cmdGenerateDNS(args []string) {
 	fmt.Printf("{\"status\":\"ok\",\"format\":%q,\"mode\":%q,\"bytes\":%d}\n", *format, *mode, len(content))
 }
 
+// readLines returns the non-empty, non-comment (#) lines of a text file, trimmed.
+func readLines(path string) ([]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return out, nil
+}
+
 func dirOf(path string) string {
 	for i := len(path) - 1; i >= 0; i-- {
 		if path[i] == '/' || path[i] == '\\' {
@@ -383,13 +1333,17 @@ func cmdGenerateProxy(args []string) {
 	policyPath := fs.String("policy", "", "Policy file to derive selective routing map (optional)")
 	sheetCSV := fs.String("sheet-csv", "", "Published Google Sheet CSV URL to derive map (optional)")
 	redirectUnknown := fs.Bool("redirect-unknown", false, "In nginx bundle, intercept 404 from guard and redirect to static explain at --explain-url?d=$host")
-	_ = fs.Parse(args)
+	expiredClass := fs.String("expired-classification", "", "Grace mode: keep expired records enforced under this classification instead of dropping them")
+	scope := fs.String("scope", "", "Only include this audience's records in the host map (e.g. students)")
+	parseFlags(fs, args)
+	*scope = policy.NormalizeScope(*scope)
+	grace := checkExpiredClassification(*expiredClass)
 
 	// Bundle mode for nginx
 	if *bundleDir != "" {
 		switch strings.ToLower(*format) {
 		case "nginx":
-			if err := writeNginxBundle(*bundleDir, *mode, *siteHost, *backendURL, *explainURL, *tlsCert, *tlsKey, *policyPath, *sheetCSV, *redirectUnknown); err != nil {
+			if err := writeNginxBundle(*bundleDir, *mode, *siteHost, *backendURL, *explainURL, *tlsCert, *tlsKey, *policyPath, *sheetCSV, *scope, grace, *redirectUnknown); err != nil {
 				fmt.Fprintf(os.Stderr, "bundle error: %v\n", err)
 				os.Exit(1)
 			}
@@ -399,7 +1353,7 @@ func cmdGenerateProxy(args []string) {
 				os.Exit(1)
 			}
 		case "haproxy":
-			if err := writeHAProxyBundle(*bundleDir, *mode, *siteHost, *backendURL, *explainURL, *policyPath, *sheetCSV); err != nil {
+			if err := writeHAProxyBundle(*bundleDir, *mode, *siteHost, *backendURL, *explainURL, *policyPath, *sheetCSV, *scope, grace); err != nil {
 				fmt.Fprintf(os.Stderr, "bundle error: %v\n", err)
 				os.Exit(1)
 			}
@@ -445,6 +1399,8 @@ func renderProxySnippet(format, mode, siteHost, backendURL, explainURL string) (
 			return fmt.Sprintf(`# Caddyfile: explanatory vhost for blocked traffic
 %s {
 	encode zstd gzip
+	# Admin endpoints are for operators on the guard host, not proxied clients.
+	respond /admin* 403
 	@all {
 		path *
 	}
@@ -469,6 +1425,10 @@ func renderProxySnippet(format, mode, siteHost, backendURL, explainURL string) (
 server {
 	listen 80;
 	server_name %s;
+	# Admin endpoints are for operators on the guard host, not proxied clients.
+	location ^~ /admin {
+		return 403;
+	}
 	location / {
 		proxy_set_header X-Original-Host $host;
 		proxy_set_header X-Forwarded-Host $host;
@@ -499,6 +1459,7 @@ frontend fe_explain
 
 backend be_guard
 	mode http
+	http-request deny deny_status 403 if { path_beg /admin }
 	http-request set-header X-Original-Host %%[req.hdr(host)]
 	http-request set-header X-Forwarded-Host %%[req.hdr(host)]
 	server s1 %s
@@ -521,6 +1482,9 @@ frontend fe_explain
 	ProxyPreserveHost On
 	RequestHeader set X-Original-Host "%%{Host}i"
 	RequestHeader set X-Forwarded-Host "%%{Host}i"
+	<Location "/admin">
+		Require all denied
+	</Location>
 	ProxyPass / %s
 	ProxyPassReverse / %s
 </VirtualHost>
@@ -546,7 +1510,7 @@ func ensureTrailingSlash(u string) string {
 }
 
 // writeNginxBundle assembles a ready-to-use directory with site.conf, optional blocked_map.conf, smoke.ps1, and README.md
-func writeNginxBundle(dir, mode, siteHost, backendURL, explainURL, tlsCert, tlsKey, policyPath, sheetCSV string, redirectUnknown bool) error {
+func writeNginxBundle(dir, mode, siteHost, backendURL, explainURL, tlsCert, tlsKey, policyPath, sheetCSV, scope, grace string, redirectUnknown bool) error {
 	// Create dir
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return fmt.Errorf("mkdir %s: %w", dir, err)
@@ -567,6 +1531,8 @@ func writeNginxBundle(dir, mode, siteHost, backendURL, explainURL, tlsCert, tlsK
 			fmt.Fprintln(sb, "    proxy_intercept_errors on;")
 			fmt.Fprintln(sb, "    error_page 404 = @static_explain;")
 		}
+		// Admin endpoints are for operators on the guard host, not proxied clients.
+		fmt.Fprintln(sb, "    location ^~ /admin { return 403; }")
 		fmt.Fprintln(sb, "    location / {")
 		fmt.Fprintln(sb, "        proxy_set_header X-Original-Host $host;")
 		fmt.Fprintln(sb, "        proxy_set_header X-Forwarded-Host $host;")
@@ -586,7 +1552,7 @@ func writeNginxBundle(dir, mode, siteHost, backendURL, explainURL, tlsCert, tlsK
 	// Optional: write blocked_map.conf if policy provided
 	var mapContent string
 	if strings.TrimSpace(policyPath) != "" || strings.TrimSpace(sheetCSV) != "" {
-		p, err := loadPolicyFromInputs(policyPath, sheetCSV)
+		p, err := loadScopedPolicy(policyPath, sheetCSV, scope, grace)
 		if err != nil {
 			return fmt.Errorf("load policy: %w", err)
 		}
@@ -595,18 +1561,15 @@ func writeNginxBundle(dir, mode, siteHost, backendURL, explainURL, tlsCert, tlsK
 		fmt.Fprintln(mc, "# Example usage: if ($sb29_blocked) { return 302 https://your-guard-host/; }")
 		fmt.Fprintln(mc, "map $host $sb29_blocked {")
 		fmt.Fprintln(mc, "    default 0;")
-		for _, r := range p.Records {
-			if strings.TrimSpace(r.Status) == "suspended" {
-				continue
-			}
-			d := strings.ToLower(strings.TrimSpace(r.Domain))
-			if strings.HasPrefix(d, "*.") {
-				base := strings.TrimPrefix(d, "*.")
+		// nginx checks exact names before regexes and regexes in order, so exemptions (0)
+		// win the same way they do in Lookup once the rules are listed most specific first.
+		for _, r := range proxyMapRules(p) {
+			if r.wildcard {
 				// Regex: match base domain or any subdomain of it
-				re := "~^(?:.*\\.)?" + nginxRegexEscape(base) + "$"
-				fmt.Fprintf(mc, "    %s 1;\n", re)
+				re := "~^(?:.*\\.)?" + nginxRegexEscape(r.name) + "$"
+				fmt.Fprintf(mc, "    %s %d;\n", re, r.value())
 			} else {
-				fmt.Fprintf(mc, "    %s 1;\n", d)
+				fmt.Fprintf(mc, "    %s %d;\n", r.name, r.value())
 			}
 		}
 		fmt.Fprintln(mc, "}")
@@ -648,6 +1611,44 @@ try {
 	return nil
 }
 
+// proxyMapRule is one host rule for the nginx/HAProxy maps: name is the exact host or the
+// base of a wildcard, and exempt marks an allow record.
+type proxyMapRule struct {
+	name     string
+	wildcard bool
+	exempt   bool
+}
+
+func (r proxyMapRule) value() int {
+	if r.exempt {
+		return 0
+	}
+	return 1
+}
+
+// proxyMapRules returns the policy's enforced rules ordered most specific first (more labels
+// first, exact before wildcard for the same name), the order first-match proxies need to
+// reproduce Lookup precedence.
+func proxyMapRules(p *policy.Policy) []proxyMapRule {
+	var out []proxyMapRule
+	for _, r := range p.ActiveRecords(time.Now()) {
+		d := strings.ToLower(strings.TrimSpace(r.Domain))
+		base, wildcard := strings.CutPrefix(d, "*.")
+		out = append(out, proxyMapRule{name: base, wildcard: wildcard, exempt: r.Exempt()})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		li, lj := strings.Count(out[i].name, "."), strings.Count(out[j].name, ".")
+		if li != lj {
+			return li > lj
+		}
+		if out[i].name != out[j].name {
+			return out[i].name < out[j].name
+		}
+		return !out[i].wildcard && out[j].wildcard
+	})
+	return out
+}
+
 func nginxRegexEscape(s string) string {
 	// Escape dots and other regex meta; keep hyphens
 	replacer := strings.NewReplacer(
@@ -678,11 +1679,51 @@ func loadPolicyFromInputs(policyPath, sheetCSV string) (*policy.Policy, error) {
 	if strings.TrimSpace(policyPath) == "" {
 		return nil, fmt.Errorf("--policy or --sheet-csv required for map generation")
 	}
-	b, err := os.ReadFile(policyPath)
+	return loadPolicyFile(policyPath)
+}
+
+// loadScopedPolicy loads the policy for the proxy maps with the grace class applied, restricted to one
+// audience when scope is set.
+func loadScopedPolicy(policyPath, sheetCSV, scope, grace string) (*policy.Policy, error) {
+	p, err := loadPolicyFromInputs(policyPath, sheetCSV)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.SetExpiredClassification(grace); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if !p.HasScope(scope) {
+		return nil, fmt.Errorf("unknown scope: %s", scope)
+	}
+	return p.ForScope(scope), nil
+}
+
+// loadPolicyFile loads a YAML policy and resolves its includes and overrides; CSV includes
+// (sheet URLs or .csv paths) are fetched with the usual cache / parsed from disk.
+func loadPolicyFile(path string) (*policy.Policy, error) {
+	return policy.LoadFile(path, fetchIncludedCSV)
+}
+
+func fetchIncludedCSV(ref string) (*policy.Policy, error) {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		p, _, err := sheets.FetchCSVPolicyCached(ref, "", &http.Client{Timeout: 15 * 1e9})
+		return p, err
+	}
+	b, err := os.ReadFile(ref)
 	if err != nil {
 		return nil, err
 	}
-	return policy.Load(b)
+	return sheets.ParseCSVPolicy(b)
+}
+
+// isReadError reports whether err came from reading a policy file (exit code 2) rather than
+// from its content.
+func isReadError(err error) bool {
+	var pe *fs.PathError
+	return errors.As(err, &pe)
 }
 
 // writeCaddyBundle emits a minimal Caddyfile and README
@@ -694,6 +1735,8 @@ func writeCaddyBundle(dir, mode, siteHost, backendURL, explainURL string) error
 	if strings.ToLower(mode) == "header-injection" {
 		caddy = fmt.Sprintf(`%s {
 	encode zstd gzip
+	# Admin endpoints are for operators on the guard host, not proxied clients.
+	respond /admin* 403
 	@all {
 		path *
 	}
@@ -718,7 +1761,7 @@ func writeCaddyBundle(dir, mode, siteHost, backendURL, explainURL string) error
 }
 
 // writeHAProxyBundle emits haproxy.cfg and optional map of blocked hosts
-func writeHAProxyBundle(dir, mode, siteHost, backendURL, explainURL, policyPath, sheetCSV string) error {
+func writeHAProxyBundle(dir, mode, siteHost, backendURL, explainURL, policyPath, sheetCSV, scope, grace string) error {
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return err
 	}
@@ -729,7 +1772,7 @@ func writeHAProxyBundle(dir, mode, siteHost, backendURL, explainURL, policyPath,
 	fmt.Fprintf(cfg, "frontend fe_guard\n    bind *:80\n    acl vhost hdr(host) -i %s\n", siteHost)
 	if strings.ToLower(mode) == "header-injection" {
 		fmt.Fprintln(cfg, "    use_backend be_guard if vhost")
-		fmt.Fprintf(cfg, "backend be_guard\n    http-request set-header X-Original-Host %%[req.hdr(host)]\n    http-request set-header X-Forwarded-Host %%[req.hdr(host)]\n    server s1 %s\n", strings.TrimPrefix(strings.TrimPrefix(backendURL, "http://"), "https://"))
+		fmt.Fprintf(cfg, "backend be_guard\n    http-request deny deny_status 403 if { path_beg /admin }\n    http-request set-header X-Original-Host %%[req.hdr(host)]\n    http-request set-header X-Forwarded-Host %%[req.hdr(host)]\n    server s1 %s\n", strings.TrimPrefix(strings.TrimPrefix(backendURL, "http://"), "https://"))
 	} else {
 		// redirect mode
 		fmt.Fprintf(cfg, "    http-request redirect code 302 location %s?d=%%[req.hdr(host)] if vhost\n", explainURL)
@@ -739,23 +1782,20 @@ func writeHAProxyBundle(dir, mode, siteHost, backendURL, explainURL, policyPath,
 	}
 	// Optional map file for blocked hosts (for selective routing in user’s wider config)
 	if strings.TrimSpace(policyPath) != "" || strings.TrimSpace(sheetCSV) != "" {
-		p, err := loadPolicyFromInputs(policyPath, sheetCSV)
+		p, err := loadScopedPolicy(policyPath, sheetCSV, scope, grace)
 		if err != nil {
 			return err
 		}
 		mb := &strings.Builder{}
-		for _, r := range p.Records {
-			if strings.TrimSpace(r.Status) == "suspended" {
-				continue
-			}
-			d := strings.ToLower(strings.TrimSpace(r.Domain))
-			if strings.HasPrefix(d, "*.") {
-				base := strings.TrimPrefix(d, "*.")
+		// HAProxy returns the first matching map entry, so rules are listed most specific first
+		// and exemptions map to 0.
+		for _, r := range proxyMapRules(p) {
+			if r.wildcard {
 				// Map semantics: wildcard noted with a leading dot to match subdomains per HAProxy's -i -m dom
-				fmt.Fprintf(mb, ".%s 1\n", base)
-				fmt.Fprintf(mb, "%s 1\n", base)
+				fmt.Fprintf(mb, ".%s %d\n", r.name, r.value())
+				fmt.Fprintf(mb, "%s %d\n", r.name, r.value())
 			} else {
-				fmt.Fprintf(mb, "%s 1\n", d)
+				fmt.Fprintf(mb, "%s %d\n", r.name, r.value())
 			}
 		}
 		if err := os.WriteFile(filepath.Join(dir, "blocked.map"), []byte(mb.String()), 0o644); err != nil {
@@ -778,6 +1818,9 @@ func writeApacheBundle(dir, mode, siteHost, backendURL, explainURL string) error
 	ProxyPreserveHost On
 	RequestHeader set X-Original-Host "%%{Host}i"
 	RequestHeader set X-Forwarded-Host "%%{Host}i"
+	<Location "/admin">
+		Require all denied
+	</Location>
 	ProxyPass / %s
 	ProxyPassReverse / %s
 </VirtualHost>
@@ -801,9 +1844,15 @@ func cmdGenerateExplainStatic(args []string) {
 	fs := flag.NewFlagSet("generate-explain-static", flag.ExitOnError)
 	outDir := fs.String("out-dir", "dist/explain", "Output directory for static bundle")
 	title := fs.String("title", "SB29 Guard", "Page title")
-	lawURL := fs.String("law-url", "https://search-prod.lis.state.oh.us/api/v2/general_assembly_135/legislation/sb29/05_EN/pdf/", "Law reference URL")
+	lawURL := fs.String("law-url", server.DefaultLawURL, "Law reference URL")
 	inlineCSS := fs.Bool("inline-css", true, "Inline CSS into index.html (else writes style.css)")
-	_ = fs.Parse(args)
+	localesDir := fs.String("locales", "", "Directory of <lang>.json message catalogs overriding or adding to the embedded ones")
+	parseFlags(fs, args)
+	locales, err := loadLocales(*localesDir, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "locales error: %v\n", err)
+		os.Exit(1)
+	}
 
 	if strings.TrimSpace(*outDir) == "" {
 		fmt.Fprintln(os.Stderr, "--out-dir is required")
@@ -822,7 +1871,7 @@ func cmdGenerateExplainStatic(args []string) {
 		}
 		css = ""
 	}
-	html := renderStaticExplainHTML(*title, *lawURL, css)
+	html := renderStaticExplainHTML(*title, *lawURL, css, locales)
 	if err := os.WriteFile(filepath.Join(*outDir, "index.html"), []byte(html), 0o644); err != nil {
 		fmt.Fprintf(os.Stderr, "write index.html error: %v\n", err)
 		os.Exit(2)
@@ -835,9 +1884,10 @@ This bundle renders an explanation page using URL parameters:
 - c: classification (optional, display-only)
 - v: policy version (optional)
 - h: policy hash short (optional)
+- lang: language tag (optional; otherwise the browser's preferred languages are used)
 
 Example:
-  https://` + "${YOUR_HOST}" + `/index.html?d=example.com&c=NO_DPA&v=0.1.0
+  https://` + "${YOUR_HOST}" + `/index.html?d=example.com&c=NO_DPA&v=0.1.0&lang=es
 
 Security notes:
 - Values are sanitized client-side. Do not include untrusted HTML.
@@ -864,9 +1914,13 @@ code{background:var(--panel);padding:2px 6px;border-radius:6px;font-size:.875em}
 .card{background:var(--panel);border:1px solid var(--ring);border-radius:14px;padding:1rem 1.1rem;box-shadow:0 6px 24px rgba(0,0,0,.25)}
 .card-title{margin:.2rem 0 .6rem;font-size:1.2rem;line-height:1.25}
 .badge{display:inline-block;padding:.35rem .7rem;background:var(--badge);color:#fff;border-radius:999px;font-size:.75rem;font-weight:700;letter-spacing:.4px;text-transform:uppercase}
+.languages a,.languages strong{margin-right:.6rem}
 `
 
-func renderStaticExplainHTML(title, lawURL, inlineCSS string) string {
+// renderStaticExplainHTML builds index.html for the static bundle. The catalogs in locales are
+// embedded as JSON; the script picks the language from ?lang= or the browser's preferences and
+// replaces the text of every data-i18n element. Without JavaScript the page reads in English.
+func renderStaticExplainHTML(title, lawURL, inlineCSS string, locales *i18n.Bundle) string {
 	// very small JS to parse query params and safely inject as text
 	// CSP: if inline CSS used, a meta CSP cannot allow style-src unsafe-inline; advise setting headers server-side
 	cssTag := ""
@@ -875,29 +1929,48 @@ func renderStaticExplainHTML(title, lawURL, inlineCSS string) string {
 	} else {
 		cssTag = "<link rel=\"stylesheet\" href=\"style.css\">"
 	}
+	en := locales.Catalog(i18n.DefaultLang).Messages
+	msg := func(id string) string { return "data-i18n=\"" + id + "\">" + template.HTMLEscapeString(en[id]) }
 	return "<!doctype html><html lang=\"en\"><head><meta charset=\"utf-8\"><meta name=\"viewport\" content=\"width=device-width,initial-scale=1\">" +
 		"<title>" + template.HTMLEscapeString(title) + "</title>" + cssTag +
-		"</head><body><header><h1>SB29 Guard</h1><p class=\"muted\">Access Redirected</p></header><main>" +
+		"</head><body><header><h1 " + msg("app_name") + "</h1><p class=\"muted\" " + msg("explain_header") + "</p></header><main>" +
 		`<section class="card">
-  <h2 class="card-title"><span class="muted">Access to</span> <span id="domain" class="domain"></span> <span class="muted">is restricted</span></h2>
+  <h2 class="card-title"><span class="muted" ` + msg("access_to") + `</span> <span id="domain" class="domain"></span> <span class="muted" ` + msg("is_restricted") + `</span></h2>
   <p class="chips"><span id="classification" class="badge"></span></p>
+  <p id="classificationDescription" class="classification-description"></p>
   <dl class="meta-grid">
-	<div><dt>Policy</dt><dd id="policyVersion"></dd></div>
-	<div><dt>UTC</dt><dd id="now"></dd></div>
+	<div><dt ` + msg("policy") + `</dt><dd id="policyVersion"></dd></div>
+	<div><dt ` + msg("utc") + `</dt><dd id="now"></dd></div>
   </dl>
-  <p class="contact">See your instructional technology team for help.</p>
+  <p class="contact" ` + msg("contact") + `</p>
 </section>` +
-		"</main><footer><p>Policy reference: <a href=\"" + template.HTMLEscapeString(lawURL) + "\">SB29</a></p></footer>" +
+		"</main><footer><p><a href=\"" + template.HTMLEscapeString(lawURL) + "\" " + msg("law_link") + "</a></p><nav id=\"languages\" class=\"languages\"></nav></footer>" +
+		`<script type="application/json" id="sb29-locales">` + staticLocalesJSON(locales) + `</script>` +
 		`<script>(function(){
+  var L = JSON.parse(document.getElementById('sb29-locales').textContent);
   function qp(k){const u=new URL(window.location.href);return (u.searchParams.get(k)||"").trim();}
   function setText(id,val){var el=document.getElementById(id); if(!el) return; el.textContent = val || ''}
   function sanitizeHost(h){try{h=h.replace(/^\s+|\s+$/g,''); if(h.indexOf('://')>=0){var u=new URL(h); return u.host.toLowerCase();} return h.toLowerCase();}catch(e){return h;}}
+  function match(t){t=(t||'').toLowerCase().replace(/_/g,'-'); if(!t) return ''; if(L.catalogs[t]) return t; var b=t.split('-')[0]; if(L.catalogs[b]) return b;
+    for(var i=0;i<L.languages.length;i++){ if(L.languages[i].tag.indexOf(b+'-')===0) return L.languages[i].tag; } return '';}
+  var lang = match(qp('lang'));
+  var prefs = navigator.languages || [navigator.language];
+  for(var i=0; !lang && i<prefs.length; i++){ lang = match(prefs[i]); }
+  lang = lang || 'en';
+  var cat = L.catalogs[lang];
+  document.documentElement.lang = lang; document.documentElement.dir = cat.dir || 'ltr';
+  var els = document.querySelectorAll('[data-i18n]');
+  for(var j=0;j<els.length;j++){ var k=els[j].getAttribute('data-i18n'); if(k in cat.messages) els[j].textContent = cat.messages[k]; }
   var d = qp('d') || qp('domain') || qp('original') || qp('url');
   if(d){ d = sanitizeHost(d); if(d.startsWith('www.')) d = d.substring(4); }
   setText('domain', d||'');
-  var c = qp('c'); if(c){ setText('classification', c); }
+  var c = qp('c');
+  if(c){ var t = (cat.classifications||{})[c] || L.taxonomy[c] || {}; setText('classification', t.label || c); document.getElementById('classification').title = c; setText('classificationDescription', t.description); }
   var v = qp('v'); if(v){ setText('policyVersion', 'v'+v); }
   setText('now', new Date().toISOString());
+  var nav = document.getElementById('languages'); nav.setAttribute('aria-label', cat.messages.language || '');
+  if(L.languages.length > 1){ for(var n=0;n<L.languages.length;n++){ var l=L.languages[n], u=new URL(window.location.href); u.searchParams.set('lang', l.tag);
+    var a=document.createElement(l.tag===lang?'strong':'a'); if(l.tag!==lang){ a.href=u.search; a.hreflang=l.tag; } a.lang=l.tag; a.textContent=l.name; nav.appendChild(a); nav.appendChild(document.createTextNode(' ')); } }
 })();</script>` +
 		"</body></html>"
 }
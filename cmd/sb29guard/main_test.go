@@ -1,6 +1,10 @@
 package main
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
@@ -10,8 +14,16 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/journal"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/server"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/sheets"
 )
 
 // helper to write a temporary policy file
@@ -89,6 +101,7 @@ func TestCLIValidateSheetCSV(t *testing.T) {
 	defer ts.Close()
 	bin := buildTestBinary(t)
 	cmd := exec.Command(bin, "validate", "--sheet-csv", ts.URL)
+	cmd.Dir = t.TempDir() // keep the default sheet cache out of the source tree
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		t.Fatalf("validate sheet-csv failed: %v output=%s", err, out)
@@ -105,6 +118,7 @@ func TestCLIHashSheetCSV(t *testing.T) {
 	defer ts.Close()
 	bin := buildTestBinary(t)
 	cmd := exec.Command(bin, "hash", "--sheet-csv", ts.URL)
+	cmd.Dir = t.TempDir() // keep the default sheet cache out of the source tree
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		t.Fatalf("hash sheet-csv failed: %v output=%s", err, out)
@@ -167,7 +181,7 @@ func TestCLIServePortInUse(t *testing.T) {
 	defer ln.Close()
 	policyPath := writeTempPolicy(t)
 	bin := buildTestBinary(t)
-	cmd := exec.Command(bin, "serve", "--policy", policyPath, "--listen", addr)
+	cmd := exec.Command(bin, "serve", "--policy", policyPath, "--listen", addr, "--cache-dir", t.TempDir())
 	// Expect failure because port is in use
 	if err := cmd.Run(); err == nil {
 		t.Fatalf("expected serve to fail when port is in use")
@@ -256,6 +270,7 @@ func TestCLIValidateSheetCSVError(t *testing.T) {
 	defer ts.Close()
 	bin := buildTestBinary(t)
 	cmd := exec.Command(bin, "validate", "--sheet-csv", ts.URL)
+	cmd.Dir = t.TempDir() // keep the default sheet cache out of the source tree
 	if err := cmd.Run(); err == nil {
 		t.Fatalf("expected non-zero exit for sheet-csv error")
 	}
@@ -268,6 +283,7 @@ func TestCLIHashSheetCSVError(t *testing.T) {
 	defer ts.Close()
 	bin := buildTestBinary(t)
 	cmd := exec.Command(bin, "hash", "--sheet-csv", ts.URL)
+	cmd.Dir = t.TempDir() // keep the default sheet cache out of the source tree
 	if err := cmd.Run(); err == nil {
 		t.Fatalf("expected non-zero exit for sheet-csv error")
 	}
@@ -287,7 +303,7 @@ func TestCLIServeSheetCSVHappy(t *testing.T) {
 	addr := ln.Addr().String()
 	ln.Close()
 	bin := buildTestBinary(t)
-	cmd := exec.Command(bin, "serve", "--sheet-csv", ts.URL, "--listen", addr)
+	cmd := exec.Command(bin, "serve", "--sheet-csv", ts.URL, "--listen", addr, "--cache-dir", t.TempDir())
 	if err := cmd.Start(); err != nil {
 		t.Fatalf("start serve: %v", err)
 	}
@@ -343,7 +359,7 @@ func TestCLIServeWithTemplatesOverride(t *testing.T) {
 	addr := ln.Addr().String()
 	ln.Close()
 	bin := buildTestBinary(t)
-	cmd := exec.Command(bin, "serve", "--policy", policyPath, "--templates", tmplDir, "--listen", addr)
+	cmd := exec.Command(bin, "serve", "--policy", policyPath, "--templates", tmplDir, "--listen", addr, "--cache-dir", t.TempDir())
 	if err := cmd.Start(); err != nil {
 		t.Fatalf("start serve: %v", err)
 	}
@@ -382,7 +398,7 @@ func TestCLIServeSheetCSVWithRefreshEveryMetrics(t *testing.T) {
 	addr := ln.Addr().String()
 	ln.Close()
 	bin := buildTestBinary(t)
-	cmd := exec.Command(bin, "serve", "--sheet-csv", ts.URL, "--listen", addr, "--refresh-every", "200ms")
+	cmd := exec.Command(bin, "serve", "--sheet-csv", ts.URL, "--listen", addr, "--refresh-every", "200ms", "--cache-dir", t.TempDir())
 	if err := cmd.Start(); err != nil {
 		t.Fatalf("start serve: %v", err)
 	}
@@ -444,7 +460,7 @@ func TestCmdGenerateProxyDryRunCaddyHeader(t *testing.T) {
 	out := captureOutput(t, func() {
 		cmdGenerateProxy([]string{"--format", "caddy", "--mode", "header-injection", "--site-host", "blocked.local", "--backend-url", "http://127.0.0.1:8080", "--dry-run"})
 	})
-	if !strings.Contains(out, "reverse_proxy") || !strings.Contains(out, "X-Original-Host") {
+	if !strings.Contains(out, "reverse_proxy") || !strings.Contains(out, "X-Original-Host") || !strings.Contains(out, "respond /admin* 403") {
 		t.Fatalf("unexpected caddy output: %s", out)
 	}
 }
@@ -507,3 +523,765 @@ func buildTestBinary(t *testing.T) string {
 	}
 	return outPath
 }
+
+func TestCLIServeWatchReloadsPolicyFile(t *testing.T) {
+	policyPath := writeTempPolicy(t)
+	// choose a free port
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	bin := buildTestBinary(t)
+	cmd := exec.Command(bin, "serve", "--policy", policyPath, "--listen", addr, "--watch", "--watch-interval", "100ms", "--cache-dir", t.TempDir())
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start serve: %v", err)
+	}
+	defer func() { _ = cmd.Process.Kill() }()
+	waitForBody := func(path, want string) {
+		t.Helper()
+		deadline := time.Now().Add(4 * time.Second)
+		var last string
+		for time.Now().Before(deadline) {
+			resp, err := http.Get("http://" + addr + path)
+			if err == nil {
+				b, _ := io.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+				last = string(b)
+				if strings.Contains(last, want) {
+					return
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatalf("%s never contained %q; last body: %s", path, want, last)
+	}
+	waitForBody("/health", "\"policy_version\":\"0.1.0\"")
+
+	// Editor-style atomic save: write a sibling file, then rename over the original.
+	v2 := strings.Replace(readFile(t, policyPath), "version: 0.1.0", "version: 0.2.0", 1)
+	tmp := policyPath + ".swp"
+	if err := os.WriteFile(tmp, []byte(v2), 0o644); err != nil {
+		t.Fatalf("write tmp: %v", err)
+	}
+	if err := os.Rename(tmp, policyPath); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	waitForBody("/health", "\"policy_version\":\"0.2.0\"")
+	waitForBody("/metrics", "\"last_refresh_source\":\"file\"")
+
+	// An invalid edit is reported but the last good policy keeps serving.
+	if err := os.WriteFile(policyPath, []byte("version: 0.3.0\nupdated: 2025-08-08\nrecords: []\n"), 0o644); err != nil {
+		t.Fatalf("write invalid: %v", err)
+	}
+	waitForBody("/metrics", "\"refresh_error_count\":1,\"refresh_errors_by_reason\":{\"invalid_policy\":1}")
+	waitForBody("/health", "\"policy_version\":\"0.2.0\"")
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(b)
+}
+
+func TestPollPolicyFileFollowsSymlinkSwap(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on windows")
+	}
+	// Mimic a Kubernetes ConfigMap mount: domains.yaml -> ..data/domains.yaml, ..data -> ..v1
+	d := t.TempDir()
+	v1 := filepath.Join(d, "..v1")
+	v2 := filepath.Join(d, "..v2")
+	for _, dir := range []string{v1, v2} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	base := readFile(t, writeTempPolicy(t))
+	if err := os.WriteFile(filepath.Join(v1, "domains.yaml"), []byte(base), 0o644); err != nil {
+		t.Fatalf("write v1: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(v2, "domains.yaml"), []byte(strings.Replace(base, "0.1.0", "0.2.0", 1)), 0o644); err != nil {
+		t.Fatalf("write v2: %v", err)
+	}
+	if err := os.Symlink("..v1", filepath.Join(d, "..data")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	path := filepath.Join(d, "domains.yaml")
+	if err := os.Symlink(filepath.Join("..data", "domains.yaml"), path); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	srv := server.New(":0", &policy.Policy{Version: "0.1.0"})
+	st := pollPolicyFile(srv, nil, path, policyFileState{})
+	if st.infos == nil || st.sum == "" {
+		t.Fatalf("expected initial state to be captured: %+v", st)
+	}
+	if again := pollPolicyFile(srv, nil, path, st); again.sum != st.sum {
+		t.Fatalf("unchanged file should keep the same content hash")
+	}
+	// Atomic swap of the ..data symlink, as the kubelet does.
+	tmpLink := filepath.Join(d, "..data_tmp")
+	if err := os.Symlink("..v2", tmpLink); err != nil {
+		t.Fatalf("symlink tmp: %v", err)
+	}
+	if err := os.Rename(tmpLink, filepath.Join(d, "..data")); err != nil {
+		t.Fatalf("rename symlink: %v", err)
+	}
+	swapped := pollPolicyFile(srv, nil, path, st)
+	if swapped.sum == st.sum {
+		t.Fatalf("expected symlink swap to be detected")
+	}
+}
+
+func TestPollPolicyFileSeesIncludedFileEdits(t *testing.T) {
+	d := t.TempDir()
+	shared := filepath.Join(d, "shared.yaml")
+	root := filepath.Join(d, "domains.yaml")
+	record := "  - domain: %s\n    classification: NO_DPA\n    rationale: vendor unsigned\n    last_review: 2025-08-01\n    status: active\n"
+	if err := os.WriteFile(shared, []byte("version: 0.1.0\nupdated: 2025-08-08\nrecords:\n"+fmt.Sprintf(record, "shared.example.com")), 0o644); err != nil {
+		t.Fatalf("write shared: %v", err)
+	}
+	if err := os.WriteFile(root, []byte("version: 0.1.0\nupdated: 2025-08-08\nincludes: [shared.yaml]\nrecords:\n"+fmt.Sprintf(record, "local.example.com")), 0o644); err != nil {
+		t.Fatalf("write root: %v", err)
+	}
+	p, err := loadPolicyFile(root)
+	if err == nil {
+		err = p.Validate()
+	}
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	srv := server.New(":0", p)
+	st := pollPolicyFile(srv, nil, root, policyFileState{files: watchedFiles(root, p)})
+	if len(st.files) != 2 || st.sum == "" {
+		t.Fatalf("expected root and include to be watched: %+v", st)
+	}
+	// Edit only the included file; make sure the size changes so the stat check cannot miss it.
+	if err := os.WriteFile(shared, []byte("version: 0.1.0\nupdated: 2025-08-08\nrecords:\n"+fmt.Sprintf(record, "shared.example.com")+fmt.Sprintf(record, "added.example.com")), 0o644); err != nil {
+		t.Fatalf("edit shared: %v", err)
+	}
+	captureOutput(t, func() { st = pollPolicyFile(srv, nil, root, st) })
+	if _, ok := srv.CurrentPolicy().Lookup("added.example.com"); !ok {
+		t.Fatalf("edit to the included file was not picked up")
+	}
+}
+
+func TestCLIDNSServeAnswersPolicyDomain(t *testing.T) {
+	policyPath := writeTempPolicy(t)
+	// choose a free UDP port (TCP binds the same number)
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := pc.LocalAddr().String()
+	pc.Close()
+	bin := buildTestBinary(t)
+	cmd := exec.Command(bin, "dns-serve", "--policy", policyPath, "--listen", addr, "--redirect-ipv4", "10.9.8.7", "--cache-dir", t.TempDir())
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start dns-serve: %v", err)
+	}
+	defer func() { _ = cmd.Process.Kill() }()
+	req, err := (&dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: 7, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: dnsmessage.MustNewName("example.com."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}},
+	}).Pack()
+	if err != nil {
+		t.Fatalf("pack: %v", err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		c, err := net.Dial("udp", addr)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		_ = c.SetDeadline(time.Now().Add(200 * time.Millisecond))
+		_, _ = c.Write(req)
+		buf := make([]byte, 512)
+		n, rerr := c.Read(buf)
+		c.Close()
+		if rerr == nil {
+			var m dnsmessage.Message
+			if err := m.Unpack(buf[:n]); err != nil {
+				t.Fatalf("unpack: %v", err)
+			}
+			if len(m.Answers) != 1 {
+				t.Fatalf("expected one answer, got %+v", m.Answers)
+			}
+			if a, ok := m.Answers[0].Body.(*dnsmessage.AResource); !ok || a.A != [4]byte{10, 9, 8, 7} {
+				t.Fatalf("unexpected answer %+v", m.Answers[0])
+			}
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("dns-serve did not answer")
+}
+
+func TestCLIDNSServeStopsGracefullyOnSIGTERM(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM is not deliverable on Windows")
+	}
+	policyPath := writeTempPolicy(t)
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := pc.LocalAddr().String()
+	pc.Close()
+	// A webhook receiver makes dns-serve start a notify dispatcher that must be closed on exit, after the
+	// watch and expiry loops have stopped.
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer hook.Close()
+	bin := buildTestBinary(t)
+	var out strings.Builder
+	cmd := exec.Command(bin, "dns-serve", "--policy", policyPath, "--listen", addr, "--redirect-ipv4", "10.9.8.7",
+		"--cache-dir", t.TempDir(), "--journal=false", "--watch", "--notify-webhook", hook.URL, "--shutdown-timeout", "2s")
+	cmd.Stdout = &out
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start dns-serve: %v", err)
+	}
+	defer func() { _ = cmd.Process.Kill() }()
+	ready := false
+	for deadline := time.Now().Add(3 * time.Second); time.Now().Before(deadline) && !ready; time.Sleep(50 * time.Millisecond) {
+		if c, err := net.Dial("tcp", addr); err == nil {
+			c.Close()
+			ready = true
+		}
+	}
+	if !ready {
+		t.Fatalf("dns-serve never became ready")
+	}
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("signal: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("dns-serve should exit 0 after SIGTERM: %v output=%s", err, out.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("dns-serve did not exit after SIGTERM")
+	}
+	if !strings.Contains(out.String(), "\"event\":\"notify.configured\"") || !strings.Contains(out.String(), "\"event\":\"dns.stopped\"") {
+		t.Fatalf("expected notify.configured and dns.stopped events, got %s", out.String())
+	}
+}
+
+func TestCLIGenerateDNSRedirectIPv6(t *testing.T) {
+	policyPath := writeTempPolicy(t)
+	out := captureOutput(t, func() {
+		cmdGenerateDNS([]string{"--policy", policyPath, "--format", "bind", "--redirect-ipv4", "10.1.2.3", "--redirect-ipv6", "fd00::1", "--dry-run"})
+	})
+	if !strings.Contains(out, "IN A 10.1.2.3") || !strings.Contains(out, "IN AAAA fd00::1") {
+		t.Fatalf("expected A and AAAA records: %s", out)
+	}
+	bin := buildTestBinary(t)
+	cmd := exec.Command(bin, "generate-dns", "--policy", policyPath, "--format", "hosts", "--redirect-ipv6", "192.0.2.1", "--dry-run")
+	if err := cmd.Run(); err == nil {
+		t.Fatalf("expected failure for non-IPv6 --redirect-ipv6")
+	}
+}
+
+func TestCLIGenerateDNSHostsExpand(t *testing.T) {
+	d := t.TempDir()
+	policyPath := filepath.Join(d, "policy.yaml")
+	content := "version: 0.1.0\nupdated: 2025-08-08\nrecords:\n" +
+		"  - domain: \"*.trackingwidgets.io\"\n    classification: EXPIRED_DPA\n    rationale: expired agreement\n    last_review: 2025-08-01\n    status: active\n"
+	if err := os.WriteFile(policyPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	expand := filepath.Join(d, "subdomains.txt")
+	if err := os.WriteFile(expand, []byte("# known subdomains\napi.trackingwidgets.io\n\ncdn.trackingwidgets.io\n"), 0o644); err != nil {
+		t.Fatalf("write expand: %v", err)
+	}
+	out := captureOutput(t, func() {
+		cmdGenerateDNS([]string{"--policy", policyPath, "--format", "hosts", "--redirect-ipv4", "10.1.2.3", "--hosts-expand", expand, "--dry-run"})
+	})
+	for _, w := range []string{"10.1.2.3 trackingwidgets.io", "10.1.2.3 api.trackingwidgets.io", "10.1.2.3 cdn.trackingwidgets.io"} {
+		if !strings.Contains(out, w) {
+			t.Fatalf("missing %q in output: %s", w, out)
+		}
+	}
+}
+
+func TestCmdDiffYAMLAgainstCSV(t *testing.T) {
+	d := t.TempDir()
+	oldPath := filepath.Join(d, "old.yaml")
+	content := "version: 0.1.0\nupdated: 2025-08-08\nrecords:\n" +
+		"  - domain: exampletool.com\n    classification: NO_DPA\n    rationale: Vendor has not signed\n    last_review: 2025-08-01\n    status: active\n" +
+		"  - domain: gone.example.org\n    classification: OTHER\n    rationale: retired\n    last_review: 2025-08-01\n    status: active\n"
+	if err := os.WriteFile(oldPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write old: %v", err)
+	}
+	newPath := filepath.Join(d, "cached.csv")
+	csvContent := "domain,classification,rationale,last_review,status\n" +
+		"exampletool.com,EXPIRED_DPA,Vendor has not signed,2025-08-02,active\n" +
+		"added.example.net,LEGAL_HOLD,hold,2025-08-02,active\n"
+	if err := os.WriteFile(newPath, []byte(csvContent), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	out := captureOutput(t, func() { cmdDiff([]string{oldPath, newPath}) })
+	for _, w := range []string{"DOMAIN", "added.example.net", "gone.example.org", "reclassified", "3 change(s)"} {
+		if !strings.Contains(out, w) {
+			t.Fatalf("missing %q in text output: %s", w, out)
+		}
+	}
+	out = captureOutput(t, func() { cmdDiff([]string{"--format", "json", oldPath, newPath}) })
+	var res struct {
+		Summary map[string]int  `json:"summary"`
+		Changes []policy.Change `json:"changes"`
+	}
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("bad json: %v\n%s", err, out)
+	}
+	if res.Summary["added"] != 1 || res.Summary["removed"] != 1 || res.Summary["reclassified"] != 1 || len(res.Changes) != 3 {
+		t.Fatalf("unexpected json diff: %+v", res)
+	}
+}
+
+func TestCmdRollbackWritesSnapshot(t *testing.T) {
+	d := t.TempDir()
+	cacheDir := filepath.Join(d, "cache")
+	j, err := journal.Open(cacheDir)
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	old := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "exampletool.com", Classification: "NO_DPA", Rationale: "Vendor has not signed", LastReview: "2025-08-01", Status: "active"},
+		{Domain: "*.trackingwidgets.io", Classification: "EXPIRED_DPA", Rationale: "Expired", LastReview: "2025-08-01", Status: "active"},
+	}}
+	if _, _, err := j.Record(old, "file"); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	out := captureOutput(t, func() { cmdRollback([]string{"--list", "--cache-dir", cacheDir}) })
+	if !strings.Contains(out, old.CanonicalHash()) {
+		t.Fatalf("list missing hash: %s", out)
+	}
+	target := filepath.Join(d, "domains.yaml")
+	out = captureOutput(t, func() {
+		cmdRollback([]string{"--to", old.CanonicalHash()[:12], "--cache-dir", cacheDir, "--out", target})
+	})
+	if !strings.Contains(out, `"status":"ok"`) {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	p, err := loadPolicySource(target)
+	if err != nil {
+		t.Fatalf("rolled back file does not load: %v", err)
+	}
+	if p.CanonicalHash() != old.CanonicalHash() {
+		t.Fatalf("hash mismatch after rollback: %s vs %s", p.CanonicalHash(), old.CanonicalHash())
+	}
+}
+
+func TestRefreshCSVOnceGuardRejectsAndAcknowledges(t *testing.T) {
+	var rows int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var b strings.Builder
+		b.WriteString("domain,classification,rationale,last_review,status\n")
+		for i := 0; i < rows; i++ {
+			fmt.Fprintf(&b, "d%d.example.com,NO_DPA,Reason,2025-08-01,active\n", i)
+		}
+		_, _ = w.Write([]byte(b.String()))
+	}))
+	defer ts.Close()
+	cacheDir := t.TempDir()
+	rows = 10
+	p, _, err := sheets.FetchCSVPolicyCached(ts.URL, cacheDir, ts.Client())
+	if err != nil {
+		t.Fatalf("initial fetch: %v", err)
+	}
+	srv := server.New(":0", p)
+	g := &refreshGuard{rails: policy.Guardrails{MaxDropPercent: 50}, ackFile: filepath.Join(cacheDir, "guard-ack")}
+
+	rows = 2
+	out := captureOutput(t, func() { refreshCSVOnce(srv, nil, g, ts.Client(), ts.URL, cacheDir) })
+	if !strings.Contains(out, `"event":"policy.refresh.rejected","reason":"record_drop"`) {
+		t.Fatalf("expected rejection event, got: %s", out)
+	}
+	if n := len(srv.CurrentPolicy().Records); n != 10 {
+		t.Fatalf("previous policy should stay active, got %d records", n)
+	}
+	// An unreachable sheet (no cache to fall back on) is counted apart from the rejection.
+	captureOutput(t, func() { refreshCSVOnce(srv, nil, g, ts.Client(), "http://127.0.0.1:0/sheet.csv", t.TempDir()) })
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.RemoteAddr = "127.0.0.1:40000"
+	srv.Handler().ServeHTTP(rr, req)
+	if body := rr.Body.String(); !strings.Contains(body, `"refresh_error_count":2,"refresh_errors_by_reason":{"fetch_error":1,"guard_rejected":1}`) {
+		t.Fatalf("expected errors counted by reason, got: %s", body)
+	}
+
+	if err := os.WriteFile(g.ackFile, nil, 0o644); err != nil {
+		t.Fatalf("write ack: %v", err)
+	}
+	out = captureOutput(t, func() { refreshCSVOnce(srv, nil, g, ts.Client(), ts.URL, cacheDir) })
+	if !strings.Contains(out, `"via":"file"`) || len(srv.CurrentPolicy().Records) != 2 {
+		t.Fatalf("acknowledged refresh not applied: %s", out)
+	}
+	if _, err := os.Stat(g.ackFile); !os.IsNotExist(err) {
+		t.Fatalf("ack file should be consumed, stat err=%v", err)
+	}
+}
+
+func TestGuardRejectedSheetSurvivesRestart(t *testing.T) {
+	var rows int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := fmt.Sprintf("\"rows-%d\"", rows)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		var b strings.Builder
+		b.WriteString("domain,classification,rationale,last_review,status\n")
+		for i := 0; i < rows; i++ {
+			fmt.Fprintf(&b, "d%d.example.com,NO_DPA,Reason,2025-08-01,active\n", i)
+		}
+		_, _ = w.Write([]byte(b.String()))
+	}))
+	defer ts.Close()
+	cacheDir := t.TempDir()
+	j, err := journal.Open(cacheDir)
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	g := &refreshGuard{rails: policy.Guardrails{MaxDropPercent: 50}, ackFile: filepath.Join(cacheDir, "guard-ack")}
+
+	rows = 10
+	p, src, err := loadSheetAtStartup(j, g, ts.URL, cacheDir, "")
+	if err != nil || src != "csv" || len(p.Records) != 10 {
+		t.Fatalf("first start: %v %s", err, src)
+	}
+	journalPolicy(j, p, src)
+	srv := server.New(":0", p)
+
+	rows = 2
+	out := captureOutput(t, func() { refreshCSVOnce(srv, j, g, ts.Client(), ts.URL, cacheDir) })
+	if !strings.Contains(out, `"reason":"record_drop"`) {
+		t.Fatalf("expected rejection, got: %s", out)
+	}
+	// Restart: the rejected sheet must not come back from the network or the cache.
+	var restarted *policy.Policy
+	out = captureOutput(t, func() { restarted, src, err = loadSheetAtStartup(j, g, ts.URL, cacheDir, "") })
+	if err != nil || src != "journal" || len(restarted.Records) != 10 || !strings.Contains(out, `"reason":"record_drop"`) {
+		t.Fatalf("restart should keep the last accepted snapshot: %v src=%s out=%s", err, src, out)
+	}
+	cached, err := filepath.Glob(filepath.Join(cacheDir, "sheets", "*.csv"))
+	if err != nil || len(cached) != 1 {
+		t.Fatalf("expected one cached sheet: %v %v", cached, err)
+	}
+	if b, _ := os.ReadFile(cached[0]); strings.Count(string(b), "\n") != 11 {
+		t.Fatalf("cache should still hold the accepted sheet:\n%s", b)
+	}
+}
+
+func TestCmdValidateWarnExpiring(t *testing.T) {
+	d := t.TempDir()
+	policyPath := filepath.Join(d, "policy.yaml")
+	soon := time.Now().AddDate(0, 0, 10).Format("2006-01-02")
+	past := time.Now().AddDate(0, 0, -3).Format("2006-01-02")
+	later := time.Now().AddDate(0, 0, 90).Format("2006-01-02")
+	content := "version: 0.1.0\nupdated: 2025-08-08\nrecords:\n" +
+		"  - domain: soon.example.com\n    classification: NO_DPA\n    rationale: expiring agreement\n    last_review: 2025-08-01\n    status: active\n    expires: " + soon + "\n" +
+		"  - domain: past.example.com\n    classification: NO_DPA\n    rationale: expiring agreement\n    last_review: 2025-08-01\n    status: active\n    expires: " + past + "\n" +
+		"  - domain: later.example.com\n    classification: NO_DPA\n    rationale: expiring agreement\n    last_review: 2025-08-01\n    status: active\n    expires: " + later + "\n"
+	if err := os.WriteFile(policyPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	out := captureOutput(t, func() { cmdValidate([]string{"--policy", policyPath, "--warn-expiring", "30d"}) })
+	var res struct {
+		Status   string `json:"status"`
+		Expiring []struct {
+			Domain   string `json:"domain"`
+			DaysLeft int    `json:"days_left"`
+		} `json:"expiring"`
+		Expired []struct {
+			Domain string `json:"domain"`
+		} `json:"expired"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &res); err != nil {
+		t.Fatalf("bad json: %v\n%s", err, out)
+	}
+	if res.Status != "ok" || len(res.Expiring) != 1 || res.Expiring[0].Domain != "soon.example.com" || res.Expiring[0].DaysLeft != 10 {
+		t.Fatalf("unexpected expiring report: %+v", res)
+	}
+	if len(res.Expired) != 1 || res.Expired[0].Domain != "past.example.com" {
+		t.Fatalf("unexpected expired report: %+v", res)
+	}
+}
+
+func TestCLIValidateLintFindings(t *testing.T) {
+	d := t.TempDir()
+	policyPath := filepath.Join(d, "policy.yaml")
+	content := "version: 0.1.0\nupdated: 2025-08-08\nrecords:\n" +
+		"  - domain: \"*.example.com\"\n    classification: NO_DPA\n    rationale: vendor unsigned\n    last_review: 2025-08-01\n    status: active\n" +
+		"  - domain: www.example.com\n    classification: NO_DPA\n    rationale: vendor unsigned\n    last_review: 2025-08-01\n    status: active\n"
+	if err := os.WriteFile(policyPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	bin := buildTestBinary(t)
+	out, err := exec.Command(bin, "validate", "--policy", policyPath).Output()
+	if err != nil {
+		t.Fatalf("validate should pass without --fail-on-lint: %v", err)
+	}
+	if !strings.Contains(string(out), `"kind":"redundant"`) {
+		t.Fatalf("expected lint finding in output: %s", out)
+	}
+	err = exec.Command(bin, "validate", "--policy", policyPath, "--fail-on-lint").Run()
+	if ee, ok := err.(*exec.ExitError); !ok || ee.ExitCode() != 1 {
+		t.Fatalf("expected exit 1 with --fail-on-lint, got %v", err)
+	}
+}
+
+func TestCmdHashResolvesIncludes(t *testing.T) {
+	d := t.TempDir()
+	csvContent := "domain,classification,rationale,last_review,status\n" +
+		"sheet.example.net,NO_DPA,Vendor has not signed,2025-08-02,active\n"
+	if err := os.WriteFile(filepath.Join(d, "district.csv"), []byte(csvContent), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	layered := filepath.Join(d, "layered.yaml")
+	content := "version: 0.1.0\nupdated: 2025-08-08\nincludes:\n  - district.csv\n" +
+		"records:\n  - domain: local.example.com\n    classification: LEGAL_HOLD\n    rationale: local hold\n    last_review: 2025-08-01\n    status: active\n" +
+		"overrides:\n  - domain: sheet.example.net\n    classification: PENDING_REVIEW\n"
+	if err := os.WriteFile(layered, []byte(content), 0o644); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	flat := filepath.Join(d, "flat.yaml")
+	content = "version: 0.1.0\nupdated: 2025-08-08\nrecords:\n" +
+		"  - domain: sheet.example.net\n    classification: PENDING_REVIEW\n    rationale: Vendor has not signed\n    last_review: 2025-08-02\n    status: active\n" +
+		"  - domain: local.example.com\n    classification: LEGAL_HOLD\n    rationale: local hold\n    last_review: 2025-08-01\n    status: active\n"
+	if err := os.WriteFile(flat, []byte(content), 0o644); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	got := captureOutput(t, func() { cmdHash([]string{"--policy", layered}) })
+	want := captureOutput(t, func() { cmdHash([]string{"--policy", flat}) })
+	if got != want || !strings.Contains(got, "\"records\":2") {
+		t.Fatalf("layered hash %s should equal flattened hash %s", got, want)
+	}
+}
+
+func TestProxyMapsHonourExemptions(t *testing.T) {
+	d := t.TempDir()
+	policyPath := filepath.Join(d, "policy.yaml")
+	content := "version: 0.1.0\nupdated: 2025-08-08\nrecords:\n" +
+		"  - domain: \"*.bigvendor.com\"\n    classification: NO_DPA\n    rationale: vendor unsigned\n    last_review: 2025-08-01\n    status: active\n" +
+		"  - domain: \"*.edu.bigvendor.com\"\n    action: allow\n    rationale: signed DPA\n    last_review: 2025-08-01\n    status: active\n" +
+		"  - domain: classroom.bigvendor.com\n    action: allow\n    rationale: signed DPA\n    last_review: 2025-08-01\n    status: active\n"
+	if err := os.WriteFile(policyPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	nginxDir := filepath.Join(d, "nginx")
+	if err := writeNginxBundle(nginxDir, "redirect", "guard.local", "http://127.0.0.1:8080", "https://guard.local/explain", "", "", policyPath, "", "", "", false); err != nil {
+		t.Fatalf("nginx bundle: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(nginxDir, "blocked_map.conf"))
+	if err != nil {
+		t.Fatalf("read map: %v", err)
+	}
+	m := string(b)
+	edu, vendor := strings.Index(m, nginxRegexEscape("edu.bigvendor.com")+"$ 0;"), strings.Index(m, ")?"+nginxRegexEscape("bigvendor.com")+"$ 1;")
+	if !strings.Contains(m, "classroom.bigvendor.com 0;") || edu < 0 || vendor < 0 || edu > vendor {
+		t.Fatalf("exemptions must map to 0 ahead of the broader block:\n%s", m)
+	}
+	haDir := filepath.Join(d, "haproxy")
+	if err := writeHAProxyBundle(haDir, "redirect", "guard.local", "http://127.0.0.1:8080", "https://guard.local/explain", policyPath, "", "", ""); err != nil {
+		t.Fatalf("haproxy bundle: %v", err)
+	}
+	b, err = os.ReadFile(filepath.Join(haDir, "blocked.map"))
+	if err != nil {
+		t.Fatalf("read map: %v", err)
+	}
+	want := "classroom.bigvendor.com 0\n.edu.bigvendor.com 0\nedu.bigvendor.com 0\n.bigvendor.com 1\nbigvendor.com 1\n"
+	if string(b) != want {
+		t.Fatalf("blocked.map = %q, want %q", b, want)
+	}
+}
+
+func TestCLIServeStopsGracefullyOnSIGTERM(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM is not deliverable on Windows")
+	}
+	policyPath := writeTempPolicy(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	bin := buildTestBinary(t)
+	var out strings.Builder
+	cmd := exec.Command(bin, "serve", "--policy", policyPath, "--listen", addr, "--cache-dir", t.TempDir(), "--journal=false", "--shutdown-timeout", "2s")
+	cmd.Stdout = &out
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start serve: %v", err)
+	}
+	defer func() { _ = cmd.Process.Kill() }()
+	ready := false
+	for i := 0; i < 100 && !ready; i++ {
+		if resp, err := http.Get("http://" + addr + "/health"); err == nil {
+			resp.Body.Close()
+			ready = true
+		} else {
+			time.Sleep(30 * time.Millisecond)
+		}
+	}
+	if !ready {
+		t.Fatalf("server never became ready")
+	}
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("signal: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("serve should exit 0 after SIGTERM: %v output=%s", err, out.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("serve did not exit after SIGTERM")
+	}
+	if !strings.Contains(out.String(), "\"event\":\"server.stopped\"") {
+		t.Fatalf("expected server.stopped event, got %s", out.String())
+	}
+}
+
+func TestCLIServeSelfSignedTLS(t *testing.T) {
+	policyPath := writeTempPolicy(t)
+	freeAddr := func() string {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		defer ln.Close()
+		return ln.Addr().String()
+	}
+	httpAddr, tlsAddr := freeAddr(), freeAddr()
+	cacheDir := t.TempDir()
+	bin := buildTestBinary(t)
+	cmd := exec.Command(bin, "serve", "--policy", policyPath, "--listen", httpAddr, "--cache-dir", cacheDir, "--journal=false",
+		"--tls-listen", tlsAddr, "--tls-self-signed", "--tls-host", "guard.test")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start serve: %v", err)
+	}
+	defer func() { _ = cmd.Process.Kill() }()
+	certPEM := ""
+	for i := 0; i < 100 && certPEM == ""; i++ {
+		if b, err := os.ReadFile(filepath.Join(cacheDir, "tls", "guard.test.crt")); err == nil {
+			certPEM = string(b)
+		} else {
+			time.Sleep(30 * time.Millisecond)
+		}
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(certPEM)) {
+		t.Fatalf("self-signed certificate not written")
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, ServerName: "guard.test"}}}
+	var lastErr error
+	for i := 0; i < 100; i++ {
+		resp, err := client.Get("https://" + tlsAddr + "/health")
+		if err == nil {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if !strings.Contains(string(body), "\"status\":\"ok\"") {
+				t.Fatalf("unexpected https body: %s", body)
+			}
+			if resp, err := http.Get("http://" + httpAddr + "/health"); err != nil {
+				t.Fatalf("plain HTTP should keep serving: %v", err)
+			} else {
+				resp.Body.Close()
+			}
+			return
+		}
+		lastErr = err
+		time.Sleep(30 * time.Millisecond)
+	}
+	t.Fatalf("https never became ready: %v", lastErr)
+}
+
+func TestDropExpiredRecordsRebuildsServedPolicy(t *testing.T) {
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "current.example.com", Classification: "NO_DPA", Rationale: "vendor unsigned", LastReview: "2025-08-01", Status: "active"},
+		{Domain: "lapsed.example.com", Classification: "NO_DPA", Rationale: "vendor unsigned", LastReview: "2025-08-01", Status: "active", Expires: yesterday},
+	}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	srv := server.New(":0", p)
+	j, err := journal.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	out := captureOutput(t, func() { dropExpiredRecords(srv, j, time.Now()) })
+	cur := srv.CurrentPolicy()
+	if len(cur.Records) != 1 || cur.Records[0].Domain != "current.example.com" {
+		t.Fatalf("expired record should be dropped from the served policy: %+v", cur.Records)
+	}
+	if !strings.Contains(out, `"dropped":1`) {
+		t.Fatalf("expected dropped count in event: %s", out)
+	}
+	// The served hash resolves in the journal, so it can be looked up and rolled back to.
+	if _, _, err := j.Load(cur.CanonicalHash()); err != nil {
+		t.Fatalf("pruned policy should be journaled: %v", err)
+	}
+
+	// Nothing left to drop: the served policy is not replaced.
+	out = captureOutput(t, func() { dropExpiredRecords(srv, j, time.Now()) })
+	if srv.CurrentPolicy() != cur || !strings.Contains(out, `"dropped":0`) {
+		t.Fatalf("expected no-op re-evaluation: %s", out)
+	}
+}
+
+func TestReloadedPolicyKeepsGraceMode(t *testing.T) {
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	path := filepath.Join(t.TempDir(), "domains.yaml")
+	doc := "version: 0.1.0\nupdated: 2025-08-08\nrecords:\n  - domain: lapsed.example.com\n    classification: NO_DPA\n    rationale: vendor unsigned\n    last_review: 2025-08-01\n    status: active\n    expires: " + yesterday + "\n"
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	p, err := loadPolicyFile(path)
+	if err == nil {
+		err = p.SetExpiredClassification("EXPIRED_DPA")
+	}
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	srv := server.New(":0", p)
+	st := pollPolicyFile(srv, nil, path, policyFileState{files: watchedFiles(path, p)})
+	if err := os.WriteFile(path, []byte(strings.Replace(doc, "vendor unsigned", "vendor still unsigned", 1)), 0o644); err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+	captureOutput(t, func() { pollPolicyFile(srv, nil, path, st) })
+	cur := srv.CurrentPolicy()
+	if cur == p || cur.ExpiredClassification() != "EXPIRED_DPA" {
+		t.Fatalf("reloaded policy should keep grace mode, got %q (reloaded=%v)", cur.ExpiredClassification(), cur != p)
+	}
+	if rec, ok := cur.Lookup("lapsed.example.com"); !ok || rec.Classification != "EXPIRED_DPA" {
+		t.Fatalf("expired record should stay enforced under the grace class, got %#v ok=%v", rec, ok)
+	}
+}
+
+func TestCmdGenerateDNSScopeIsCaseInsensitive(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	content := "version: 0.1.0\nupdated: 2025-08-08\nrecords:\n" +
+		"  - domain: games.com\n    classification: OTHER\n    rationale: student devices only\n    last_review: 2025-08-01\n    status: active\n    scopes: [students]\n" +
+		"  - domain: staff.example\n    classification: NO_DPA\n    rationale: staff devices only\n    last_review: 2025-08-01\n    status: active\n    scopes: [staff]\n"
+	if err := os.WriteFile(policyPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	out := captureOutput(t, func() {
+		cmdGenerateDNS([]string{"--policy", policyPath, "--format", "hosts", "--redirect-ipv4", "10.1.2.3", "--scope", " Students ", "--dry-run"})
+	})
+	if !strings.Contains(out, "games.com") || strings.Contains(out, "staff.example") {
+		t.Fatalf("expected only the students view: %s", out)
+	}
+}
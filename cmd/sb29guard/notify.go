@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/notify"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+)
+
+// notifyFlags are the serve and dns-serve flags for outbound webhook and SMTP notifications.
+type notifyFlags struct {
+	webhooks          *string
+	webhookSecretFile *string
+	smtpAddr          *string
+	smtpFrom          *string
+	smtpTo            *string
+	smtpUser          *string
+	refreshFailures   *int
+	expiring          *string
+	retries           *int
+}
+
+func addNotifyFlags(fs *flag.FlagSet) *notifyFlags {
+	return &notifyFlags{
+		webhooks:          fs.String("notify-webhook", "", "Comma-separated webhook URLs receiving events as signed JSON POSTs (Slack/Teams compatible)"),
+		webhookSecretFile: fs.String("notify-webhook-secret-file", "", "File holding the HMAC secret for the X-SB29Guard-Signature header"),
+		smtpAddr:          fs.String("notify-smtp", "", "SMTP server host:port for event mail (STARTTLS when offered)"),
+		smtpFrom:          fs.String("notify-smtp-from", "", "Sender address for event mail"),
+		smtpTo:            fs.String("notify-smtp-to", "", "Comma-separated recipients for event mail"),
+		smtpUser:          fs.String("notify-smtp-user", "", "SMTP user for PLAIN auth (password from SB29_NOTIFY_SMTP_PASS)"),
+		refreshFailures:   fs.Int("notify-refresh-failures", 3, "Notify when this many policy refreshes fail in a row (0 = off)"),
+		expiring:          fs.String("notify-expiring", "14d", "Notify once per record when its expires date is this close (e.g. 14d; 0 = off)"),
+		retries:           fs.Int("notify-retries", notify.DefaultRetries, "Delivery retries per event and receiver, with exponential backoff"),
+	}
+}
+
+// build starts the dispatcher; it returns nil when no receiver is configured.
+func (f *notifyFlags) build() (*notify.Dispatcher, error) {
+	var sinks []notify.Sink
+	var secret []byte
+	if *f.webhookSecretFile != "" {
+		b, err := os.ReadFile(*f.webhookSecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("--notify-webhook-secret-file: %w", err)
+		}
+		if secret = []byte(strings.TrimSpace(string(b))); len(secret) == 0 {
+			return nil, errors.New("--notify-webhook-secret-file is empty")
+		}
+	}
+	for _, raw := range splitList(*f.webhooks) {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("--notify-webhook: %q is not an http(s) URL", raw)
+		}
+		sinks = append(sinks, &notify.Webhook{URL: raw, Secret: secret})
+	}
+	if *f.smtpAddr != "" {
+		if _, _, err := net.SplitHostPort(*f.smtpAddr); err != nil {
+			return nil, fmt.Errorf("--notify-smtp: %w", err)
+		}
+		to := splitList(*f.smtpTo)
+		if *f.smtpFrom == "" || len(to) == 0 {
+			return nil, errors.New("--notify-smtp needs --notify-smtp-from and --notify-smtp-to")
+		}
+		sinks = append(sinks, &notify.SMTP{Addr: *f.smtpAddr, From: *f.smtpFrom, To: to, Username: *f.smtpUser, Password: os.Getenv("SB29_NOTIFY_SMTP_PASS")})
+	}
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+	if _, err := f.expiryWindow(); err != nil {
+		return nil, err
+	}
+	retries := *f.retries
+	if retries == 0 {
+		retries = -1 // notify.Options treats 0 as the default
+	}
+	d := notify.New(notify.Options{
+		Sinks:   sinks,
+		Retries: retries,
+		OnError: func(sink string, e notify.Event, err error) {
+			fmt.Printf("{\"event\":\"notify.error\",\"sink\":%q,\"type\":%q,\"message\":%q}\n", sink, e.Type, err.Error())
+		},
+	})
+	fmt.Printf("{\"event\":\"notify.configured\",\"webhooks\":%d,\"smtp\":%q}\n", len(splitList(*f.webhooks)), *f.smtpAddr)
+	return d, nil
+}
+
+func (f *notifyFlags) expiryWindow() (time.Duration, error) {
+	if *f.expiring == "" {
+		return 0, nil
+	}
+	w, err := parseDays(*f.expiring)
+	if err != nil {
+		return 0, fmt.Errorf("--notify-expiring: %w", err)
+	}
+	return w, nil
+}
+
+// wrap returns t, reporting its refresh outcomes and expiring records to d, the dispatcher
+// started by build (t itself when notifications are off).
+func (f *notifyFlags) wrap(t policyTarget, d *notify.Dispatcher) policyTarget {
+	if d == nil {
+		return t
+	}
+	window, _ := f.expiryWindow()
+	n := &notifyingTarget{policyTarget: t, d: d, failThreshold: *f.refreshFailures, expiryWindow: window, warned: map[string]bool{}}
+	p := t.CurrentPolicy()
+	n.hash = p.CanonicalHash()
+	n.checkExpiring(p, time.Now())
+	return n
+}
+
+// closeNotifier delivers the events queued on d after the server and its policy loops have
+// stopped, waiting at most timeout. d may be nil.
+func closeNotifier(d *notify.Dispatcher, timeout time.Duration) {
+	if d == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := d.Close(ctx); err != nil {
+		fmt.Printf("{\"event\":\"notify.error\",\"sink\":\"\",\"type\":\"\",\"message\":%q}\n", "undelivered events at shutdown: "+err.Error())
+	}
+}
+
+// notifyingTarget wraps a policyTarget and raises events for repeated refresh failures, policy
+// hash changes on refresh, and records about to expire.
+type notifyingTarget struct {
+	policyTarget
+	d             *notify.Dispatcher
+	failThreshold int
+	expiryWindow  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	hash     string
+	warned   map[string]bool // domain|expires already announced by this process
+}
+
+func (n *notifyingTarget) UpdatePolicy(p *policy.Policy) {
+	n.policyTarget.UpdatePolicy(p)
+	n.checkExpiring(p, time.Now())
+}
+
+func (n *notifyingTarget) RecordRefreshSuccess(source string) {
+	n.policyTarget.RecordRefreshSuccess(source)
+	p := n.CurrentPolicy()
+	h := p.CanonicalHash()
+	n.mu.Lock()
+	old := n.hash
+	n.failures, n.hash = 0, h
+	n.mu.Unlock()
+	if h == old {
+		return
+	}
+	n.send(notify.Event{
+		Type: notify.PolicyChanged,
+		Text: fmt.Sprintf("Policy changed via %s: version %s, %d records, hash %s", source, p.Version, len(p.Records), shortHash(h)),
+		Data: map[string]interface{}{"source": source, "version": p.Version, "records": len(p.Records), "old_hash": old, "new_hash": h},
+	})
+}
+
+func (n *notifyingTarget) RecordRefreshError(reason, msg string) {
+	n.policyTarget.RecordRefreshError(reason, msg)
+	n.mu.Lock()
+	n.failures++
+	failures := n.failures
+	n.mu.Unlock()
+	// Announce the streak once when it reaches the threshold; a success starts a new streak.
+	if n.failThreshold <= 0 || failures != n.failThreshold {
+		return
+	}
+	p := n.CurrentPolicy()
+	n.send(notify.Event{
+		Type: notify.RefreshFailing,
+		Text: fmt.Sprintf("Policy refresh failed %d times in a row; still serving version %s: %s", failures, p.Version, msg),
+		Data: map[string]interface{}{"failures": failures, "reason": reason, "last_error": msg, "version": p.Version, "hash": n.hash},
+	})
+}
+
+// expiryWatcher is a policyTarget that announces expiring records (see notifyingTarget); the midnight
+// re-evaluation checks it directly when there is no pruned policy to apply.
+type expiryWatcher interface {
+	checkExpiring(p *policy.Policy, now time.Time)
+}
+
+// checkExpiring announces records that entered the expiry window since the last check.
+func (n *notifyingTarget) checkExpiring(p *policy.Policy, now time.Time) {
+	if n.expiryWindow <= 0 {
+		return
+	}
+	expiring, _ := p.ExpiringWithin(now, n.expiryWindow)
+	var fresh []policy.Record
+	n.mu.Lock()
+	for _, r := range expiring {
+		k := r.Domain + "|" + r.Expires
+		if !n.warned[k] {
+			n.warned[k] = true
+			fresh = append(fresh, r)
+		}
+	}
+	n.mu.Unlock()
+	if len(fresh) == 0 {
+		return
+	}
+	items := make([]map[string]string, 0, len(fresh))
+	names := make([]string, 0, len(fresh))
+	for _, r := range fresh {
+		items = append(items, map[string]string{"domain": r.Domain, "classification": r.Classification, "expires": r.Expires})
+		names = append(names, r.Domain+" ("+r.Expires+")")
+	}
+	if len(names) > 5 {
+		names = append(names[:5], fmt.Sprintf("and %d more", len(fresh)-5))
+	}
+	n.send(notify.Event{
+		Type: notify.RecordsExpiring,
+		Text: fmt.Sprintf("%d policy record(s) expire within %d days: %s", len(fresh), int(n.expiryWindow.Hours()/24), strings.Join(names, ", ")),
+		Data: map[string]interface{}{"version": p.Version, "window_days": int(n.expiryWindow.Hours() / 24), "records": items},
+	})
+}
+
+func (n *notifyingTarget) send(e notify.Event) {
+	if !n.d.Notify(e) {
+		fmt.Printf("{\"event\":\"notify.dropped\",\"type\":%q}\n", e.Type)
+	}
+}
+
+func shortHash(h string) string { return h[:minInt(12, len(h))] }
+
+// splitList splits a comma-separated flag value, dropping blanks.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
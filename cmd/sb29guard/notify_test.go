@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/notify"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/server"
+)
+
+func TestNotifyFlagsRefreshAndExpiryEvents(t *testing.T) {
+	var mu sync.Mutex
+	var events []notify.Event
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if r.Header.Get(notify.HeaderSignature) != notify.Sign([]byte("hook-secret"), r.Header.Get(notify.HeaderTimestamp), b) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var e notify.Event
+		_ = json.Unmarshal(b, &e)
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secret, []byte("hook-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	parse := func(args ...string) *notifyFlags {
+		fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+		nf := addNotifyFlags(fs)
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		return nf
+	}
+	for args, want := range map[string]string{
+		"--notify-webhook ftp://x":                         "not an http(s) URL",
+		"--notify-smtp mail.school.org:25":                 "needs --notify-smtp-from",
+		"--notify-webhook http://x --notify-expiring soon": "--notify-expiring",
+		"--notify-webhook http://x --notify-webhook-secret-file " + filepath.Join(t.TempDir(), "missing"): "secret-file",
+	} {
+		if _, err := parse(strings.Fields(args)...).build(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", args, want, err)
+		}
+	}
+	if d, err := parse().build(); d != nil || err != nil {
+		t.Fatalf("expected no dispatcher without receivers, got %v %v", d, err)
+	}
+
+	nf := parse("--notify-webhook", ts.URL+"/hook", "--notify-webhook-secret-file", secret, "--notify-refresh-failures", "2", "--notify-expiring", "7d")
+	var d *notify.Dispatcher
+	out := captureOutput(t, func() {
+		var err error
+		if d, err = nf.build(); err != nil {
+			t.Errorf("build: %v", err)
+		}
+	})
+	if !strings.Contains(out, `"event":"notify.configured","webhooks":1`) {
+		t.Fatalf("unexpected event: %s", out)
+	}
+	soon := time.Now().AddDate(0, 0, 3).Format("2006-01-02")
+	nextWeek := time.Now().AddDate(0, 0, 8).Format("2006-01-02")
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "exampletool.com", Classification: "NO_DPA", Rationale: "r", LastReview: "2025-08-01", Status: "active", Expires: soon},
+		{Domain: "later.example", Classification: "NO_DPA", Rationale: "r", LastReview: "2025-08-01", Status: "active", Expires: "2999-01-01"},
+		{Domain: "nextweek.example", Classification: "NO_DPA", Rationale: "r", LastReview: "2025-08-01", Status: "active", Expires: nextWeek},
+	}}
+	srv := server.New(":0", p)
+	target := nf.wrap(srv, d)
+
+	// Midnight re-evaluation with nothing lapsed: the expiring record is not repeated, but one
+	// that has since entered the window is announced.
+	captureOutput(t, func() { dropExpiredRecords(target, nil, time.Now()) })
+	captureOutput(t, func() { dropExpiredRecords(target, nil, time.Now().AddDate(0, 0, 2)) })
+	for i := 0; i < 3; i++ {
+		target.RecordRefreshError(refreshFetchError, "sheet unreachable")
+	}
+	p2 := &policy.Policy{Version: "0.2.0", Updated: "2025-08-09", Records: append([]policy.Record{
+		{Domain: "newtool.com", Classification: "NO_DPA", Rationale: "r", LastReview: "2025-08-01", Status: "active"},
+	}, p.Records...)}
+	acceptPolicy(target, nil, p2, "csv")
+	acceptPolicy(target, nil, p2, "csv")
+	if srv.CurrentPolicy().Version != "0.2.0" {
+		t.Fatalf("wrapper did not pass the policy through")
+	}
+	closeNotifier(d, 5*time.Second)
+
+	mu.Lock()
+	defer mu.Unlock()
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	if strings.Join(types, ",") != "records.expiring,records.expiring,refresh.failing,policy.changed" {
+		t.Fatalf("unexpected events: %v", types)
+	}
+	if !strings.Contains(events[0].Text, "exampletool.com ("+soon+")") || strings.Contains(events[0].Text, "later.example") {
+		t.Fatalf("unexpected expiry text: %s", events[0].Text)
+	}
+	if !strings.Contains(events[1].Text, "nextweek.example") || strings.Contains(events[1].Text, "exampletool.com") {
+		t.Fatalf("unexpected midnight expiry text: %s", events[1].Text)
+	}
+	if events[2].Data["failures"] != float64(2) || events[2].Data["reason"] != refreshFetchError || events[2].Data["last_error"] != "sheet unreachable" {
+		t.Fatalf("unexpected failure data: %v", events[2].Data)
+	}
+	if events[3].Data["source"] != "csv" || events[3].Data["new_hash"] != p2.CanonicalHash() || events[3].Data["old_hash"] != p.CanonicalHash() {
+		t.Fatalf("unexpected change data: %v", events[3].Data)
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/reviews"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/server"
+)
+
+// reviewFlags are the serve flags for the opt-in review request form.
+type reviewFlags struct {
+	enabled    *bool
+	perClient  *int
+	total      *int
+	rateWindow time.Duration
+}
+
+func addReviewFlags(fs *flag.FlagSet) *reviewFlags {
+	return &reviewFlags{
+		enabled:    fs.Bool("request-access", false, "Show a review request form on the explain page; requests queue under <cache-dir>/reviews"),
+		perClient:  fs.Int("request-rate-client", server.DefaultReviewRate.PerClient, "Review requests accepted per client address per hour (0 = no limit)"),
+		total:      fs.Int("request-rate-total", server.DefaultReviewRate.Total, "Review requests accepted per hour from all clients (0 = no limit)"),
+		rateWindow: server.DefaultReviewRate.Window,
+	}
+}
+
+// apply opens the review queue under cacheDir and enables the form on srv. Failures are
+// logged and the form stays off.
+func (f *reviewFlags) apply(srv *server.Server, cacheDir string) {
+	if !*f.enabled {
+		return
+	}
+	q, err := reviews.Open(cacheDir)
+	if err == nil {
+		err = srv.SetReviews(q, server.RateLimit{PerClient: *f.perClient, Total: *f.total, Window: f.rateWindow})
+	}
+	if err != nil {
+		fmt.Printf("{\"event\":\"requests.error\",\"message\":%q}\n", err.Error())
+		return
+	}
+	fmt.Printf("{\"event\":\"requests.enabled\",\"dir\":%q,\"per_client\":%d,\"total\":%d}\n", q.Dir(), *f.perClient, *f.total)
+}
+
+func cmdRequests(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: sb29guard requests list | export | close --id <id> --resolution <resolution>")
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "list":
+		cmdRequestsList(args[1:])
+	case "export":
+		cmdRequestsExport(args[1:])
+	case "close":
+		cmdRequestsClose(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown requests command: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// requestSource selects the queue file under --cache-dir or a running server's /admin/requests.
+type requestSource struct {
+	cacheDir  *string
+	serverURL *string
+}
+
+func addRequestSource(fs *flag.FlagSet) requestSource {
+	return requestSource{
+		cacheDir:  fs.String("cache-dir", "cache", "Cache directory holding the review queue"),
+		serverURL: fs.String("server", "", "Base URL of a running serve instance (authenticates like rollback --server)"),
+	}
+}
+
+func (s requestSource) list(status string) ([]reviews.Request, error) {
+	if *s.serverURL == "" {
+		q, err := reviews.Open(*s.cacheDir)
+		if err != nil {
+			return nil, err
+		}
+		if status == "all" {
+			status = ""
+		}
+		return q.List(status)
+	}
+	var out struct {
+		Requests []reviews.Request `json:"requests"`
+	}
+	err := adminRequest(http.MethodGet, strings.TrimRight(*s.serverURL, "/")+"/admin/requests?status="+url.QueryEscape(status), &out)
+	return out.Requests, err
+}
+
+// errRejected marks a server's non-200 answer, as opposed to a network failure.
+var errRejected = errors.New("server rejected request")
+
+// adminRequest calls a protected JSON endpoint and decodes a 200 response into v; other
+// responses become errRejected errors carrying the server's error detail.
+func adminRequest(method, endpoint string, v interface{}) error {
+	req, _ := http.NewRequest(method, endpoint, nil)
+	setAdminAuth(req)
+	resp, err := (&http.Client{Timeout: 15 * time.Second}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if resp.StatusCode != http.StatusOK {
+		var e struct{ Detail string }
+		if json.Unmarshal(body, &e) == nil && e.Detail != "" {
+			return fmt.Errorf("%w: %s: %s", errRejected, resp.Status, e.Detail)
+		}
+		return fmt.Errorf("%w: %s: %s", errRejected, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func checkStatus(status string) {
+	if status != reviews.StatusOpen && status != reviews.StatusClosed && status != "all" {
+		fmt.Fprintf(os.Stderr, "unknown status: %s (use open|closed|all)\n", status)
+		os.Exit(1)
+	}
+}
+
+// cmdRequestsList prints review requests, oldest first.
+func cmdRequestsList(args []string) {
+	fs := flag.NewFlagSet("requests", flag.ExitOnError)
+	src := addRequestSource(fs)
+	status := fs.String("status", reviews.StatusOpen, "Requests to show: open|closed|all")
+	format := fs.String("format", "text", "Output format: text|json")
+	parseFlags(fs, args)
+	checkStatus(*status)
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format: %s (use text|json)\n", *format)
+		os.Exit(1)
+	}
+	list, err := src.list(*status)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	if *format == "json" {
+		if list == nil {
+			list = []reviews.Request{}
+		}
+		_ = json.NewEncoder(os.Stdout).Encode(list)
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSUBMITTED\tDOMAIN\tCLASSIFICATION\tSTATUS\tEMAIL\tJUSTIFICATION")
+	for _, r := range list {
+		status := r.Status
+		if r.Resolution != "" {
+			status += ":" + r.Resolution
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.Submitted.Local().Format("2006-01-02 15:04"), r.Domain, r.Classification, status, dashIfEmpty(r.Email), oneLine(r.Justification, 60))
+	}
+	_ = tw.Flush()
+	fmt.Printf("%d %s requests\n", len(list), *status)
+}
+
+// cmdRequestsExport writes review requests as CSV (for a spreadsheet) or JSON.
+func cmdRequestsExport(args []string) {
+	fs := flag.NewFlagSet("requests", flag.ExitOnError)
+	src := addRequestSource(fs)
+	status := fs.String("status", "all", "Requests to export: open|closed|all")
+	format := fs.String("format", "csv", "Output format: csv|json")
+	out := fs.String("out", "", "Output file (default stdout)")
+	parseFlags(fs, args)
+	checkStatus(*status)
+	if *format != "csv" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format: %s (use csv|json)\n", *format)
+		os.Exit(1)
+	}
+	list, err := src.list(*status)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.OpenFile(*out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	if *format == "json" {
+		if list == nil {
+			list = []reviews.Request{}
+		}
+		err = json.NewEncoder(w).Encode(list)
+	} else {
+		err = writeRequestsCSV(w, list)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+}
+
+func writeRequestsCSV(w io.Writer, list []reviews.Request) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"id", "submitted", "domain", "classification", "policy_version", "status", "resolution", "closed", "email", "justification", "note"})
+	for _, r := range list {
+		closed := ""
+		if r.Closed != nil {
+			closed = r.Closed.Format(time.RFC3339)
+		}
+		_ = cw.Write([]string{r.ID, r.Submitted.Format(time.RFC3339), r.Domain, r.Classification, r.PolicyVersion, r.Status, r.Resolution, closed,
+			csvText(r.Email), csvText(r.Justification), csvText(r.Note)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvText keeps spreadsheet apps from evaluating submitted text as a formula.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// oneLine flattens s to a single line of at most n characters for table output.
+func oneLine(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// cmdRequestsClose records a triage decision for one request.
+func cmdRequestsClose(args []string) {
+	fs := flag.NewFlagSet("requests", flag.ExitOnError)
+	src := addRequestSource(fs)
+	id := fs.String("id", "", "Request ID (from requests list)")
+	resolution := fs.String("resolution", "", "Decision: "+strings.Join(reviews.Resolutions, "|"))
+	note := fs.String("note", "", "Optional note kept with the request")
+	parseFlags(fs, args)
+	if strings.TrimSpace(*id) == "" || *resolution == "" {
+		fmt.Fprintln(os.Stderr, "--id and --resolution required")
+		os.Exit(1)
+	}
+	var r reviews.Request
+	var err error
+	if *src.serverURL != "" {
+		v := url.Values{"id": {*id}, "resolution": {*resolution}, "note": {*note}}
+		err = adminRequest(http.MethodPost, strings.TrimRight(*src.serverURL, "/")+"/admin/requests/close?"+v.Encode(), &r)
+	} else {
+		var q *reviews.Queue
+		if q, err = reviews.Open(*src.cacheDir); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		r, err = q.Close(*id, *resolution, *note)
+	}
+	if err != nil {
+		fmt.Printf("{\"status\":\"error\",\"message\":%q}\n", err.Error())
+		if errors.Is(err, reviews.ErrInvalid) || errors.Is(err, reviews.ErrNotFound) || errors.Is(err, reviews.ErrAlreadyClosed) || errors.Is(err, errRejected) {
+			os.Exit(1)
+		}
+		os.Exit(2)
+	}
+	fmt.Printf("{\"status\":\"ok\",\"id\":%q,\"domain\":%q,\"resolution\":%q}\n", r.ID, r.Domain, r.Resolution)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/reviews"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/server"
+)
+
+func TestCmdRequestsListExportClose(t *testing.T) {
+	cacheDir := t.TempDir()
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	rf := addReviewFlags(fs)
+	if err := fs.Parse([]string{"--request-access", "--request-rate-client", "3"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := server.New(":0", &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "exampletool.com", Classification: "NO_DPA", Rationale: "r", LastReview: "2025-08-01", Status: "active"},
+	}})
+	out := captureOutput(t, func() { rf.apply(srv, cacheDir) })
+	if !strings.Contains(out, `"event":"requests.enabled"`) || !strings.Contains(out, `"per_client":3`) {
+		t.Fatalf("unexpected event: %s", out)
+	}
+	q, err := reviews.Open(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := q.Submit(reviews.Request{Domain: "exampletool.com", Classification: "NO_DPA", PolicyVersion: "0.1.0", Justification: "=HYPERLINK(\"x\")\nsecond line", Email: "teacher@school.org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := q.Submit(reviews.Request{Domain: "exampletool.com", Classification: "NO_DPA", Justification: "Art class"})
+
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	out = captureOutput(t, func() { cmdRequestsList([]string{"--server", ts.URL, "--format", "json"}) })
+	var list []reviews.Request
+	if err := json.Unmarshal([]byte(out), &list); err != nil || len(list) != 2 || list[0].ID != first.ID {
+		t.Fatalf("unexpected server list: %s", out)
+	}
+
+	out = captureOutput(t, func() {
+		cmdRequestsClose([]string{"--server", ts.URL, "--id", second.ID, "--resolution", "duplicate"})
+	})
+	if !strings.Contains(out, `"status":"ok"`) || !strings.Contains(out, `"resolution":"duplicate"`) {
+		t.Fatalf("unexpected close output: %s", out)
+	}
+	out = captureOutput(t, func() {
+		cmdRequestsClose([]string{"--cache-dir", cacheDir, "--id", first.ID, "--resolution", "approved", "--note", "DPA signed"})
+	})
+	if !strings.Contains(out, `"status":"ok"`) {
+		t.Fatalf("unexpected close output: %s", out)
+	}
+
+	out = captureOutput(t, func() { cmdRequestsList([]string{"--cache-dir", cacheDir}) })
+	if !strings.Contains(out, "0 open requests") {
+		t.Fatalf("expected empty open list: %s", out)
+	}
+	out = captureOutput(t, func() { cmdRequestsList([]string{"--cache-dir", cacheDir, "--status", "closed"}) })
+	if !strings.Contains(out, "closed:approved") || !strings.Contains(out, `=HYPERLINK("x") second line`) {
+		t.Fatalf("unexpected closed list: %s", out)
+	}
+
+	exp := filepath.Join(t.TempDir(), "requests.csv")
+	captureOutput(t, func() { cmdRequestsExport([]string{"--cache-dir", cacheDir, "--out", exp}) })
+	f, err := os.Open(exp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil || len(rows) != 3 {
+		t.Fatalf("unexpected csv: %v %v", rows, err)
+	}
+	if rows[0][9] != "justification" || rows[1][0] != first.ID || rows[1][9] != "'=HYPERLINK(\"x\")\nsecond line" || rows[1][10] != "DPA signed" || rows[2][6] != "duplicate" {
+		t.Fatalf("unexpected csv rows: %q", rows)
+	}
+}
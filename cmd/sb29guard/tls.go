@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/certs"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/server"
+)
+
+// tlsFlags are the serve flags for native TLS termination.
+type tlsFlags struct {
+	listen         *string
+	cert           *string
+	key            *string
+	selfSigned     *bool
+	host           *string
+	dir            *string
+	caCert         *string
+	caKey          *string
+	reloadInterval *time.Duration
+}
+
+func addTLSFlags(fs *flag.FlagSet) *tlsFlags {
+	return &tlsFlags{
+		listen:         fs.String("tls-listen", ":8443", "HTTPS listen address, used when TLS is enabled (plain HTTP stays on --listen)"),
+		cert:           fs.String("tls-cert", "", "TLS certificate (PEM); reloaded when the file changes"),
+		key:            fs.String("tls-key", "", "TLS private key (PEM) for --tls-cert"),
+		selfSigned:     fs.Bool("tls-self-signed", false, "Generate a self-signed certificate for --tls-host on first boot (when --tls-cert is not set)"),
+		host:           fs.String("tls-host", "", "Guard host name for the self-signed certificate and CA issuance (default: system host name)"),
+		dir:            fs.String("tls-dir", "", "Directory for the self-signed certificate (default <cache-dir>/tls)"),
+		caCert:         fs.String("tls-ca-cert", "", "District CA certificate (PEM) used to issue certificates for blocked host names on the fly"),
+		caKey:          fs.String("tls-ca-key", "", "Private key (PEM) for --tls-ca-cert"),
+		reloadInterval: fs.Duration("tls-reload-interval", 30*time.Second, "How often to check --tls-cert/--tls-key for changes"),
+	}
+}
+
+func (f *tlsFlags) enabled() bool {
+	return *f.cert != "" || *f.key != "" || *f.selfSigned || *f.caCert != "" || *f.caKey != ""
+}
+
+// apply configures HTTPS on srv when any TLS flag is set.
+func (f *tlsFlags) apply(srv *server.Server, cacheDir string) error {
+	if !f.enabled() {
+		return nil
+	}
+	if (*f.cert == "") != (*f.key == "") {
+		return errors.New("--tls-cert and --tls-key must be given together")
+	}
+	if (*f.caCert == "") != (*f.caKey == "") {
+		return errors.New("--tls-ca-cert and --tls-ca-key must be given together")
+	}
+	if *f.cert != "" && *f.selfSigned {
+		return errors.New("--tls-self-signed cannot be combined with --tls-cert")
+	}
+	host := strings.ToLower(strings.TrimSpace(*f.host))
+	if host == "" {
+		h, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("--tls-host: %w", err)
+		}
+		host = strings.ToLower(h)
+	}
+	src := &certs.Source{}
+	certFile, keyFile := *f.cert, *f.key
+	if *f.selfSigned {
+		dir := *f.dir
+		if dir == "" {
+			dir = filepath.Join(cacheDir, "tls")
+		}
+		var created bool
+		var err error
+		certFile, keyFile, created, err = certs.EnsureSelfSigned(dir, host)
+		if err != nil {
+			return fmt.Errorf("self-signed certificate: %w", err)
+		}
+		if created {
+			fmt.Printf("{\"event\":\"tls.selfsigned.created\",\"host\":%q,\"cert\":%q}\n", host, certFile)
+		}
+	}
+	if certFile != "" {
+		r, err := certs.NewReloader(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		src.Static = r
+		go watchCertFiles(r, *f.reloadInterval, srv.Done())
+	}
+	if *f.caCert != "" {
+		iss, err := certs.NewIssuer(*f.caCert, *f.caKey)
+		if err != nil {
+			return err
+		}
+		src.Issuer = iss
+		// Only the guard itself and names the policy blocks get certificates, so arbitrary
+		// SNI values cannot make the guard mint certificates for the district CA.
+		src.Allow = func(name string) bool {
+			if name == host {
+				return true
+			}
+			_, blocked := srv.CurrentPolicy().Lookup(name)
+			return blocked
+		}
+	}
+	srv.SetTLS(*f.listen, src.TLSConfig())
+	fmt.Printf("{\"event\":\"tls.enabled\",\"listen\":%q,\"host\":%q,\"static\":%t,\"ca_issuance\":%t}\n", *f.listen, host, src.Static != nil, src.Issuer != nil)
+	return nil
+}
+
+// watchCertFiles reloads the certificate when its files change until stop is closed.
+// A broken pair is reported and the previous certificate keeps serving.
+func watchCertFiles(r *certs.Reloader, interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+		changed, err := r.ReloadIfChanged()
+		if err != nil {
+			fmt.Printf("{\"event\":\"tls.reload.error\",\"message\":%q}\n", err.Error())
+			continue
+		}
+		if changed {
+			fmt.Printf("{\"event\":\"tls.reloaded\",\"not_after\":%q}\n", r.Certificate().Leaf.NotAfter.Format(time.RFC3339))
+		}
+	}
+}
@@ -12,15 +12,18 @@
 #   GUARD_BASE=https://guard.school.internal
 #   OUT_FILE=/etc/proxy/blocked.txt
 #   ONLY_WHEN_CHANGED=true
+#   GUARD_TOKEN=<token>   # bearer token for /metrics and /domain-list (sb29guard auth token; needed unless run on the guard host)
 
 set -euo pipefail
 # Allow env overrides; fall back to sensible defaults
 GUARD_BASE="${GUARD_BASE:-https://guard.school.internal}"
 OUT_FILE="${OUT_FILE:-/etc/proxy/blocked.txt}"
 ONLY_WHEN_CHANGED="${ONLY_WHEN_CHANGED:-true}"
+AUTH=()
+[[ -n "${GUARD_TOKEN:-}" ]] && AUTH=(-H "Authorization: Bearer $GUARD_TOKEN")
 
 get_policy_version(){
-  curl -fsS "$GUARD_BASE/metrics" | jq -r '.policy_version // ""' 2>/dev/null || true
+  curl -fsS ${AUTH[@]+"${AUTH[@]}"} "$GUARD_BASE/metrics" | jq -r '.policy_version // ""' 2>/dev/null || true
 }
 
 prev_ver_file="${OUT_FILE}.ver"
@@ -32,7 +35,7 @@ if [[ "$ONLY_WHEN_CHANGED" == "true" && -n "$cur_ver" && "$cur_ver" == "$prev_ve
 fi
 
 echo "Fetching domain list from $GUARD_BASE/domain-list"
-curl -fsS "$GUARD_BASE/domain-list" -o "$OUT_FILE"
+curl -fsS ${AUTH[@]+"${AUTH[@]}"} "$GUARD_BASE/domain-list" -o "$OUT_FILE"
 
 # PROXY: Replace with your product's import/reload.
 # NGINX example using rsync + reload on the proxy host:
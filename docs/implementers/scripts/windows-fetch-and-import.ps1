@@ -16,14 +16,18 @@
 param(
   [string]$GuardBase = "https://guard.school.internal",
   [string]$OutFile   = "C:\\sb29\\blocked.txt",
-  [switch]$OnlyWhenChanged = $true
+  [switch]$OnlyWhenChanged = $true,
+  # Bearer token for /metrics and /domain-list (sb29guard auth token); needed unless run on the guard host
+  [string]$Token     = $env:GUARD_TOKEN
 )
 
 $ErrorActionPreference = "Stop"
+$Headers = @{}
+if ($Token) { $Headers["Authorization"] = "Bearer $Token" }
 
 function Get-PolicyVersion {
   try {
-    $m = Invoke-WebRequest -UseBasicParsing -Uri "$GuardBase/metrics" | Select-Object -ExpandProperty Content | ConvertFrom-Json
+    $m = Invoke-WebRequest -UseBasicParsing -Headers $Headers -Uri "$GuardBase/metrics" | Select-Object -ExpandProperty Content | ConvertFrom-Json
     return $m.policy_version
   } catch {
     return ""
@@ -39,7 +43,7 @@ if ($OnlyWhenChanged -and $curVer -and $curVer -eq $prevVer) {
 }
 
 Write-Host "Fetching domain list from $GuardBase/domain-list"
-Invoke-WebRequest -UseBasicParsing -Uri "$GuardBase/domain-list" -OutFile $OutFile
+Invoke-WebRequest -UseBasicParsing -Headers $Headers -Uri "$GuardBase/domain-list" -OutFile $OutFile
 
 <#
 PROXY IMPORT/RELOAD EXAMPLES (pick one and customize)
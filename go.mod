@@ -4,5 +4,9 @@ go 1.22
 
 require (
 	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+	golang.org/x/crypto v0.31.0
+	golang.org/x/net v0.33.0
 	gopkg.in/yaml.v3 v3.0.1
 )
+
+require golang.org/x/text v0.21.0 // indirect
@@ -0,0 +1,173 @@
+// Package certs provides the certificates for serve's native TLS listener: a key pair loaded
+// from files and reloaded when they change, a self-signed certificate generated for the guard
+// host on first boot, and leaf certificates issued on the fly from a district-internal CA.
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reloader holds a certificate loaded from a PEM certificate and key file pair and reloads it
+// when either file changes. It is safe for concurrent use.
+type Reloader struct {
+	certFile, keyFile string
+
+	mu    sync.RWMutex
+	cert  *tls.Certificate
+	stamp string
+}
+
+// NewReloader loads the key pair; the files must be valid now.
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.ReloadIfChanged(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Certificate returns the current certificate.
+func (r *Reloader) Certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+// Covers reports whether the current certificate is valid for name.
+func (r *Reloader) Covers(name string) bool {
+	c := r.Certificate()
+	return c != nil && c.Leaf != nil && c.Leaf.VerifyHostname(name) == nil
+}
+
+// ReloadIfChanged reloads the key pair when the size or modification time of either file
+// changed since the last load. On error the previous certificate stays in use.
+func (r *Reloader) ReloadIfChanged() (bool, error) {
+	stamp, err := fileStamp(r.certFile, r.keyFile)
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	same := stamp == r.stamp
+	r.mu.RUnlock()
+	if same {
+		return false, nil
+	}
+	cert, err := loadKeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	r.cert, r.stamp = cert, stamp
+	r.mu.Unlock()
+	return true, nil
+}
+
+func fileStamp(paths ...string) (string, error) {
+	var b strings.Builder
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%d/%d;", fi.Size(), fi.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+// loadKeyPair loads a PEM key pair and parses its leaf.
+func loadKeyPair(certFile, keyFile string) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, err
+		}
+	}
+	return &cert, nil
+}
+
+// SelfSignedValidity is the lifetime of certificates made by EnsureSelfSigned.
+const SelfSignedValidity = 825 * 24 * time.Hour
+
+// EnsureSelfSigned returns the paths of a self-signed certificate for host in dir
+// (<host>.crt and <host>.key), generating them on first use. created reports whether new
+// files were written. An expired certificate is replaced.
+func EnsureSelfSigned(dir, host string) (certFile, keyFile string, created bool, err error) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" {
+		return "", "", false, errors.New("self-signed certificate needs a host name")
+	}
+	certFile = filepath.Join(dir, host+".crt")
+	keyFile = filepath.Join(dir, host+".key")
+	if c, err := loadKeyPair(certFile, keyFile); err == nil && time.Now().Before(c.Leaf.NotAfter) {
+		return certFile, keyFile, false, nil
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", false, err
+	}
+	tmpl, err := leafTemplate(host, SelfSignedValidity)
+	if err != nil {
+		return "", "", false, err
+	}
+	tmpl.Subject.Organization = []string{"SB29 Guard (self-signed)"}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return "", "", false, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", false, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", "", false, err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return "", "", false, err
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		return "", "", false, err
+	}
+	return certFile, keyFile, true, nil
+}
+
+// leafTemplate returns a server certificate template for name (a DNS name or IP address).
+func leafTemplate(name string, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	t := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	if ip := net.ParseIP(name); ip != nil {
+		t.IPAddresses = []net.IP{ip}
+	} else {
+		t.DNSNames = []string{name}
+	}
+	return t, nil
+}
@@ -0,0 +1,155 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEnsureSelfSignedCreatesOnceAndReloads(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, created, err := EnsureSelfSigned(dir, "Guard.District.Local")
+	if err != nil || !created {
+		t.Fatalf("first boot: created=%v err=%v", created, err)
+	}
+	if fi, err := os.Stat(keyFile); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Fatalf("key file should be 0600: %v %v", fi, err)
+	}
+	if _, _, created, err := EnsureSelfSigned(dir, "guard.district.local"); err != nil || created {
+		t.Fatalf("second boot should reuse: created=%v err=%v", created, err)
+	}
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("reloader: %v", err)
+	}
+	if !r.Covers("guard.district.local") || r.Covers("other.example") {
+		t.Fatalf("unexpected coverage for %v", r.Certificate().Leaf.DNSNames)
+	}
+	if changed, err := r.ReloadIfChanged(); changed || err != nil {
+		t.Fatalf("unchanged files reloaded: %v %v", changed, err)
+	}
+	first := r.Certificate()
+
+	// Replace the pair (as cert-manager or an operator would) and reload.
+	other := t.TempDir()
+	c2, k2, _, err := EnsureSelfSigned(other, "guard.district.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	copyFile(t, k2, keyFile)
+	copyFile(t, c2, certFile)
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, future, future)
+	if changed, err := r.ReloadIfChanged(); !changed || err != nil {
+		t.Fatalf("expected reload: %v %v", changed, err)
+	}
+	if r.Certificate() == first {
+		t.Fatalf("certificate not replaced")
+	}
+
+	// A broken write keeps the last good certificate.
+	if err := os.WriteFile(certFile, []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReloadIfChanged(); err == nil {
+		t.Fatalf("expected error for broken certificate")
+	}
+	if r.Certificate() == nil {
+		t.Fatalf("last good certificate dropped")
+	}
+}
+
+func copyFile(t *testing.T, from, to string) {
+	t.Helper()
+	b, err := os.ReadFile(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(to, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeCA writes a test CA pair and returns its paths and certificate.
+func writeCA(t *testing.T, isCA bool) (string, string, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "District Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	_ = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644)
+	_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile, ca
+}
+
+func TestIssuerAndSource(t *testing.T) {
+	notCA, notCAKey, _ := writeCA(t, false)
+	if _, err := NewIssuer(notCA, notCAKey); err == nil {
+		t.Fatalf("expected error for a non-CA certificate")
+	}
+	caCert, caKey, ca := writeCA(t, true)
+	iss, err := NewIssuer(caCert, caKey)
+	if err != nil {
+		t.Fatalf("issuer: %v", err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile, _, err := EnsureSelfSigned(dir, "guard.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	static, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := &Source{Static: static, Issuer: iss, Allow: func(name string) bool { return name == "blocked.example" }}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	c, err := src.GetCertificate(&tls.ClientHelloInfo{ServerName: "Blocked.Example."})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if _, err := c.Leaf.Verify(x509.VerifyOptions{DNSName: "blocked.example", Roots: pool}); err != nil {
+		t.Fatalf("issued leaf does not verify against the CA: %v", err)
+	}
+	again, _ := src.GetCertificate(&tls.ClientHelloInfo{ServerName: "blocked.example"})
+	if again != c {
+		t.Fatalf("expected cached leaf")
+	}
+	for _, name := range []string{"", "guard.local", "not-blocked.example"} {
+		c, err := src.GetCertificate(&tls.ClientHelloInfo{ServerName: name})
+		if err != nil || c != static.Certificate() {
+			t.Fatalf("%q should get the static certificate: %v", name, err)
+		}
+	}
+	only := &Source{Issuer: iss, Allow: func(string) bool { return false }}
+	if _, err := only.GetCertificate(&tls.ClientHelloInfo{ServerName: "random.example"}); err == nil {
+		t.Fatalf("expected refusal for a name outside Allow")
+	}
+}
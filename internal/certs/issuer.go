@@ -0,0 +1,118 @@
+package certs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LeafValidity is the lifetime of certificates made by an Issuer; cached leaves are
+// re-issued once less than a day remains.
+const LeafValidity = 7 * 24 * time.Hour
+
+// maxCachedLeaves bounds the issuer's cache; it is cleared when full.
+const maxCachedLeaves = 4096
+
+// Issuer signs leaf certificates on the fly with a district-internal CA, so the explain page
+// can be served over HTTPS for a blocked hostname on devices that trust that CA.
+type Issuer struct {
+	ca  *x509.Certificate
+	key crypto.Signer
+
+	mu     sync.Mutex
+	leaves map[string]*tls.Certificate
+}
+
+// NewIssuer loads the CA certificate and private key (PEM). The certificate must be a CA
+// allowed to sign certificates.
+func NewIssuer(caCertFile, caKeyFile string) (*Issuer, error) {
+	pair, err := loadKeyPair(caCertFile, caKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load CA: %w", err)
+	}
+	ca := pair.Leaf
+	if !ca.IsCA || ca.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return nil, errors.New("load CA: certificate is not a CA allowed to sign certificates")
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("load CA: unsupported private key type")
+	}
+	return &Issuer{ca: ca, key: key, leaves: map[string]*tls.Certificate{}}, nil
+}
+
+// Issue returns a leaf certificate for name, from the cache while it has at least a day left.
+func (i *Issuer) Issue(name string) (*tls.Certificate, error) {
+	now := time.Now()
+	i.mu.Lock()
+	c, ok := i.leaves[name]
+	i.mu.Unlock()
+	if ok && now.Add(24*time.Hour).Before(c.Leaf.NotAfter) {
+		return c, nil
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := leafTemplate(name, LeafValidity)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl.NotAfter.After(i.ca.NotAfter) {
+		tmpl.NotAfter = i.ca.NotAfter
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, i.ca, &key.PublicKey, i.key)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	c = &tls.Certificate{Certificate: [][]byte{der, i.ca.Raw}, PrivateKey: key, Leaf: leaf}
+	i.mu.Lock()
+	if len(i.leaves) >= maxCachedLeaves {
+		i.leaves = map[string]*tls.Certificate{}
+	}
+	i.leaves[name] = c
+	i.mu.Unlock()
+	return c, nil
+}
+
+// Source picks the certificate for each TLS handshake: the static certificate when it covers
+// the requested name (or no name was sent), else a leaf from Issuer when Allow accepts the
+// name, else the static certificate. Either Static or Issuer may be nil.
+type Source struct {
+	Static *Reloader
+	Issuer *Issuer
+	// Allow limits issuance, e.g. to the guard host and names the policy blocks; nil allows any.
+	Allow func(name string) bool
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (s *Source) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	name := strings.TrimSuffix(strings.ToLower(hello.ServerName), ".")
+	if s.Static != nil && (name == "" || s.Static.Covers(name)) {
+		return s.Static.Certificate(), nil
+	}
+	if s.Issuer != nil && name != "" && (s.Allow == nil || s.Allow(name)) {
+		return s.Issuer.Issue(name)
+	}
+	if s.Static != nil {
+		return s.Static.Certificate(), nil
+	}
+	return nil, fmt.Errorf("no certificate for %q", name)
+}
+
+// TLSConfig returns a server TLS configuration using s.
+func (s *Source) TLSConfig() *tls.Config {
+	return &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: s.GetCertificate}
+}
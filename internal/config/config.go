@@ -0,0 +1,450 @@
+// Package config loads the application config file (app.yaml) shared by all subcommands.
+//
+// Every setting is a Key with a dotted name (e.g. serve.listen), an environment variable
+// and the subcommand flags it fills. The CLI resolves each flag the user did not pass as:
+// environment variable, then config file, then the flag default. The file is YAML with one
+// section per dotted prefix:
+//
+//	policy:
+//	  file: policy/domains.yaml
+//	serve:
+//	  listen: ":8080"
+//	dns:
+//	  presets:
+//	    pihole: {format: dnsmasq, redirect_ipv4: 10.10.10.50}
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+)
+
+// Kind is the value type of a Key.
+type Kind int
+
+// Value kinds.
+const (
+	String Kind = iota
+	Bool
+	Int
+	Float
+	Duration
+	Clock    // HH:MM, 24-hour local time
+	HostPort // listen address host:port (host may be empty)
+	URL      // absolute http(s) URL
+	IPv4
+	IPv6
+	Enum  // one of Choices
+	Class // classification key
+)
+
+// Key describes one setting.
+type Key struct {
+	Name     string   // dotted name, e.g. serve.listen
+	Env      string   // environment variable
+	Kind     Kind     // value type
+	Choices  []string // allowed values for Enum
+	Default  string   // documentation only; the flag default applies when unset
+	Flag     string   // flag name filled by this key
+	Commands []string // subcommands that have Flag
+	Preset   bool     // may be set per DNS preset (dns.presets.<name>.<field>)
+	Help     string
+}
+
+var policyCommands = []string{"validate", "hash", "serve", "dns-serve", "generate-dns", "generate-proxy"}
+
+// Keys lists every setting in the order config print writes them.
+var Keys = []Key{
+	{Name: "policy.file", Env: "SB29_POLICY_FILE", Default: "policy/domains.yaml", Flag: "policy", Commands: policyCommands, Help: "Policy YAML path"},
+	{Name: "policy.sheet_csv", Env: "SB29_POLICY_SHEET_CSV", Kind: URL, Flag: "sheet-csv", Commands: policyCommands, Help: "Published Google Sheet CSV URL (overrides policy.file)"},
+	{Name: "policy.strict", Env: "SB29_POLICY_STRICT", Kind: Bool, Default: "true", Flag: "strict", Commands: []string{"validate", "hash", "diff"}, Help: "Enforce JSON Schema validation"},
+	{Name: "policy.expired_classification", Env: "SB29_POLICY_EXPIRED_CLASSIFICATION", Kind: Class, Flag: "expired-classification", Commands: []string{"serve", "dns-serve", "generate-dns", "generate-proxy"}, Help: "Grace mode classification for expired records"},
+	{Name: "policy.cache_dir", Env: "SB29_POLICY_CACHE_DIR", Default: "cache", Flag: "cache-dir", Commands: []string{"serve", "dns-serve", "rollback", "report", "requests"}, Help: "Sheet CSV cache, policy journal, hit counts and review queue directory"},
+	{Name: "policy.journal", Env: "SB29_POLICY_JOURNAL", Kind: Bool, Default: "true", Flag: "journal", Commands: []string{"serve", "dns-serve"}, Help: "Record accepted policy snapshots"},
+
+	{Name: "refresh.at", Env: "SB29_REFRESH_AT", Kind: Clock, Default: "23:59", Flag: "refresh-at", Commands: []string{"serve", "dns-serve"}, Help: "Daily sheet refresh time (HH:MM local)"},
+	{Name: "refresh.every", Env: "SB29_REFRESH_EVERY", Kind: Duration, Default: "0s", Flag: "refresh-every", Commands: []string{"serve", "dns-serve"}, Help: "Sheet refresh interval instead of refresh.at (0 = daily)"},
+	{Name: "refresh.watch", Env: "SB29_REFRESH_WATCH", Kind: Bool, Default: "false", Flag: "watch", Commands: []string{"serve", "dns-serve"}, Help: "Reload policy.file when it changes on disk"},
+	{Name: "refresh.watch_interval", Env: "SB29_REFRESH_WATCH_INTERVAL", Kind: Duration, Default: "2s", Flag: "watch-interval", Commands: []string{"serve", "dns-serve"}, Help: "Polling interval for refresh.watch"},
+	{Name: "refresh.guard_max_drop_percent", Env: "SB29_REFRESH_GUARD_MAX_DROP_PERCENT", Kind: Float, Default: "50", Flag: "guard-max-drop-percent", Commands: []string{"serve", "dns-serve"}, Help: "Reject refreshes that shrink active records by more than this percent"},
+	{Name: "refresh.guard_max_drop", Env: "SB29_REFRESH_GUARD_MAX_DROP", Kind: Int, Default: "0", Flag: "guard-max-drop", Commands: []string{"serve", "dns-serve"}, Help: "Reject refreshes that remove more than this many records"},
+	{Name: "refresh.guard_max_reclassified", Env: "SB29_REFRESH_GUARD_MAX_RECLASSIFIED", Kind: Int, Default: "0", Flag: "guard-max-reclassified", Commands: []string{"serve", "dns-serve"}, Help: "Reject refreshes that reclassify more than this many domains"},
+	{Name: "refresh.guard_require_tags", Env: "SB29_REFRESH_GUARD_REQUIRE_TAGS", Default: "", Flag: "guard-require-tags", Commands: []string{"serve", "dns-serve"}, Help: "Comma-separated tags a refreshed policy must carry"},
+
+	{Name: "serve.listen", Env: "SB29_SERVE_LISTEN", Kind: HostPort, Default: ":8080", Flag: "listen", Commands: []string{"serve"}, Help: "HTTP listen address"},
+	{Name: "serve.templates", Env: "SB29_SERVE_TEMPLATES", Flag: "templates", Commands: []string{"serve"}, Help: "Templates directory overriding the embedded templates"},
+	{Name: "serve.locales", Env: "SB29_SERVE_LOCALES", Flag: "locales", Commands: []string{"serve", "generate-explain-static"}, Help: "Directory of <lang>.json message catalogs (default: <templates>/locales when present)"},
+	{Name: "serve.law_url", Env: "SB29_LAW_URL", Kind: URL, Flag: "law-url", Commands: []string{"serve", "generate-explain-static"}, Help: "Target of the /law link (empty = Ohio SB29 text)"},
+	{Name: "serve.allow_host_fallback", Env: "SB29_ALLOW_HOST_FALLBACK", Kind: Bool, Default: "false", Flag: "allow-host-fallback", Commands: []string{"serve"}, Help: "Use the Host header as a last resort on /explain"},
+	{Name: "serve.read_header_timeout", Env: "SB29_SERVE_READ_HEADER_TIMEOUT", Kind: Duration, Default: "5s", Flag: "read-header-timeout", Commands: []string{"serve"}, Help: "Max time to read request headers (0 = no limit)"},
+	{Name: "serve.read_timeout", Env: "SB29_SERVE_READ_TIMEOUT", Kind: Duration, Default: "10s", Flag: "read-timeout", Commands: []string{"serve"}, Help: "Max time to read a whole request (0 = no limit)"},
+	{Name: "serve.write_timeout", Env: "SB29_SERVE_WRITE_TIMEOUT", Kind: Duration, Default: "15s", Flag: "write-timeout", Commands: []string{"serve"}, Help: "Max time to write a response (0 = no limit)"},
+	{Name: "serve.idle_timeout", Env: "SB29_SERVE_IDLE_TIMEOUT", Kind: Duration, Default: "1m0s", Flag: "idle-timeout", Commands: []string{"serve"}, Help: "Max keep-alive idle time (0 = no limit)"},
+	{Name: "serve.max_header_bytes", Env: "SB29_SERVE_MAX_HEADER_BYTES", Kind: Int, Default: "65536", Flag: "max-header-bytes", Commands: []string{"serve"}, Help: "Max request header size in bytes"},
+	{Name: "serve.shutdown_timeout", Env: "SB29_SERVE_SHUTDOWN_TIMEOUT", Kind: Duration, Default: "20s", Flag: "shutdown-timeout", Commands: []string{"serve", "dns-serve"}, Help: "Grace period for in-flight requests and queued notifications on SIGTERM/SIGINT"},
+	{Name: "serve.tls_listen", Env: "SB29_SERVE_TLS_LISTEN", Kind: HostPort, Default: ":8443", Flag: "tls-listen", Commands: []string{"serve"}, Help: "HTTPS listen address when TLS is enabled"},
+	{Name: "serve.tls_cert", Env: "SB29_SERVE_TLS_CERT", Flag: "tls-cert", Commands: []string{"serve"}, Help: "TLS certificate (PEM), reloaded on change"},
+	{Name: "serve.tls_key", Env: "SB29_SERVE_TLS_KEY", Flag: "tls-key", Commands: []string{"serve"}, Help: "TLS private key (PEM)"},
+	{Name: "serve.tls_self_signed", Env: "SB29_SERVE_TLS_SELF_SIGNED", Kind: Bool, Default: "false", Flag: "tls-self-signed", Commands: []string{"serve"}, Help: "Generate a self-signed certificate for serve.tls_host on first boot"},
+	{Name: "serve.tls_host", Env: "SB29_SERVE_TLS_HOST", Flag: "tls-host", Commands: []string{"serve"}, Help: "Guard host name (default: system host name)"},
+	{Name: "serve.tls_dir", Env: "SB29_SERVE_TLS_DIR", Flag: "tls-dir", Commands: []string{"serve"}, Help: "Self-signed certificate directory (default <cache_dir>/tls)"},
+	{Name: "serve.tls_ca_cert", Env: "SB29_SERVE_TLS_CA_CERT", Flag: "tls-ca-cert", Commands: []string{"serve"}, Help: "District CA certificate for on-the-fly certificates"},
+	{Name: "serve.tls_ca_key", Env: "SB29_SERVE_TLS_CA_KEY", Flag: "tls-ca-key", Commands: []string{"serve"}, Help: "District CA private key"},
+	{Name: "serve.hits", Env: "SB29_SERVE_HITS", Kind: Bool, Default: "true", Flag: "hits", Commands: []string{"serve"}, Help: "Count explain page views per day by domain and classification"},
+	{Name: "serve.hits_retention_days", Env: "SB29_SERVE_HITS_RETENTION_DAYS", Kind: Int, Default: "30", Flag: "hits-retention-days", Commands: []string{"serve"}, Help: "Days of hit counts kept on disk (0 = keep)"},
+	{Name: "serve.hits_flush_interval", Env: "SB29_SERVE_HITS_FLUSH_INTERVAL", Kind: Duration, Default: "1m0s", Flag: "hits-flush-interval", Commands: []string{"serve"}, Help: "How often hit counts are written to disk"},
+	{Name: "serve.request_access", Env: "SB29_SERVE_REQUEST_ACCESS", Kind: Bool, Default: "false", Flag: "request-access", Commands: []string{"serve"}, Help: "Show a review request form on the explain page"},
+	{Name: "serve.request_rate_client", Env: "SB29_SERVE_REQUEST_RATE_CLIENT", Kind: Int, Default: "5", Flag: "request-rate-client", Commands: []string{"serve"}, Help: "Review requests accepted per client address per hour (0 = no limit)"},
+	{Name: "serve.request_rate_total", Env: "SB29_SERVE_REQUEST_RATE_TOTAL", Kind: Int, Default: "60", Flag: "request-rate-total", Commands: []string{"serve"}, Help: "Review requests accepted per hour from all clients (0 = no limit)"},
+	{Name: "serve.auth_tokens", Env: "SB29_SERVE_AUTH_TOKENS", Flag: "auth-tokens", Commands: []string{"serve"}, Help: "Bearer token file (name:sha256-hex per line)"},
+	{Name: "serve.auth_users", Env: "SB29_SERVE_AUTH_USERS", Flag: "auth-users", Commands: []string{"serve"}, Help: "Basic auth users file (user:bcrypt-hash per line)"},
+	{Name: "serve.auth_client_ca", Env: "SB29_SERVE_AUTH_CLIENT_CA", Flag: "auth-client-ca", Commands: []string{"serve"}, Help: "CA certificates for client-certificate auth"},
+	{Name: "serve.auth_public", Env: "SB29_SERVE_AUTH_PUBLIC", Default: "none", Flag: "auth-public", Commands: []string{"serve"}, Help: "Auth methods for /, /explain, /law, /health, /classify"},
+	{Name: "serve.auth_ops", Env: "SB29_SERVE_AUTH_OPS", Default: "auto", Flag: "auth-ops", Commands: []string{"serve"}, Help: "Auth methods for /metrics, /metrics/history, /domain-list"},
+	{Name: "serve.auth_admin", Env: "SB29_SERVE_AUTH_ADMIN", Default: "auto", Flag: "auth-admin", Commands: []string{"serve"}, Help: "Auth methods for /admin/*"},
+	{Name: "serve.tls_reload_interval", Env: "SB29_SERVE_TLS_RELOAD_INTERVAL", Kind: Duration, Default: "30s", Flag: "tls-reload-interval", Commands: []string{"serve"}, Help: "Certificate file change check interval"},
+
+	{Name: "notify.webhook", Env: "SB29_NOTIFY_WEBHOOK", Flag: "notify-webhook", Commands: []string{"serve", "dns-serve"}, Help: "Comma-separated webhook URLs for event notifications"},
+	{Name: "notify.webhook_secret_file", Env: "SB29_NOTIFY_WEBHOOK_SECRET_FILE", Flag: "notify-webhook-secret-file", Commands: []string{"serve", "dns-serve"}, Help: "File holding the webhook HMAC secret"},
+	{Name: "notify.smtp", Env: "SB29_NOTIFY_SMTP", Kind: HostPort, Flag: "notify-smtp", Commands: []string{"serve", "dns-serve"}, Help: "SMTP server host:port for event mail"},
+	{Name: "notify.smtp_from", Env: "SB29_NOTIFY_SMTP_FROM", Flag: "notify-smtp-from", Commands: []string{"serve", "dns-serve"}, Help: "Sender address for event mail"},
+	{Name: "notify.smtp_to", Env: "SB29_NOTIFY_SMTP_TO", Flag: "notify-smtp-to", Commands: []string{"serve", "dns-serve"}, Help: "Comma-separated recipients for event mail"},
+	{Name: "notify.smtp_user", Env: "SB29_NOTIFY_SMTP_USER", Flag: "notify-smtp-user", Commands: []string{"serve", "dns-serve"}, Help: "SMTP user (password from SB29_NOTIFY_SMTP_PASS)"},
+	{Name: "notify.refresh_failures", Env: "SB29_NOTIFY_REFRESH_FAILURES", Kind: Int, Default: "3", Flag: "notify-refresh-failures", Commands: []string{"serve", "dns-serve"}, Help: "Consecutive refresh failures before notifying (0 = off)"},
+	{Name: "notify.expiring", Env: "SB29_NOTIFY_EXPIRING", Default: "14d", Flag: "notify-expiring", Commands: []string{"serve", "dns-serve"}, Help: "Notify when a record expires within this window (0 = off)"},
+	{Name: "notify.retries", Env: "SB29_NOTIFY_RETRIES", Kind: Int, Default: "3", Flag: "notify-retries", Commands: []string{"serve", "dns-serve"}, Help: "Delivery retries per event and receiver"},
+
+	{Name: "dns.preset", Env: "SB29_DNS_PRESET", Flag: "preset", Commands: []string{"generate-dns"}, Help: "Name of a dns.presets entry to apply"},
+	{Name: "dns.format", Env: "SB29_DNS_FORMAT", Kind: Enum, Choices: []string{"hosts", "bind", "unbound", "rpz", "dnsmasq", "domain-list", "winps"}, Default: "hosts", Flag: "format", Commands: []string{"generate-dns"}, Preset: true, Help: "generate-dns output format"},
+	{Name: "dns.out", Env: "SB29_DNS_OUT", Flag: "out", Commands: []string{"generate-dns"}, Preset: true, Help: "generate-dns output file"},
+	{Name: "dns.mode", Env: "SB29_DNS_MODE", Kind: Enum, Choices: []string{"a-record", "cname"}, Default: "a-record", Flag: "mode", Commands: []string{"generate-dns", "dns-serve"}, Preset: true, Help: "Redirect mode"},
+	{Name: "dns.redirect_ipv4", Env: "SB29_DNS_REDIRECT_IPV4", Kind: IPv4, Flag: "redirect-ipv4", Commands: []string{"generate-dns", "dns-serve"}, Preset: true, Help: "Redirect IPv4 address"},
+	{Name: "dns.redirect_ipv6", Env: "SB29_DNS_REDIRECT_IPV6", Kind: IPv6, Flag: "redirect-ipv6", Commands: []string{"generate-dns", "dns-serve"}, Preset: true, Help: "Redirect IPv6 address"},
+	{Name: "dns.redirect_host", Env: "SB29_DNS_REDIRECT_HOST", Default: "blocked.guard.local", Flag: "redirect-host", Commands: []string{"generate-dns", "dns-serve"}, Preset: true, Help: "Redirect host (cname mode)"},
+	{Name: "dns.ttl", Env: "SB29_DNS_TTL", Kind: Int, Default: "300", Flag: "ttl", Commands: []string{"generate-dns", "dns-serve"}, Preset: true, Help: "Record TTL seconds"},
+	{Name: "dns.serial_strategy", Env: "SB29_DNS_SERIAL_STRATEGY", Kind: Enum, Choices: []string{"date", "epoch", "hash"}, Default: "date", Flag: "serial-strategy", Commands: []string{"generate-dns"}, Preset: true, Help: "Serial strategy for bind/rpz"},
+	{Name: "dns.hosts_expand", Env: "SB29_DNS_HOSTS_EXPAND", Flag: "hosts-expand", Commands: []string{"generate-dns"}, Preset: true, Help: "Known subdomains file for wildcard records in hosts format"},
+	{Name: "dns.scope", Env: "SB29_DNS_SCOPE", Flag: "scope", Commands: []string{"generate-dns", "dns-serve"}, Preset: true, Help: "Audience scope"},
+	{Name: "dns.listen", Env: "SB29_DNS_LISTEN", Kind: HostPort, Default: ":53", Flag: "listen", Commands: []string{"dns-serve"}, Help: "dns-serve listen address (UDP and TCP)"},
+	{Name: "dns.upstream", Env: "SB29_DNS_UPSTREAM", Flag: "upstream", Commands: []string{"dns-serve"}, Help: "dns-serve upstream resolver host[:port]"},
+
+	{Name: "proxy.format", Env: "SB29_PROXY_FORMAT", Kind: Enum, Choices: []string{"caddy", "nginx", "haproxy", "apache"}, Default: "caddy", Flag: "format", Commands: []string{"generate-proxy"}, Help: "Proxy flavour"},
+	{Name: "proxy.mode", Env: "SB29_PROXY_MODE", Kind: Enum, Choices: []string{"header-injection", "redirect"}, Default: "header-injection", Flag: "mode", Commands: []string{"generate-proxy"}, Help: "School Mode integration"},
+	{Name: "proxy.site_host", Env: "SB29_PROXY_SITE_HOST", Default: "blocked.example", Flag: "site-host", Commands: []string{"generate-proxy"}, Help: "Virtual host handling blocked flows"},
+	{Name: "proxy.backend_url", Env: "SB29_PROXY_BACKEND_URL", Kind: URL, Default: "http://127.0.0.1:8080", Flag: "backend-url", Commands: []string{"generate-proxy"}, Help: "Backend SB29 Guard URL (header-injection)"},
+	{Name: "proxy.explain_url", Env: "SB29_PROXY_EXPLAIN_URL", Kind: URL, Default: "https://explain.example/explain", Flag: "explain-url", Commands: []string{"generate-proxy"}, Help: "Public explain page URL (redirect)"},
+	{Name: "proxy.bundle_dir", Env: "SB29_PROXY_BUNDLE_DIR", Flag: "bundle-dir", Commands: []string{"generate-proxy"}, Help: "Write a ready-to-use bundle into this directory"},
+	{Name: "proxy.tls_cert", Env: "SB29_PROXY_TLS_CERT", Flag: "tls-cert", Commands: []string{"generate-proxy"}, Help: "TLS certificate path (nginx bundle)"},
+	{Name: "proxy.tls_key", Env: "SB29_PROXY_TLS_KEY", Flag: "tls-key", Commands: []string{"generate-proxy"}, Help: "TLS key path (nginx bundle)"},
+	{Name: "proxy.redirect_unknown", Env: "SB29_PROXY_REDIRECT_UNKNOWN", Kind: Bool, Default: "false", Flag: "redirect-unknown", Commands: []string{"generate-proxy"}, Help: "nginx bundle: redirect guard 404s to the static explain page"},
+	{Name: "proxy.scope", Env: "SB29_PROXY_SCOPE", Flag: "scope", Commands: []string{"generate-proxy"}, Help: "Audience scope for the host map"},
+}
+
+// EnvConfig names the environment variable consulted when --config is not given.
+const EnvConfig = "SB29_CONFIG"
+
+const presetPrefix = "dns.presets."
+
+var (
+	presetName  = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+	clockFormat = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+)
+
+// Lookup returns the key with the given dotted name.
+func Lookup(name string) (Key, bool) {
+	for _, k := range Keys {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+// ForFlag returns the key that fills flag on the named subcommand.
+func ForFlag(command, flag string) (Key, bool) {
+	for _, k := range Keys {
+		if k.Flag != flag {
+			continue
+		}
+		for _, c := range k.Commands {
+			if c == command {
+				return k, true
+			}
+		}
+	}
+	return Key{}, false
+}
+
+// File is a parsed config file: the settings it assigns, by dotted name.
+type File struct {
+	Path    string
+	values  map[string]string
+	presets map[string]bool
+}
+
+// Load reads and parses the config file at path. Unknown keys and non-scalar values are
+// errors; values are checked by Validate. Read errors are returned unwrapped.
+func Load(path string) (*File, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	f.Path = path
+	return f, nil
+}
+
+// Parse parses config YAML.
+func Parse(b []byte) (*File, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	f := &File{values: map[string]string{}, presets: map[string]bool{}}
+	if len(doc.Content) == 0 {
+		return f, nil
+	}
+	if err := f.walk(doc.Content[0], ""); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) walk(n *yaml.Node, prefix string) error {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			name := n.Content[i].Value
+			full := name
+			if prefix != "" {
+				full = prefix + "." + name
+			}
+			if prefix+"." == presetPrefix {
+				if !presetName.MatchString(name) {
+					return fmt.Errorf("line %d: invalid preset name %q", n.Content[i].Line, name)
+				}
+				f.presets[name] = true
+			}
+			if err := f.walk(n.Content[i+1], full); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.ScalarNode:
+		if n.Tag == "!!null" || n.Value == "" {
+			return nil // unset, like an empty environment variable
+		}
+		if !knownKey(prefix) {
+			return fmt.Errorf("line %d: unknown key %s", n.Line, prefix)
+		}
+		f.values[prefix] = n.Value
+		return nil
+	default:
+		return fmt.Errorf("line %d: %s must be a single value", n.Line, prefix)
+	}
+}
+
+// knownKey reports whether name is a Key or a preset field.
+func knownKey(name string) bool {
+	if _, ok := Lookup(name); ok {
+		return true
+	}
+	if !strings.HasPrefix(name, presetPrefix) {
+		return false
+	}
+	rest := strings.TrimPrefix(name, presetPrefix)
+	dot := strings.IndexByte(rest, '.')
+	if dot < 0 {
+		return false
+	}
+	k, ok := Lookup("dns." + rest[dot+1:])
+	return ok && k.Preset
+}
+
+// Presets returns the names of the DNS presets defined in the file, sorted.
+func (f *File) Presets() []string {
+	if f == nil {
+		return nil
+	}
+	var out []string
+	for p := range f.presets {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// HasPreset reports whether the file defines the named DNS preset.
+func (f *File) HasPreset(name string) bool { return f != nil && f.presets[name] }
+
+// Get returns the file's value for key, preferring the named DNS preset for preset keys.
+// f may be nil.
+func (f *File) Get(k Key, preset string) (string, bool) {
+	if f == nil {
+		return "", false
+	}
+	if k.Preset && preset != "" {
+		if v, ok := f.values[presetPrefix+preset+"."+strings.TrimPrefix(k.Name, "dns.")]; ok {
+			return v, true
+		}
+	}
+	v, ok := f.values[k.Name]
+	return v, ok
+}
+
+// Validate checks every value in the file against its key's kind, and that dns.preset
+// names a defined preset.
+func (f *File) Validate() error {
+	var names []string
+	for name := range f.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names {
+		k, ok := Lookup(name)
+		if !ok {
+			rest := strings.TrimPrefix(name, presetPrefix)
+			k, _ = Lookup("dns." + rest[strings.IndexByte(rest, '.')+1:])
+		}
+		if err := Check(k, f.values[name]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	if p, ok := f.values["dns.preset"]; ok && p != "" && !f.presets[p] {
+		errs = append(errs, fmt.Errorf("dns.preset: unknown preset %s", p))
+	}
+	return errors.Join(errs...)
+}
+
+// Check validates v as a value for k. The empty string is accepted for every kind
+// except Bool, Int, Float and Duration, and means "unset".
+func Check(k Key, v string) error {
+	switch k.Kind {
+	case Bool:
+		if _, err := strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("invalid boolean %q", v)
+		}
+		return nil
+	case Int:
+		if _, err := strconv.Atoi(v); err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+		return nil
+	case Float:
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return fmt.Errorf("invalid number %q", v)
+		}
+		return nil
+	case Duration:
+		if _, err := time.ParseDuration(v); err != nil {
+			return fmt.Errorf("invalid duration %q (e.g. 30m, 2h)", v)
+		}
+		return nil
+	}
+	if v == "" {
+		return nil
+	}
+	switch k.Kind {
+	case Clock:
+		if !clockFormat.MatchString(v) {
+			return fmt.Errorf("invalid time %q (use HH:MM)", v)
+		}
+	case HostPort:
+		if _, port, err := net.SplitHostPort(v); err != nil || port == "" {
+			return fmt.Errorf("invalid listen address %q (use host:port or :port)", v)
+		}
+	case URL:
+		u, err := url.Parse(v)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid URL %q", v)
+		}
+	case IPv4:
+		if ip := net.ParseIP(v); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("invalid IPv4 address %q", v)
+		}
+	case IPv6:
+		if ip := net.ParseIP(v); ip == nil || ip.To4() != nil {
+			return fmt.Errorf("invalid IPv6 address %q", v)
+		}
+	case Enum:
+		for _, c := range k.Choices {
+			if v == c {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid value %q (%s)", v, strings.Join(k.Choices, "|"))
+	case Class:
+		if !policy.ValidClassificationKey(v) {
+			return fmt.Errorf("invalid classification %q", v)
+		}
+	}
+	return nil
+}
+
+// Sources of a resolved value.
+const (
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+	SourceFile    = "file"
+	SourceDefault = "default"
+)
+
+// Resolve returns the value for k from the environment, then the file (preset first),
+// reporting which one supplied it; ok is false when neither does.
+func Resolve(k Key, f *File, preset string, getenv func(string) string) (value, source string, ok bool) {
+	if v := getenv(k.Env); v != "" {
+		return v, SourceEnv, true
+	}
+	if v, ok := f.Get(k, preset); ok {
+		return v, SourceFile, true
+	}
+	return "", "", false
+}
+
+// Setting is one key with its effective value.
+type Setting struct {
+	Key    Key
+	Value  string
+	Source string
+}
+
+// Effective resolves every key (environment > file > default) for config print. A non-empty
+// preset acts like the --preset flag; otherwise dns.preset comes from the environment or file.
+func Effective(f *File, preset string, getenv func(string) string) ([]Setting, error) {
+	presetSrc := SourceFlag
+	if preset == "" {
+		pk, _ := Lookup("dns.preset")
+		preset, presetSrc, _ = Resolve(pk, f, "", getenv)
+	}
+	if preset != "" && !f.HasPreset(preset) {
+		return nil, fmt.Errorf("unknown dns preset %s", preset)
+	}
+	out := make([]Setting, 0, len(Keys))
+	for _, k := range Keys {
+		v, src, ok := Resolve(k, f, preset, getenv)
+		if k.Name == "dns.preset" {
+			v, src, ok = preset, presetSrc, preset != ""
+		}
+		if !ok {
+			v, src = k.Default, SourceDefault
+		}
+		out = append(out, Setting{Key: k, Value: v, Source: src})
+	}
+	return out, nil
+}
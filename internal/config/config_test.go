@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const sample = `policy:
+  file: policy/district.yaml
+serve:
+  listen: "127.0.0.1:9090"
+  law_url: ""
+dns:
+  format: rpz
+  redirect_ipv4: 10.10.10.50
+  presets:
+    pihole:
+      format: dnsmasq
+      out: dist/pihole.conf
+`
+
+func TestParseAndResolvePrecedence(t *testing.T) {
+	f, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := f.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if got := f.Presets(); len(got) != 1 || got[0] != "pihole" {
+		t.Fatalf("presets = %v", got)
+	}
+	env := map[string]string{"SB29_DNS_REDIRECT_IPV4": "10.0.0.9"}
+	getenv := func(k string) string { return env[k] }
+	format, _ := Lookup("dns.format")
+	if v, src, _ := Resolve(format, f, "", getenv); v != "rpz" || src != SourceFile {
+		t.Fatalf("dns.format = %s (%s)", v, src)
+	}
+	if v, _, _ := Resolve(format, f, "pihole", getenv); v != "dnsmasq" {
+		t.Fatalf("preset should win over dns.format, got %s", v)
+	}
+	ip, _ := Lookup("dns.redirect_ipv4")
+	if v, src, _ := Resolve(ip, f, "pihole", getenv); v != "10.0.0.9" || src != SourceEnv {
+		t.Fatalf("env should win over file, got %s (%s)", v, src)
+	}
+	law, _ := Lookup("serve.law_url")
+	if _, _, ok := Resolve(law, f, "", getenv); ok {
+		t.Fatalf("empty file value should count as unset")
+	}
+	if k, ok := ForFlag("dns-serve", "listen"); !ok || k.Name != "dns.listen" {
+		t.Fatalf("dns-serve --listen bound to %+v", k)
+	}
+	if k, ok := ForFlag("generate-proxy", "format"); !ok || k.Name != "proxy.format" {
+		t.Fatalf("generate-proxy --format bound to %+v", k)
+	}
+}
+
+func TestParseRejectsUnknownAndInvalid(t *testing.T) {
+	for _, doc := range []string{
+		"serve:\n  listne: \":80\"\n",
+		"dns:\n  presets:\n    pihole:\n      listen: \":53\"\n", // dns.listen is not a preset field
+		"policy:\n  file: [a, b]\n",
+		"dns:\n  presets:\n    Bad Name:\n      format: rpz\n",
+	} {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Fatalf("expected parse error for %q", doc)
+		}
+	}
+	for _, doc := range []string{
+		"serve:\n  listen: localhost\n",
+		"refresh:\n  at: \"25:00\"\n",
+		"refresh:\n  every: soon\n",
+		"dns:\n  format: zonefile\n",
+		"dns:\n  redirect_ipv6: 10.0.0.1\n",
+		"dns:\n  preset: missing\n",
+		"policy:\n  expired_classification: grace\n",
+	} {
+		f, err := Parse([]byte(doc))
+		if err != nil {
+			t.Fatalf("parse %q: %v", doc, err)
+		}
+		if err := f.Validate(); err == nil {
+			t.Fatalf("expected validation error for %q", doc)
+		}
+	}
+}
+
+func TestLoadAndEffective(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); !os.IsNotExist(err) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte(sample+"  preset: pihole\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	settings, err := Effective(f, "", func(string) string { return "" })
+	if err != nil || settings[len(Keys)-1].Key.Name != Keys[len(Keys)-1].Name {
+		t.Fatalf("effective: %v", err)
+	}
+	for _, s := range settings {
+		if s.Key.Name == "dns.format" && s.Value != "dnsmasq" {
+			t.Fatalf("dns.preset from the file should apply, got format %s", s.Value)
+		}
+	}
+	if err := os.WriteFile(path, []byte(sample), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err = Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	got := map[string]Setting{}
+	settings, err = Effective(f, "pihole", func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("effective: %v", err)
+	}
+	for _, s := range settings {
+		got[s.Key.Name] = s
+	}
+	if len(settings) != len(Keys) {
+		t.Fatalf("expected every key, got %d", len(settings))
+	}
+	if s := got["dns.out"]; s.Value != "dist/pihole.conf" || s.Source != SourceFile {
+		t.Fatalf("dns.out = %+v", s)
+	}
+	if s := got["dns.ttl"]; s.Value != "300" || s.Source != SourceDefault {
+		t.Fatalf("dns.ttl = %+v", s)
+	}
+	if _, err := Effective(f, "unbound", func(string) string { return "" }); err == nil {
+		t.Fatalf("expected unknown preset error")
+	}
+}
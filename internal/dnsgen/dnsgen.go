@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"net"
 	"sort"
 	"strconv"
 	"strings"
@@ -19,9 +20,19 @@ type Options struct {
 	Format         string
 	Mode           string // a-record|cname
 	RedirectIPv4   string
+	RedirectIPv6   string // optional; emits AAAA (or IPv6 equivalents) alongside or instead of A
 	RedirectHost   string
 	TTL            int
 	SerialStrategy string // date|epoch|hash
+	// HostsExpand lists known subdomains (FQDNs) to write explicitly in hosts output for
+	// wildcard records, since hosts files cannot express wildcards. Entries not covered by an
+	// active wildcard record are ignored.
+	HostsExpand []string
+	// Now is the instant used to evaluate record expiry (zero = time.Now()).
+	Now time.Time
+	// Scope restricts output to the records for one audience (e.g. "students"); records
+	// without scopes apply to every audience. Empty means all records.
+	Scope string
 }
 
 // Generate produces DNS content for the given policy according to Options.
@@ -35,10 +46,24 @@ func Generate(p *policy.Policy, o Options) ([]byte, error) {
 	if o.TTL <= 0 {
 		o.TTL = 300
 	}
-	records := activeDomains(p)
+	if o.RedirectIPv6 != "" {
+		if ip := net.ParseIP(o.RedirectIPv6); ip == nil || ip.To4() != nil {
+			return nil, fmt.Errorf("invalid redirect-ipv6: %s", o.RedirectIPv6)
+		}
+	}
+	if o.Now.IsZero() {
+		o.Now = time.Now()
+	}
+	if o.Scope != "" {
+		if !p.HasScope(o.Scope) {
+			return nil, fmt.Errorf("unknown scope: %s", o.Scope)
+		}
+		p = p.ForScope(o.Scope)
+	}
+	records := activeDomains(p, o.Now)
 	switch o.Format {
 	case "hosts":
-		return genHosts(records, o)
+		return genHosts(records, p, o)
 	case "bind":
 		return genBindZone(records, p, o)
 	case "unbound":
@@ -48,7 +73,7 @@ func Generate(p *policy.Policy, o Options) ([]byte, error) {
 	case "dnsmasq":
 		return genDnsmasq(records, o)
 	case "domain-list":
-		return genDomainList(records)
+		return genDomainList(records, p, o)
 	case "winps":
 		return genWinPS(records, p, o)
 	default:
@@ -56,28 +81,52 @@ func Generate(p *policy.Policy, o Options) ([]byte, error) {
 	}
 }
 
-func activeDomains(p *policy.Policy) []policy.Record {
-	var out []policy.Record
-	for _, r := range p.Records {
-		if r.Status == "suspended" {
-			continue
-		}
-		out = append(out, r)
+// activeDomains returns the records enforced at now (suspended and expired ones dropped), sorted by domain.
+// Exemptions (action: allow) are included; each generator either emits its native passthrough
+// form for them or, when the format cannot express one, skips exempt names and warns.
+func activeDomains(p *policy.Policy, now time.Time) []policy.Record {
+	if now.IsZero() {
+		now = time.Now()
 	}
+	out := p.ActiveRecords(now)
 	sort.Slice(out, func(i, j int) bool { return out[i].Domain < out[j].Domain })
 	return out
 }
 
-func genHosts(recs []policy.Record, o Options) ([]byte, error) {
-	if o.RedirectIPv4 == "" {
-		return nil, errors.New("redirect-ipv4 required for hosts format")
+// genHosts outputs hosts-file lines. Hosts files have no wildcard syntax, so a "*.example.com"
+// record only covers example.com itself plus any subdomains supplied via Options.HostsExpand;
+// a warning comment is written for every wildcard record so the gap is visible. Names that an
+// exemption carves out are simply not written.
+func genHosts(recs []policy.Record, p *policy.Policy, o Options) ([]byte, error) {
+	addrs := redirectAddrs(o)
+	if len(addrs) == 0 {
+		return nil, errors.New("redirect-ipv4 or redirect-ipv6 required for hosts format")
 	}
 	var b strings.Builder
 	fmt.Fprintf(&b, "# sb29guard format=hosts mode=%s\n", o.Mode)
 	for _, r := range recs {
-		// hosts file ignores wildcard marker; strip '*.'
-		domain := strings.TrimPrefix(r.Domain, "*.")
-		fmt.Fprintf(&b, "%s %s\n", o.RedirectIPv4, domain)
+		if !isWildcard(r.Domain) || r.Exempt() {
+			continue
+		}
+		base := strings.TrimPrefix(r.Domain, "*.")
+		fmt.Fprintf(&b, "# WARNING: hosts format cannot express wildcards; wildcard record for %s covers only the base name and %d listed subdomain(s)\n", base, len(expandWildcard(base, o.HostsExpand)))
+	}
+	for _, r := range recs {
+		if r.Exempt() {
+			continue
+		}
+		names := []string{strings.TrimPrefix(r.Domain, "*.")}
+		if isWildcard(r.Domain) {
+			names = append(names, expandWildcard(names[0], o.HostsExpand)...)
+		}
+		for _, name := range names {
+			if !blocked(p, name, o) {
+				continue
+			}
+			for _, a := range addrs {
+				fmt.Fprintf(&b, "%s %s\n", a.ip, name)
+			}
+		}
 	}
 	return []byte(b.String()), nil
 }
@@ -89,33 +138,78 @@ func genBindZone(recs []policy.Record, p *policy.Policy, o Options) ([]byte, err
 	serial := computeSerial(p, o)
 	fmt.Fprintf(&b, "@ IN SOA %s. hostmaster.%s. (%s 3600 900 604800 %d)\n", o.RedirectHost, o.RedirectHost, serial, o.TTL)
 	fmt.Fprintf(&b, "@ IN NS %s.\n", o.RedirectHost)
+	warnUnexpressedExemptions(&b, ";", "bind", recs, p, o)
+	written := map[string]bool{} // owner names already emitted (an exact record and a wildcard share the base)
 	for _, r := range recs {
-		name := strings.TrimPrefix(r.Domain, "*.")
-		if o.Mode == "cname" {
-			fmt.Fprintf(&b, "%s %d IN CNAME %s.\n", name, o.TTL, o.RedirectHost)
-		} else {
-			if o.RedirectIPv4 == "" {
-				return nil, errors.New("redirect-ipv4 required for a-record mode")
+		if r.Exempt() {
+			continue
+		}
+		// A wildcard record covers the base name and every subdomain, so emit both owners.
+		owners := []string{strings.TrimPrefix(r.Domain, "*.")}
+		if isWildcard(r.Domain) {
+			owners = append(owners, r.Domain)
+		}
+		for _, name := range owners {
+			if written[name] || (!isWildcard(name) && !blocked(p, name, o)) {
+				continue
+			}
+			written[name] = true
+			if o.Mode == "cname" {
+				fmt.Fprintf(&b, "%s %d IN CNAME %s.\n", name, o.TTL, o.RedirectHost)
+				continue
+			}
+			addrs := redirectAddrs(o)
+			if len(addrs) == 0 {
+				return nil, errors.New("redirect-ipv4 or redirect-ipv6 required for a-record mode")
+			}
+			for _, a := range addrs {
+				fmt.Fprintf(&b, "%s %d IN %s %s\n", name, o.TTL, a.rrType, a.ip)
 			}
-			fmt.Fprintf(&b, "%s %d IN A %s\n", name, o.TTL, o.RedirectIPv4)
 		}
 	}
 	return []byte(b.String()), nil
 }
 
+// genUnbound outputs unbound.conf server-clause lines. Wildcard records use a "redirect"
+// local-zone so the apex data answers for every subdomain; exact records use "transparent"
+// so only the listed name is overridden and its subdomains resolve normally. Exemptions get a
+// "transparent" local-zone without local-data: being more specific than the blocking zone,
+// it lets the name (and, unlike Lookup for an exact exemption, its subdomains) resolve normally.
 func genUnbound(recs []policy.Record, p *policy.Policy, o Options) ([]byte, error) {
 	var b strings.Builder
 	fmt.Fprintf(&b, "# sb29guard format=unbound mode=%s policy_version=%s\n", o.Mode, p.Version)
+	addrs := redirectAddrs(o)
+	if o.Mode != "cname" && len(addrs) == 0 {
+		return nil, errors.New("redirect-ipv4 or redirect-ipv6 required for a-record mode")
+	}
+	exemptZone := map[string]bool{} // zone name -> whether an exemption already claimed it
 	for _, r := range recs {
 		name := strings.TrimPrefix(r.Domain, "*.")
+		if prev, seen := exemptZone[name]; seen {
+			// Only one local-zone per name. Two blocking rules share it (records sort "*.x" before
+			// "x", so a wildcard's redirect zone already answers for its base name), but an exemption
+			// for exactly the base of a wildcard block cannot be expressed separately from it.
+			if prev || r.Exempt() {
+				fmt.Fprintf(&b, "# WARNING: unbound format cannot separate %s from the other rule for %s\n", r.Domain, name)
+			}
+			continue
+		}
+		exemptZone[name] = r.Exempt()
+		if r.Exempt() {
+			fmt.Fprintf(&b, "local-zone: \"%s\" transparent\n", name)
+			continue
+		}
+		zoneType := "transparent"
+		if isWildcard(r.Domain) {
+			zoneType = "redirect"
+		}
+		fmt.Fprintf(&b, "local-zone: \"%s\" %s\n", name, zoneType)
 		if o.Mode == "cname" {
 			fmt.Fprintf(&b, "local-data: \"%s CNAME %s\"\n", name, o.RedirectHost)
-		} else {
-			if o.RedirectIPv4 == "" {
-				return nil, errors.New("redirect-ipv4 required for a-record mode")
-			}
-			fmt.Fprintf(&b, "local-zone: \"%s\" redirect\n", name)
-			fmt.Fprintf(&b, "local-data: \"%s A %s\"\n", name, o.RedirectIPv4)
+			continue
+		}
+		for _, a := range addrs {
+			fmt.Fprintf(&b, "local-data: \"%s %s %s\"\n", name, a.rrType, a.ip)
 		}
 	}
 	return []byte(b.String()), nil
@@ -133,29 +227,52 @@ func genRPZ(recs []policy.Record, p *policy.Policy, o Options) ([]byte, error) {
 	fmt.Fprintf(&b, "@ IN NS %s.\n", o.RedirectHost)
 	for _, r := range recs {
 		name := r.Domain
-		// keep wildcard as-is for RPZ (policy trigger)
-		fmt.Fprintf(&b, "%s. CNAME %s.\n", name, o.RedirectHost)
+		// keep wildcard as-is for RPZ (policy trigger); RPZ prefers exact over wildcard
+		// triggers and longer wildcards over shorter ones, matching Lookup precedence.
+		target := o.RedirectHost + "."
+		if r.Exempt() {
+			target = "rpz-passthru."
+		}
+		fmt.Fprintf(&b, "%s. CNAME %s\n", name, target)
 	}
-	if o.RedirectIPv4 != "" {
-		fmt.Fprintf(&b, "%s. A %s\n", o.RedirectHost, o.RedirectIPv4)
+	for _, a := range redirectAddrs(o) {
+		fmt.Fprintf(&b, "%s. %s %s\n", o.RedirectHost, a.rrType, a.ip)
 	}
 	return []byte(b.String()), nil
 }
 
 // genDnsmasq outputs dnsmasq config lines.
-// a-record mode: address=/example.com/10.10.10.50
+// a-record mode: address=/example.com/10.10.10.50 (plus address=/example.com/<ipv6> when set)
 // cname mode: cname=example.com,blocked.guard.local
+//
+// dnsmasq's address=/name/ matches name and all of its subdomains, so wildcard records are
+// expressed natively by their base name. cname= lines are exact-name only; wildcard records in
+// cname mode therefore also get an address= line per known redirect address when one is set.
+// Exemptions become server=/name/#, which sends the name and its subdomains to the normal
+// upstreams; dnsmasq applies the most specific domain match, so they beat a broader address=.
 func genDnsmasq(recs []policy.Record, o Options) ([]byte, error) {
 	var b strings.Builder
 	fmt.Fprintf(&b, "# sb29guard format=dnsmasq mode=%s\n", o.Mode)
+	var blocks []policy.Record
+	for _, r := range recs {
+		if r.Exempt() {
+			fmt.Fprintf(&b, "server=/%s/#\n", strings.TrimPrefix(r.Domain, "*."))
+			continue
+		}
+		blocks = append(blocks, r)
+	}
+	recs = blocks
 	switch o.Mode {
 	case "a-record":
-		if o.RedirectIPv4 == "" {
-			return nil, errors.New("redirect-ipv4 required for dnsmasq a-record mode")
+		addrs := redirectAddrs(o)
+		if len(addrs) == 0 {
+			return nil, errors.New("redirect-ipv4 or redirect-ipv6 required for dnsmasq a-record mode")
 		}
 		for _, r := range recs {
 			name := strings.TrimPrefix(r.Domain, "*.")
-			fmt.Fprintf(&b, "address=/%s/%s\n", name, o.RedirectIPv4)
+			for _, a := range addrs {
+				fmt.Fprintf(&b, "address=/%s/%s\n", name, a.ip)
+			}
 		}
 	case "cname":
 		if o.RedirectHost == "" {
@@ -164,6 +281,11 @@ func genDnsmasq(recs []policy.Record, o Options) ([]byte, error) {
 		for _, r := range recs {
 			name := strings.TrimPrefix(r.Domain, "*.")
 			fmt.Fprintf(&b, "cname=%s,%s\n", name, o.RedirectHost)
+			if isWildcard(r.Domain) {
+				for _, a := range redirectAddrs(o) {
+					fmt.Fprintf(&b, "address=/%s/%s\n", name, a.ip)
+				}
+			}
 		}
 	default:
 		return nil, fmt.Errorf("unsupported mode for dnsmasq: %s", o.Mode)
@@ -172,11 +294,15 @@ func genDnsmasq(recs []policy.Record, o Options) ([]byte, error) {
 }
 
 // genDomainList outputs one domain per line (wildcards stripped), for adlist-style consumers.
-func genDomainList(recs []policy.Record) ([]byte, error) {
+// Adlists have no allow syntax, so exemptions only remove names they cover from the list.
+func genDomainList(recs []policy.Record, p *policy.Policy, o Options) ([]byte, error) {
 	var b strings.Builder
 	fmt.Fprintf(&b, "# sb29guard format=domain-list\n")
 	for _, r := range recs {
 		name := strings.TrimPrefix(r.Domain, "*.")
+		if r.Exempt() || !blocked(p, name, o) {
+			continue
+		}
 		fmt.Fprintf(&b, "%s\n", name)
 	}
 	return []byte(b.String()), nil
@@ -192,16 +318,34 @@ func genWinPS(recs []policy.Record, p *policy.Policy, o Options) ([]byte, error)
 		o.TTL = 300
 	}
 	fmt.Fprintf(&b, "$ttl = New-TimeSpan -Seconds %d\n", o.TTL)
+	warnUnexpressedExemptions(&b, "#", "winps", recs, p, o)
+	var blocks []policy.Record
+	for _, r := range recs {
+		if !r.Exempt() && blocked(p, strings.TrimPrefix(r.Domain, "*."), o) {
+			blocks = append(blocks, r)
+		}
+	}
+	recs = blocks
 	switch o.Mode {
 	case "a-record":
-		if o.RedirectIPv4 == "" {
-			return nil, errors.New("redirect-ipv4 required for winps a-record mode")
+		if o.RedirectIPv4 == "" && o.RedirectIPv6 == "" {
+			return nil, errors.New("redirect-ipv4 or redirect-ipv6 required for winps a-record mode")
+		}
+		if o.RedirectIPv4 != "" {
+			fmt.Fprintf(&b, "$ip = '%s'\n", o.RedirectIPv4)
+		}
+		if o.RedirectIPv6 != "" {
+			fmt.Fprintf(&b, "$ip6 = '%s'\n", o.RedirectIPv6)
 		}
-		fmt.Fprintf(&b, "$ip = '%s'\n", o.RedirectIPv4)
 		for _, r := range recs {
 			name := strings.TrimPrefix(r.Domain, "*.")
 			fmt.Fprintf(&b, "if (-not (Get-DnsServerZone -Name '%s' -ErrorAction SilentlyContinue)) { Add-DnsServerPrimaryZone -Name '%s' -ZoneFile '%s.dns' -DynamicUpdate None }\n", name, name, name)
-			fmt.Fprintf(&b, "try { Add-DnsServerResourceRecordA -ZoneName '%s' -Name '@' -IPv4Address $ip -TimeToLive $ttl -AllowUpdateAny:$false -CreatePtr:$false } catch {}\n", name)
+			if o.RedirectIPv4 != "" {
+				fmt.Fprintf(&b, "try { Add-DnsServerResourceRecordA -ZoneName '%s' -Name '@' -IPv4Address $ip -TimeToLive $ttl -AllowUpdateAny:$false -CreatePtr:$false } catch {}\n", name)
+			}
+			if o.RedirectIPv6 != "" {
+				fmt.Fprintf(&b, "try { Add-DnsServerResourceRecordAAAA -ZoneName '%s' -Name '@' -IPv6Address $ip6 -TimeToLive $ttl -AllowUpdateAny:$false -CreatePtr:$false } catch {}\n", name)
+			}
 		}
 	case "cname":
 		if o.RedirectHost == "" {
@@ -219,6 +363,73 @@ func genWinPS(recs []policy.Record, p *policy.Policy, o Options) ([]byte, error)
 	return []byte(b.String()), nil
 }
 
+func isWildcard(domain string) bool { return strings.HasPrefix(domain, "*.") }
+
+// blocked reports whether name is blocked under the policy's full precedence rules, so a base
+// name that an exemption carves out is not emitted.
+func blocked(p *policy.Policy, name string, o Options) bool {
+	_, ok := p.LookupAt(name, o.Now)
+	return ok
+}
+
+// warnUnexpressedExemptions writes a comment for every exemption that sits under a wildcard
+// block, for formats (bind zone, winps) that cannot pass a name through to normal resolution.
+// Exempt names that are the base of a wildcard are still left out of the output.
+func warnUnexpressedExemptions(b *strings.Builder, comment, format string, recs []policy.Record, p *policy.Policy, o Options) {
+	for _, r := range recs {
+		if !r.Exempt() {
+			continue
+		}
+		for _, w := range recs {
+			base, ok := strings.CutPrefix(w.Domain, "*.")
+			name := strings.TrimPrefix(r.Domain, "*.")
+			if !ok || w.Exempt() || !strings.HasSuffix(name, "."+base) {
+				continue
+			}
+			fmt.Fprintf(b, "%s WARNING: %s format cannot exempt %s from %s; it will still be redirected (use rpz, unbound or dnsmasq)\n", comment, format, r.Domain, w.Domain)
+			break
+		}
+	}
+}
+
+// expandWildcard returns the entries of known that are strict subdomains of base, lowercased,
+// de-duplicated and sorted.
+func expandWildcard(base string, known []string) []string {
+	seen := map[string]struct{}{}
+	var out []string
+	for _, k := range known {
+		d, _, err := policy.NormalizeDomain(strings.TrimSuffix(strings.TrimSpace(k), "."))
+		if err != nil || !strings.HasSuffix(d, "."+base) {
+			continue
+		}
+		if _, ok := seen[d]; ok {
+			continue
+		}
+		seen[d] = struct{}{}
+		out = append(out, d)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// redirectAddr is one redirect address with its DNS record type (A or AAAA).
+type redirectAddr struct {
+	rrType string
+	ip     string
+}
+
+// redirectAddrs returns the configured redirect addresses, IPv4 first.
+func redirectAddrs(o Options) []redirectAddr {
+	var out []redirectAddr
+	if o.RedirectIPv4 != "" {
+		out = append(out, redirectAddr{rrType: "A", ip: o.RedirectIPv4})
+	}
+	if o.RedirectIPv6 != "" {
+		out = append(out, redirectAddr{rrType: "AAAA", ip: o.RedirectIPv6})
+	}
+	return out
+}
+
 // computeSerial returns a BIND/RPZ serial based on strategy.
 // date: YYYYMMDDNN where NN is hash-derived (00-99)
 // epoch: Unix timestamp
@@ -3,6 +3,7 @@ package dnsgen
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
 )
@@ -179,3 +180,320 @@ func TestGenerateErrors(t *testing.T) {
 		t.Fatalf("expected error for rpz missing redirect host")
 	}
 }
+
+func TestGenerateIPv6AlongsideIPv4(t *testing.T) {
+	p := testPolicy()
+	cases := []struct {
+		opt   Options
+		wants []string
+	}{
+		{Options{Format: "hosts"}, []string{"10.10.10.50 exampletool.com", "fd00::50 exampletool.com"}},
+		{Options{Format: "bind", RedirectHost: "blocked.guard.local"}, []string{"IN A 10.10.10.50", "IN AAAA fd00::50"}},
+		{Options{Format: "unbound"}, []string{"local-data: \"exampletool.com A 10.10.10.50\"", "local-data: \"exampletool.com AAAA fd00::50\""}},
+		{Options{Format: "rpz", Mode: "cname", RedirectHost: "blocked.guard.local"}, []string{"blocked.guard.local. A 10.10.10.50", "blocked.guard.local. AAAA fd00::50"}},
+		{Options{Format: "dnsmasq"}, []string{"address=/exampletool.com/10.10.10.50", "address=/exampletool.com/fd00::50"}},
+		{Options{Format: "winps"}, []string{"Add-DnsServerResourceRecordA ", "Add-DnsServerResourceRecordAAAA", "$ip6 = 'fd00::50'"}},
+	}
+	for _, c := range cases {
+		c.opt.RedirectIPv4 = "10.10.10.50"
+		c.opt.RedirectIPv6 = "fd00::50"
+		b, err := Generate(p, c.opt)
+		if err != nil {
+			t.Fatalf("%s: err: %v", c.opt.Format, err)
+		}
+		for _, w := range c.wants {
+			if !strings.Contains(string(b), w) {
+				t.Fatalf("%s: missing %q in:\n%s", c.opt.Format, w, b)
+			}
+		}
+	}
+}
+
+func TestGenerateIPv6Only(t *testing.T) {
+	p := testPolicy()
+	for _, f := range []string{"hosts", "bind", "unbound", "dnsmasq", "winps"} {
+		b, err := Generate(p, Options{Format: f, RedirectIPv6: "2001:db8::50", RedirectHost: "blocked.guard.local"})
+		if err != nil {
+			t.Fatalf("%s: IPv6-only should be accepted: %v", f, err)
+		}
+		out := string(b)
+		if !strings.Contains(out, "2001:db8::50") {
+			t.Fatalf("%s: missing IPv6 redirect:\n%s", f, out)
+		}
+		if strings.Contains(out, " A ") || strings.Contains(out, "RecordA ") {
+			t.Fatalf("%s: unexpected A record in IPv6-only output:\n%s", f, out)
+		}
+	}
+}
+
+func TestGenerateInvalidIPv6(t *testing.T) {
+	p := testPolicy()
+	for _, bad := range []string{"10.10.10.50", "not-an-ip", "fd00::zz"} {
+		if _, err := Generate(p, Options{Format: "hosts", RedirectIPv4: "10.10.10.50", RedirectIPv6: bad}); err == nil {
+			t.Fatalf("expected error for redirect-ipv6 %q", bad)
+		}
+	}
+}
+
+func TestWildcardSemanticsPerFormat(t *testing.T) {
+	p := testPolicy()
+	cases := []struct {
+		name  string
+		opt   Options
+		wants []string
+		nots  []string
+	}{
+		{"bind a-record", Options{Format: "bind", RedirectIPv4: "10.10.10.50", RedirectHost: "blocked.guard.local"},
+			[]string{"trackingwidgets.io 300 IN A 10.10.10.50", "*.trackingwidgets.io 300 IN A 10.10.10.50", "exampletool.com 300 IN A"},
+			[]string{"*.exampletool.com"}},
+		{"bind cname", Options{Format: "bind", Mode: "cname", RedirectHost: "blocked.guard.local"},
+			[]string{"trackingwidgets.io 300 IN CNAME blocked.guard.local.", "*.trackingwidgets.io 300 IN CNAME blocked.guard.local."},
+			nil},
+		{"unbound a-record", Options{Format: "unbound", RedirectIPv4: "10.10.10.50"},
+			[]string{"local-zone: \"trackingwidgets.io\" redirect", "local-zone: \"exampletool.com\" transparent"},
+			[]string{"local-zone: \"exampletool.com\" redirect"}},
+		{"unbound cname", Options{Format: "unbound", Mode: "cname", RedirectHost: "blocked.guard.local"},
+			[]string{"local-zone: \"trackingwidgets.io\" redirect", "local-data: \"trackingwidgets.io CNAME blocked.guard.local\""},
+			nil},
+		// dnsmasq address=/name/ implicitly covers all subdomains, so the base name is the whole wildcard.
+		{"dnsmasq a-record", Options{Format: "dnsmasq", RedirectIPv4: "10.10.10.50"},
+			[]string{"address=/trackingwidgets.io/10.10.10.50"},
+			[]string{"*."}},
+		{"dnsmasq cname", Options{Format: "dnsmasq", Mode: "cname", RedirectHost: "blocked.guard.local", RedirectIPv4: "10.10.10.50"},
+			[]string{"cname=trackingwidgets.io,blocked.guard.local", "address=/trackingwidgets.io/10.10.10.50"},
+			[]string{"address=/exampletool.com/"}},
+	}
+	for _, c := range cases {
+		b, err := Generate(p, c.opt)
+		if err != nil {
+			t.Fatalf("%s: err: %v", c.name, err)
+		}
+		out := string(b)
+		for _, w := range c.wants {
+			if !strings.Contains(out, w) {
+				t.Fatalf("%s: missing %q in:\n%s", c.name, w, out)
+			}
+		}
+		for _, n := range c.nots {
+			if strings.Contains(out, n) {
+				t.Fatalf("%s: unexpected %q in:\n%s", c.name, n, out)
+			}
+		}
+	}
+}
+
+func TestExactAndWildcardShareOwner(t *testing.T) {
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "example.com", Classification: "NO_DPA", Rationale: "x", LastReview: "2025-08-01", Status: "active"},
+		{Domain: "*.example.com", Classification: "OTHER", Rationale: "x", LastReview: "2025-08-01", Status: "active"},
+	}}
+	cases := []struct {
+		opt   Options
+		owner string
+		zone  string
+	}{
+		{Options{Format: "unbound", RedirectIPv4: "10.10.10.50", RedirectIPv6: "fd00::50"}, "local-zone: \"example.com\"", "local-zone: \"example.com\" redirect"},
+		{Options{Format: "unbound", Mode: "cname", RedirectHost: "blocked.guard.local"}, "local-zone: \"example.com\"", "local-zone: \"example.com\" redirect"},
+		{Options{Format: "bind", RedirectIPv4: "10.10.10.50", RedirectHost: "blocked.guard.local"}, "\nexample.com 300 IN A ", ""},
+		{Options{Format: "bind", Mode: "cname", RedirectHost: "blocked.guard.local"}, "\nexample.com 300 IN CNAME ", ""},
+	}
+	for _, c := range cases {
+		b, err := Generate(p, c.opt)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", c.opt.Format, c.opt.Mode, err)
+		}
+		out := string(b)
+		if n := strings.Count(out, c.owner); n != 1 {
+			t.Fatalf("%s/%s: expected one %q, got %d:\n%s", c.opt.Format, c.opt.Mode, c.owner, n, out)
+		}
+		if c.zone != "" && !strings.Contains(out, c.zone) {
+			t.Fatalf("%s/%s: wildcard zone should cover the base name:\n%s", c.opt.Format, c.opt.Mode, out)
+		}
+		if strings.Contains(out, "WARNING") {
+			t.Fatalf("%s/%s: two blocking rules should not warn:\n%s", c.opt.Format, c.opt.Mode, out)
+		}
+	}
+}
+
+func TestHostsWildcardWarningAndExpansion(t *testing.T) {
+	p := testPolicy()
+	b, err := Generate(p, Options{Format: "hosts", RedirectIPv4: "10.10.10.50"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(string(b), "# WARNING: hosts format cannot express wildcards; wildcard record for trackingwidgets.io") {
+		t.Fatalf("expected wildcard warning:\n%s", b)
+	}
+	b, err = Generate(p, Options{Format: "hosts", RedirectIPv4: "10.10.10.50", HostsExpand: []string{
+		"API.trackingwidgets.io.", "cdn.trackingwidgets.io", "api.trackingwidgets.io", "unrelated.example.org", "sub.exampletool.com", "nottrackingwidgets.io",
+	}})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out := string(b)
+	for _, w := range []string{"10.10.10.50 trackingwidgets.io\n", "10.10.10.50 api.trackingwidgets.io\n", "10.10.10.50 cdn.trackingwidgets.io\n", "2 listed subdomain(s)"} {
+		if !strings.Contains(out, w) {
+			t.Fatalf("missing %q in:\n%s", w, out)
+		}
+	}
+	// Only subdomains of wildcard records are expanded; exact records are never widened.
+	for _, n := range []string{"unrelated.example.org", "sub.exampletool.com", "nottrackingwidgets.io"} {
+		if strings.Contains(out, n) {
+			t.Fatalf("unexpected expansion %q in:\n%s", n, out)
+		}
+	}
+	if strings.Count(out, "api.trackingwidgets.io") != 1 {
+		t.Fatalf("expected de-duplicated expansion:\n%s", out)
+	}
+}
+
+func TestGenerateSkipsExpiredRecords(t *testing.T) {
+	p := testPolicy()
+	p.Records[0].Expires = "2025-08-31"
+	opt := Options{Format: "domain-list", Now: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)}
+	b, err := Generate(p, opt)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out := string(b); strings.Contains(out, "exampletool.com") || !strings.Contains(out, "trackingwidgets.io") {
+		t.Fatalf("expired record should be dropped: %s", out)
+	}
+	opt.Now = time.Date(2025, 8, 31, 12, 0, 0, 0, time.UTC)
+	b, _ = Generate(p, opt)
+	if !strings.Contains(string(b), "exampletool.com") {
+		t.Fatalf("record should be present on its expires date: %s", b)
+	}
+}
+
+func TestGenerateEmitsALabels(t *testing.T) {
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "bücher.de", Classification: "NO_DPA", Rationale: "x", LastReview: "2025-08-01", Status: "active"},
+	}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	for _, f := range []string{"hosts", "bind", "unbound", "dnsmasq", "domain-list"} {
+		b, err := Generate(p, Options{Format: f, Mode: "a-record", RedirectIPv4: "10.0.0.1"})
+		if err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+		if out := string(b); !strings.Contains(out, "xn--bcher-kva.de") || strings.Contains(out, "bücher") {
+			t.Fatalf("%s output should use the A-label only: %s", f, out)
+		}
+	}
+}
+
+func TestGenerateHonoursExemptions(t *testing.T) {
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "*.bigvendor.com", Classification: "NO_DPA", Rationale: "vendor unsigned", LastReview: "2025-08-01", Status: "active"},
+		{Domain: "classroom.bigvendor.com", Action: policy.ActionAllow, Rationale: "signed DPA", LastReview: "2025-08-01", Status: "active"},
+		{Domain: "exampletool.com", Action: policy.ActionAllow, Rationale: "signed DPA", LastReview: "2025-08-01", Status: "active"},
+		{Domain: "*.exampletool.com", Classification: "NO_DPA", Rationale: "vendor unsigned", LastReview: "2025-08-01", Status: "active"},
+	}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	cases := []struct {
+		name  string
+		opt   Options
+		wants []string
+		nots  []string
+	}{
+		{"rpz", Options{Format: "rpz", RedirectHost: "blocked.guard.local"},
+			[]string{"*.bigvendor.com. CNAME blocked.guard.local.", "classroom.bigvendor.com. CNAME rpz-passthru.", "exampletool.com. CNAME rpz-passthru."},
+			nil},
+		{"unbound", Options{Format: "unbound", RedirectIPv4: "10.10.10.50"},
+			[]string{"local-zone: \"bigvendor.com\" redirect", "local-zone: \"classroom.bigvendor.com\" transparent", "WARNING: unbound format cannot separate exampletool.com"},
+			[]string{"classroom.bigvendor.com A"}},
+		{"dnsmasq", Options{Format: "dnsmasq", RedirectIPv4: "10.10.10.50"},
+			[]string{"address=/bigvendor.com/10.10.10.50", "server=/classroom.bigvendor.com/#"},
+			[]string{"address=/classroom.bigvendor.com/"}},
+		{"hosts", Options{Format: "hosts", RedirectIPv4: "10.10.10.50", HostsExpand: []string{"classroom.bigvendor.com", "ads.bigvendor.com"}},
+			[]string{"10.10.10.50 bigvendor.com", "10.10.10.50 ads.bigvendor.com"},
+			[]string{"classroom.bigvendor.com", " exampletool.com\n"}},
+		{"bind", Options{Format: "bind", RedirectIPv4: "10.10.10.50", RedirectHost: "blocked.guard.local"},
+			[]string{"; WARNING: bind format cannot exempt classroom.bigvendor.com from *.bigvendor.com", "*.exampletool.com 300 IN A"},
+			[]string{"\nexampletool.com 300 IN A", "classroom.bigvendor.com 300"}},
+		{"domain-list", Options{Format: "domain-list"},
+			[]string{"bigvendor.com\n"},
+			[]string{"classroom", "exampletool.com"}},
+		{"winps", Options{Format: "winps", RedirectIPv4: "10.10.10.50"},
+			[]string{"# WARNING: winps format cannot exempt classroom.bigvendor.com", "-Name 'bigvendor.com'"},
+			[]string{"-Name 'exampletool.com'", "-Name 'classroom.bigvendor.com'"}},
+	}
+	for _, c := range cases {
+		b, err := Generate(p, c.opt)
+		if err != nil {
+			t.Fatalf("%s: err: %v", c.name, err)
+		}
+		out := string(b)
+		for _, w := range c.wants {
+			if !strings.Contains(out, w) {
+				t.Fatalf("%s: missing %q in:\n%s", c.name, w, out)
+			}
+		}
+		for _, n := range c.nots {
+			if strings.Contains(out, n) {
+				t.Fatalf("%s: unexpected %q in:\n%s", c.name, n, out)
+			}
+		}
+	}
+}
+
+func TestGenerateScope(t *testing.T) {
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "everyone.com", Classification: "NO_DPA", Rationale: "vendor unsigned", LastReview: "2025-08-01", Status: "active"},
+		{Domain: "games.com", Classification: "OTHER", Rationale: "student devices only", LastReview: "2025-08-01", Status: "active", Scopes: []string{"students"}},
+	}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	staff, err := Generate(p, Options{Format: "domain-list", Scope: "staff"})
+	if err == nil {
+		t.Fatalf("expected unknown scope error, got:\n%s", staff)
+	}
+	students, err := Generate(p, Options{Format: "domain-list", Scope: "students"})
+	if err != nil || !strings.Contains(string(students), "games.com") || !strings.Contains(string(students), "everyone.com") {
+		t.Fatalf("students view: err=%v\n%s", err, students)
+	}
+	p.Records[0].Scopes = []string{"staff"}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	staff, err = Generate(p, Options{Format: "domain-list", Scope: "staff"})
+	if err != nil || strings.Contains(string(staff), "games.com") || !strings.Contains(string(staff), "everyone.com") {
+		t.Fatalf("staff view: err=%v\n%s", err, staff)
+	}
+}
+
+func TestGenerateScopeKeepsCustomTaxonomy(t *testing.T) {
+	informational := false
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08",
+		Classifications: []policy.ClassificationDef{
+			{Key: "PARENT_CONSENT_REQUIRED", Label: "Parent Consent Required"},
+			{Key: "MONITORED", Label: "Monitored", Blocks: &informational},
+		},
+		Records: []policy.Record{
+			{Domain: "consent.com", Classification: "PARENT_CONSENT_REQUIRED", Rationale: "needs consent", LastReview: "2025-08-01", Status: "active", Scopes: []string{"students"}},
+			{Domain: "*.games.com", Classification: "NO_DPA", Rationale: "vendor unsigned", LastReview: "2025-08-01", Status: "active"},
+			{Domain: "quiz.games.com", Classification: "MONITORED", Rationale: "logged only", LastReview: "2025-08-01", Status: "active", Scopes: []string{"students"}},
+		}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	out, err := Generate(p, Options{Format: "rpz", RedirectHost: "blocked.guard.local", Scope: "students"})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	s := string(out)
+	if !strings.Contains(s, "consent.com") || !strings.Contains(s, "quiz.games.com. CNAME rpz-passthru.") {
+		t.Fatalf("scoped output should block consent.com and pass quiz.games.com through:\n%s", s)
+	}
+	v := p.ForScope("students")
+	if err := v.Validate(); err != nil {
+		t.Fatalf("scoped view should validate against the policy's taxonomy: %v", err)
+	}
+	if c, ok := v.Classification("PARENT_CONSENT_REQUIRED"); !ok || c.DisplayLabel() != "Parent Consent Required" {
+		t.Fatalf("scoped view lost the custom label: %+v ok=%v", c, ok)
+	}
+}
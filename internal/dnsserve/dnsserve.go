@@ -0,0 +1,392 @@
+// Package dnsserve provides a small authoritative DNS responder that answers queries for
+// policy-matched domains with the configured redirect target and forwards everything else upstream.
+package dnsserve
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/dnsgen"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+)
+
+// maxUDPSize is the largest UDP payload we read or relay (EDNS0-sized buffer).
+const maxUDPSize = 4096
+
+// Server answers DNS queries over UDP and TCP using the in-memory policy.
+type Server struct {
+	addr     string
+	opts     dnsgen.Options
+	upstream string
+	timeout  time.Duration
+
+	mu     sync.RWMutex
+	policy *policy.Policy
+
+	// refresh counters (mirrors the HTTP server so refresh loops can drive either)
+	refreshMu         sync.RWMutex
+	refreshCount      int
+	refreshErrorCount int
+	refreshErrors     map[string]int // by reason
+	lastRefreshError  string
+
+	closeMu sync.Mutex
+	pc      net.PacketConn
+	ln      net.Listener
+}
+
+// New creates a DNS responder bound to addr. Redirect answers use o.Mode, o.RedirectIPv4,
+// o.RedirectIPv6, o.RedirectHost and o.TTL, matching dnsgen.Generate; o.Scope limits matching
+// to one audience's records so each VLAN can get its own responder. upstream is a host:port
+// resolver used for names outside the policy; when empty such queries are answered with REFUSED.
+func New(addr string, p *policy.Policy, o dnsgen.Options, upstream string) (*Server, error) {
+	if o.Mode == "" {
+		o.Mode = "a-record"
+	}
+	if o.TTL <= 0 {
+		o.TTL = 300
+	}
+	if o.RedirectIPv4 != "" && net.ParseIP(o.RedirectIPv4).To4() == nil {
+		return nil, fmt.Errorf("invalid redirect-ipv4: %s", o.RedirectIPv4)
+	}
+	if o.RedirectIPv6 != "" {
+		if ip := net.ParseIP(o.RedirectIPv6); ip == nil || ip.To4() != nil {
+			return nil, fmt.Errorf("invalid redirect-ipv6: %s", o.RedirectIPv6)
+		}
+	}
+	switch o.Mode {
+	case "a-record":
+		if o.RedirectIPv4 == "" && o.RedirectIPv6 == "" {
+			return nil, errors.New("redirect-ipv4 or redirect-ipv6 required for a-record mode")
+		}
+	case "cname":
+		if strings.TrimSpace(o.RedirectHost) == "" {
+			return nil, errors.New("redirect-host required for cname mode")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported mode: %s", o.Mode)
+	}
+	if !p.HasScope(o.Scope) {
+		return nil, fmt.Errorf("unknown scope: %s", o.Scope)
+	}
+	if upstream != "" {
+		if _, _, err := net.SplitHostPort(upstream); err != nil {
+			upstream = net.JoinHostPort(upstream, "53")
+		}
+	}
+	return &Server{addr: addr, opts: o, upstream: upstream, timeout: 3 * time.Second, policy: p}, nil
+}
+
+// Start listens on the configured address (UDP and TCP) and serves until Close is called.
+func (s *Server) Start() error {
+	pc, err := net.ListenPacket("udp", s.addr)
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		_ = pc.Close()
+		return err
+	}
+	return s.Serve(pc, ln)
+}
+
+// Serve answers queries arriving on pc (UDP) and ln (TCP). Either may be nil.
+// It blocks until both are closed and returns the first non-close error.
+func (s *Server) Serve(pc net.PacketConn, ln net.Listener) error {
+	s.closeMu.Lock()
+	s.pc, s.ln = pc, ln
+	s.closeMu.Unlock()
+	errs := make(chan error, 2)
+	n := 0
+	if pc != nil {
+		n++
+		go func() { errs <- s.serveUDP(pc) }()
+	}
+	if ln != nil {
+		n++
+		go func() { errs <- s.serveTCP(ln) }()
+	}
+	var first error
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil && !errors.Is(err, net.ErrClosed) && first == nil {
+			first = err
+			_ = s.Close()
+		}
+	}
+	return first
+}
+
+// Close stops the listeners started by Start or Serve.
+func (s *Server) Close() error {
+	s.closeMu.Lock()
+	defer s.closeMu.Unlock()
+	var err error
+	if s.pc != nil {
+		err = s.pc.Close()
+	}
+	if s.ln != nil {
+		if lerr := s.ln.Close(); err == nil {
+			err = lerr
+		}
+	}
+	return err
+}
+
+func (s *Server) serveUDP(pc net.PacketConn) error {
+	buf := make([]byte, maxUDPSize)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		req := make([]byte, n)
+		copy(req, buf[:n])
+		go func() {
+			if resp := s.handle(req, "udp"); resp != nil {
+				_, _ = pc.WriteTo(resp, addr)
+			}
+		}()
+	}
+}
+
+func (s *Server) serveTCP(ln net.Listener) error {
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveTCPConn(c)
+	}
+}
+
+// serveTCPConn handles length-prefixed DNS messages until the client closes or goes idle.
+func (s *Server) serveTCPConn(c net.Conn) {
+	defer func() { _ = c.Close() }()
+	for {
+		_ = c.SetDeadline(time.Now().Add(10 * time.Second))
+		req, err := readTCPMsg(c)
+		if err != nil {
+			return
+		}
+		resp := s.handle(req, "tcp")
+		if resp == nil {
+			return
+		}
+		if err := writeTCPMsg(c, resp); err != nil {
+			return
+		}
+	}
+}
+
+// handle builds the response for a single raw query. It returns nil when the query is too
+// malformed to answer at all.
+func (s *Server) handle(req []byte, network string) []byte {
+	var p dnsmessage.Parser
+	hdr, err := p.Start(req)
+	if err != nil {
+		return nil
+	}
+	q, err := p.Question()
+	if err != nil || hdr.Response || hdr.OpCode != 0 {
+		return s.reply(hdr, nil, dnsmessage.RCodeFormatError, nil)
+	}
+	name := strings.TrimSuffix(strings.ToLower(q.Name.String()), ".")
+	if q.Class == dnsmessage.ClassINET {
+		if answers, ok := s.answer(name, q); ok {
+			return s.reply(hdr, &q, dnsmessage.RCodeSuccess, answers)
+		}
+	}
+	if s.upstream == "" {
+		return s.reply(hdr, &q, dnsmessage.RCodeRefused, nil)
+	}
+	resp, err := s.forward(req, network)
+	if err != nil {
+		return s.reply(hdr, &q, dnsmessage.RCodeServerFailure, nil)
+	}
+	return resp
+}
+
+// answer returns the redirect records for a policy-matched name. ok is false when the name is
+// not ours to answer and should be forwarded.
+func (s *Server) answer(name string, q dnsmessage.Question) ([]dnsmessage.Resource, bool) {
+	o := s.opts
+	redirectHost := strings.TrimSuffix(strings.ToLower(o.RedirectHost), ".")
+	_, matched := s.getPolicy().LookupScopedAt(name, o.Scope, time.Now())
+	if !matched {
+		// Answer for the redirect host itself when we know its address (as the RPZ output does).
+		if o.Mode == "cname" && name == redirectHost && (o.RedirectIPv4 != "" || o.RedirectIPv6 != "") {
+			return s.addressRecords(q.Name, q.Type), true
+		}
+		return nil, false
+	}
+	if o.Mode == "cname" {
+		target, err := dnsmessage.NewName(redirectHost + ".")
+		if err != nil {
+			return nil, true
+		}
+		out := []dnsmessage.Resource{{
+			Header: s.rrHeader(q.Name, dnsmessage.TypeCNAME),
+			Body:   &dnsmessage.CNAMEResource{CNAME: target},
+		}}
+		return append(out, s.addressRecords(target, q.Type)...), true
+	}
+	return s.addressRecords(q.Name, q.Type), true
+}
+
+// addressRecords returns the redirect address records for qtype (empty for NODATA).
+func (s *Server) addressRecords(name dnsmessage.Name, qtype dnsmessage.Type) []dnsmessage.Resource {
+	var out []dnsmessage.Resource
+	if s.opts.RedirectIPv4 != "" && (qtype == dnsmessage.TypeA || qtype == dnsmessage.TypeALL) {
+		var a [4]byte
+		copy(a[:], net.ParseIP(s.opts.RedirectIPv4).To4())
+		out = append(out, dnsmessage.Resource{Header: s.rrHeader(name, dnsmessage.TypeA), Body: &dnsmessage.AResource{A: a}})
+	}
+	if s.opts.RedirectIPv6 != "" && (qtype == dnsmessage.TypeAAAA || qtype == dnsmessage.TypeALL) {
+		var aaaa [16]byte
+		copy(aaaa[:], net.ParseIP(s.opts.RedirectIPv6).To16())
+		out = append(out, dnsmessage.Resource{Header: s.rrHeader(name, dnsmessage.TypeAAAA), Body: &dnsmessage.AAAAResource{AAAA: aaaa}})
+	}
+	return out
+}
+
+func (s *Server) rrHeader(name dnsmessage.Name, t dnsmessage.Type) dnsmessage.ResourceHeader {
+	return dnsmessage.ResourceHeader{Name: name, Type: t, Class: dnsmessage.ClassINET, TTL: uint32(s.opts.TTL)}
+}
+
+// reply packs a response that echoes the query ID and question.
+func (s *Server) reply(req dnsmessage.Header, q *dnsmessage.Question, rcode dnsmessage.RCode, answers []dnsmessage.Resource) []byte {
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 req.ID,
+			Response:           true,
+			Authoritative:      rcode == dnsmessage.RCodeSuccess,
+			RecursionDesired:   req.RecursionDesired,
+			RecursionAvailable: s.upstream != "",
+			RCode:              rcode,
+		},
+		Answers: answers,
+	}
+	if q != nil {
+		msg.Questions = []dnsmessage.Question{*q}
+	}
+	b, err := msg.Pack()
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// forward relays the raw query to the upstream resolver over the same transport it arrived on.
+func (s *Server) forward(req []byte, network string) ([]byte, error) {
+	c, err := net.DialTimeout(network, s.upstream, s.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = c.Close() }()
+	_ = c.SetDeadline(time.Now().Add(s.timeout))
+	if network == "tcp" {
+		if err := writeTCPMsg(c, req); err != nil {
+			return nil, err
+		}
+		return readTCPMsg(c)
+	}
+	if _, err := c.Write(req); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, maxUDPSize)
+	n, err := c.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func readTCPMsg(r io.Reader) ([]byte, error) {
+	var l [2]byte
+	if _, err := io.ReadFull(r, l[:]); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, binary.BigEndian.Uint16(l[:]))
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func writeTCPMsg(w io.Writer, msg []byte) error {
+	if len(msg) > 0xffff {
+		return errors.New("dns message too large")
+	}
+	b := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(b, uint16(len(msg)))
+	copy(b[2:], msg)
+	_, err := w.Write(b)
+	return err
+}
+
+// UpdatePolicy swaps the in-memory policy used to answer queries.
+func (s *Server) UpdatePolicy(p *policy.Policy) {
+	p.BuildIndex()
+	s.mu.Lock()
+	s.policy = p
+	s.mu.Unlock()
+}
+
+// CurrentPolicy returns the policy currently in use.
+func (s *Server) CurrentPolicy() *policy.Policy {
+	return s.getPolicy()
+}
+
+// RecordRefreshSuccess records a successful policy refresh.
+func (s *Server) RecordRefreshSuccess(_ string) {
+	s.refreshMu.Lock()
+	s.refreshCount++
+	s.lastRefreshError = ""
+	s.refreshMu.Unlock()
+}
+
+// RecordRefreshError records a failed policy refresh under a short reason code.
+func (s *Server) RecordRefreshError(reason, msg string) {
+	s.refreshMu.Lock()
+	s.refreshErrorCount++
+	if s.refreshErrors == nil {
+		s.refreshErrors = make(map[string]int)
+	}
+	s.refreshErrors[reason]++
+	s.lastRefreshError = msg
+	s.refreshMu.Unlock()
+}
+
+// RefreshStats returns the refresh success and error counts and the last error message.
+func (s *Server) RefreshStats() (refreshes, errs int, lastErr string) {
+	s.refreshMu.RLock()
+	defer s.refreshMu.RUnlock()
+	return s.refreshCount, s.refreshErrorCount, s.lastRefreshError
+}
+
+// RefreshErrorsByReason returns a copy of the refresh error counts keyed by reason code.
+func (s *Server) RefreshErrorsByReason() map[string]int {
+	s.refreshMu.RLock()
+	defer s.refreshMu.RUnlock()
+	out := make(map[string]int, len(s.refreshErrors))
+	for k, v := range s.refreshErrors {
+		out[k] = v
+	}
+	return out
+}
+
+func (s *Server) getPolicy() *policy.Policy {
+	s.mu.RLock()
+	p := s.policy
+	s.mu.RUnlock()
+	return p
+}
@@ -0,0 +1,331 @@
+package dnsserve
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/dnsgen"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+)
+
+func testPolicy() *policy.Policy {
+	return &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "exampletool.com", Classification: "NO_DPA", Rationale: "x", LastReview: "2025-08-01", Status: "active"},
+		{Domain: "*.trackingwidgets.io", Classification: "EXPIRED_DPA", Rationale: "x", LastReview: "2025-07-15", Status: "active"},
+	}}
+}
+
+// startUpstreamStub answers every UDP and TCP query with A 192.0.2.99 so forwarded answers are recognizable.
+func startUpstreamStub(t *testing.T) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("stub listen udp: %v", err)
+	}
+	ln, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		_ = pc.Close()
+		t.Skipf("stub tcp port unavailable: %v", err)
+	}
+	t.Cleanup(func() { _ = pc.Close(); _ = ln.Close() })
+	answer := func(req []byte) []byte {
+		var p dnsmessage.Parser
+		h, err := p.Start(req)
+		if err != nil {
+			return nil
+		}
+		q, err := p.Question()
+		if err != nil {
+			return nil
+		}
+		msg := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: h.ID, Response: true, RecursionAvailable: true},
+			Questions: []dnsmessage.Question{q},
+			Answers: []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.AResource{A: [4]byte{192, 0, 2, 99}},
+			}},
+		}
+		b, _ := msg.Pack()
+		return b
+	}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = pc.WriteTo(answer(buf[:n]), addr)
+		}
+	}()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				req, err := readTCPMsg(c)
+				if err != nil {
+					return
+				}
+				_ = writeTCPMsg(c, answer(req))
+			}()
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+// startGuard starts the responder on loopback and returns its address (same port for UDP and TCP).
+func startGuard(t *testing.T, srv *Server) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	ln, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		_ = pc.Close()
+		t.Skipf("tcp port unavailable: %v", err)
+	}
+	go func() { _ = srv.Serve(pc, ln) }()
+	t.Cleanup(func() { _ = srv.Close() })
+	return pc.LocalAddr().String()
+}
+
+func query(t *testing.T, network, addr, name string, qtype dnsmessage.Type) dnsmessage.Message {
+	t.Helper()
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: 4242, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: dnsmessage.MustNewName(name), Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	req, err := msg.Pack()
+	if err != nil {
+		t.Fatalf("pack: %v", err)
+	}
+	c, err := net.DialTimeout(network, addr, 2*time.Second)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer c.Close()
+	_ = c.SetDeadline(time.Now().Add(2 * time.Second))
+	var resp []byte
+	if network == "tcp" {
+		if err := writeTCPMsg(c, req); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if resp, err = readTCPMsg(c); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+	} else {
+		if _, err := c.Write(req); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		buf := make([]byte, maxUDPSize)
+		n, err := c.Read(buf)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		resp = buf[:n]
+	}
+	var out dnsmessage.Message
+	if err := out.Unpack(resp); err != nil {
+		t.Fatalf("unpack: %v", err)
+	}
+	if out.Header.ID != 4242 {
+		t.Fatalf("response ID mismatch: %d", out.Header.ID)
+	}
+	return out
+}
+
+func firstA(t *testing.T, m dnsmessage.Message) [4]byte {
+	t.Helper()
+	for _, a := range m.Answers {
+		if r, ok := a.Body.(*dnsmessage.AResource); ok {
+			return r.A
+		}
+	}
+	t.Fatalf("no A record in answers: %+v", m.Answers)
+	return [4]byte{}
+}
+
+func TestARecordModeRedirectsAndForwards(t *testing.T) {
+	upstream := startUpstreamStub(t)
+	srv, err := New("", testPolicy(), dnsgen.Options{Mode: "a-record", RedirectIPv4: "10.10.10.50", TTL: 120}, upstream)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	addr := startGuard(t, srv)
+	for _, network := range []string{"udp", "tcp"} {
+		// Exact and wildcard policy matches are answered locally.
+		for _, name := range []string{"exampletool.com.", "trackingwidgets.io.", "api.trackingwidgets.io.", "ExampleTool.COM."} {
+			m := query(t, network, addr, name, dnsmessage.TypeA)
+			if !m.Header.Authoritative || m.Header.RCode != dnsmessage.RCodeSuccess {
+				t.Fatalf("%s %s: expected authoritative NOERROR, got %+v", network, name, m.Header)
+			}
+			if a := firstA(t, m); a != [4]byte{10, 10, 10, 50} {
+				t.Fatalf("%s %s: unexpected redirect %v", network, name, a)
+			}
+			if m.Answers[0].Header.TTL != 120 {
+				t.Fatalf("expected TTL 120 got %d", m.Answers[0].Header.TTL)
+			}
+		}
+		// AAAA for a blocked name is NODATA, not forwarded (no IPv6 bypass).
+		m := query(t, network, addr, "exampletool.com.", dnsmessage.TypeAAAA)
+		if m.Header.RCode != dnsmessage.RCodeSuccess || len(m.Answers) != 0 {
+			t.Fatalf("%s: expected NODATA for AAAA, got %+v", network, m)
+		}
+		// Everything else goes to the upstream stub.
+		m = query(t, network, addr, "allowed.example.org.", dnsmessage.TypeA)
+		if a := firstA(t, m); a != [4]byte{192, 0, 2, 99} {
+			t.Fatalf("%s: expected forwarded answer, got %v", network, a)
+		}
+	}
+}
+
+func TestCNAMEModeIncludesTargetAddress(t *testing.T) {
+	srv, err := New("", testPolicy(), dnsgen.Options{Mode: "cname", RedirectHost: "blocked.guard.local", RedirectIPv4: "10.10.10.50"}, "")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	addr := startGuard(t, srv)
+	m := query(t, "udp", addr, "api.trackingwidgets.io.", dnsmessage.TypeA)
+	if len(m.Answers) != 2 {
+		t.Fatalf("expected CNAME + A, got %+v", m.Answers)
+	}
+	cn, ok := m.Answers[0].Body.(*dnsmessage.CNAMEResource)
+	if !ok || cn.CNAME.String() != "blocked.guard.local." {
+		t.Fatalf("expected CNAME to redirect host, got %+v", m.Answers[0])
+	}
+	if a := firstA(t, m); a != [4]byte{10, 10, 10, 50} {
+		t.Fatalf("unexpected target address %v", a)
+	}
+	// The redirect host itself resolves locally.
+	m = query(t, "udp", addr, "blocked.guard.local.", dnsmessage.TypeA)
+	if a := firstA(t, m); a != [4]byte{10, 10, 10, 50} {
+		t.Fatalf("unexpected redirect host address %v", a)
+	}
+}
+
+func TestRefusedWithoutUpstream(t *testing.T) {
+	srv, err := New("", testPolicy(), dnsgen.Options{RedirectIPv4: "10.10.10.50"}, "")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	addr := startGuard(t, srv)
+	m := query(t, "udp", addr, "allowed.example.org.", dnsmessage.TypeA)
+	if m.Header.RCode != dnsmessage.RCodeRefused {
+		t.Fatalf("expected REFUSED, got %v", m.Header.RCode)
+	}
+}
+
+func TestUpstreamFailureIsServFail(t *testing.T) {
+	// Reserve a port and close it so nothing answers there.
+	c, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	dead := c.LocalAddr().String()
+	_ = c.Close()
+	srv, err := New("", testPolicy(), dnsgen.Options{RedirectIPv4: "10.10.10.50"}, dead)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	srv.timeout = 200 * time.Millisecond
+	addr := startGuard(t, srv)
+	m := query(t, "udp", addr, "allowed.example.org.", dnsmessage.TypeA)
+	if m.Header.RCode != dnsmessage.RCodeServerFailure {
+		t.Fatalf("expected SERVFAIL, got %v", m.Header.RCode)
+	}
+}
+
+func TestUpdatePolicySwap(t *testing.T) {
+	srv, err := New("", testPolicy(), dnsgen.Options{RedirectIPv4: "10.10.10.50"}, "")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	addr := startGuard(t, srv)
+	if m := query(t, "udp", addr, "newtool.example.", dnsmessage.TypeA); m.Header.RCode != dnsmessage.RCodeRefused {
+		t.Fatalf("expected REFUSED before swap, got %v", m.Header.RCode)
+	}
+	srv.UpdatePolicy(&policy.Policy{Version: "B", Records: []policy.Record{
+		{Domain: "newtool.example", Classification: "NO_DPA", Status: "active"},
+	}})
+	srv.RecordRefreshSuccess("file")
+	if m := query(t, "udp", addr, "newtool.example.", dnsmessage.TypeA); firstA(t, m) != [4]byte{10, 10, 10, 50} {
+		t.Fatalf("expected redirect after swap")
+	}
+	if n, _, _ := srv.RefreshStats(); n != 1 {
+		t.Fatalf("expected refresh count 1, got %d", n)
+	}
+	srv.RecordRefreshError("guard_rejected", "guard rejected refresh (record_drop)")
+	if _, errs, last := srv.RefreshStats(); errs != 1 || last != "guard rejected refresh (record_drop)" || srv.RefreshErrorsByReason()["guard_rejected"] != 1 {
+		t.Fatalf("unexpected refresh errors: %d %q %v", errs, last, srv.RefreshErrorsByReason())
+	}
+}
+
+func TestNewValidatesOptions(t *testing.T) {
+	if _, err := New("", testPolicy(), dnsgen.Options{Mode: "a-record"}, ""); err == nil {
+		t.Fatalf("expected error without redirect-ipv4")
+	}
+	if _, err := New("", testPolicy(), dnsgen.Options{Mode: "cname"}, ""); err == nil {
+		t.Fatalf("expected error without redirect-host")
+	}
+	if _, err := New("", testPolicy(), dnsgen.Options{Mode: "bogus", RedirectIPv4: "10.0.0.1"}, ""); err == nil {
+		t.Fatalf("expected error for unsupported mode")
+	}
+	srv, err := New("", testPolicy(), dnsgen.Options{RedirectIPv4: "10.0.0.1"}, "192.0.2.53")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if srv.upstream != "192.0.2.53:53" {
+		t.Fatalf("expected default port on upstream, got %s", srv.upstream)
+	}
+}
+
+func TestAAAARedirect(t *testing.T) {
+	srv, err := New("", testPolicy(), dnsgen.Options{RedirectIPv4: "10.10.10.50", RedirectIPv6: "fd00::50"}, "")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	addr := startGuard(t, srv)
+	m := query(t, "udp", addr, "api.trackingwidgets.io.", dnsmessage.TypeAAAA)
+	if len(m.Answers) != 1 {
+		t.Fatalf("expected one AAAA answer, got %+v", m.Answers)
+	}
+	r, ok := m.Answers[0].Body.(*dnsmessage.AAAAResource)
+	if !ok || net.IP(r.AAAA[:]).String() != "fd00::50" {
+		t.Fatalf("unexpected AAAA answer %+v", m.Answers[0])
+	}
+	if _, err := New("", testPolicy(), dnsgen.Options{RedirectIPv6: "10.0.0.1"}, ""); err == nil {
+		t.Fatalf("expected error for IPv4 literal in redirect-ipv6")
+	}
+}
+
+func TestScopedResponder(t *testing.T) {
+	p := testPolicy()
+	p.Records[0].Scopes = []string{"students"}
+	if _, err := New("", p, dnsgen.Options{RedirectIPv4: "10.0.0.1", Scope: "visitors"}, ""); err == nil {
+		t.Fatalf("expected error for unknown scope")
+	}
+	if _, err := New("", p, dnsgen.Options{RedirectIPv4: "10.0.0.1", Scope: "staff"}, ""); err == nil {
+		t.Fatalf("expected error: staff is not declared by any record")
+	}
+	p.Records[1].Scopes = []string{"staff"}
+	srv, err := New("", p, dnsgen.Options{RedirectIPv4: "10.0.0.1", Scope: "staff"}, "")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	q := dnsmessage.Question{Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}
+	if _, ok := srv.answer("exampletool.com", q); ok {
+		t.Fatalf("students-only record should not be answered for staff")
+	}
+	if _, ok := srv.answer("api.trackingwidgets.io", q); !ok {
+		t.Fatalf("staff record should be answered")
+	}
+}
@@ -0,0 +1,250 @@
+// Package hits keeps privacy-preserving daily counts of explain page views. A hit is keyed
+// only by (date, domain, classification): no client addresses, user agents or times of day
+// are recorded, and the domain is the policy record that matched, not the full host name.
+//
+// Layout under <cacheDir>/hits:
+//
+//	YYYY-MM-DD.json   one Summary per local calendar day, pruned after the retention period
+package hits
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DateLayout is the format of summary dates and file names.
+const DateLayout = "2006-01-02"
+
+// MaxEntriesPerDay bounds the distinct (domain, classification) pairs kept for one day;
+// further pairs are counted in Summary.Dropped instead.
+const MaxEntriesPerDay = 10000
+
+// ErrNoData reports that no hits were recorded for the requested date.
+var ErrNoData = errors.New("no hits recorded for that date")
+
+// Entry is the count for one domain and classification on one day.
+type Entry struct {
+	Domain         string `json:"domain"`
+	Classification string `json:"classification"`
+	Count          int    `json:"count"`
+}
+
+// Summary is one day's counts, most-hit first. PolicyVersion is the version active at the
+// day's latest hit.
+type Summary struct {
+	Date          string  `json:"date"`
+	PolicyVersion string  `json:"policy_version"`
+	Entries       []Entry `json:"entries"`
+	Dropped       int     `json:"dropped,omitempty"`
+}
+
+type entryKey struct{ domain, class string }
+
+type day struct {
+	version string
+	counts  map[entryKey]int
+	dropped int
+	dirty   bool
+}
+
+// Aggregator counts hits in memory and writes them to daily files on Flush. It is safe for
+// concurrent use.
+type Aggregator struct {
+	dir       string
+	retention int
+	now       func() time.Time
+
+	mu   sync.Mutex
+	days map[string]*day
+}
+
+// Open prepares the hits directory under cacheDir (default "cache") and loads today's file
+// so counts survive a restart. Flush deletes files older than retentionDays days; 0 keeps
+// them forever.
+func Open(cacheDir string, retentionDays int) (*Aggregator, error) {
+	if cacheDir == "" {
+		cacheDir = "cache"
+	}
+	a := &Aggregator{dir: filepath.Join(cacheDir, "hits"), retention: retentionDays, now: time.Now, days: map[string]*day{}}
+	if err := os.MkdirAll(a.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create hits dir: %w", err)
+	}
+	today := a.now().Format(DateLayout)
+	s, err := a.readFile(today)
+	if errors.Is(err, ErrNoData) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	d := &day{version: s.PolicyVersion, counts: map[entryKey]int{}, dropped: s.Dropped}
+	for _, e := range s.Entries {
+		d.counts[entryKey{e.Domain, e.Classification}] += e.Count
+	}
+	a.days[today] = d
+	return a, nil
+}
+
+// Dir returns the hits directory.
+func (a *Aggregator) Dir() string { return a.dir }
+
+// Record counts one hit for domain under classification on today's date.
+func (a *Aggregator) Record(domain, classification, policyVersion string) {
+	date := a.now().Format(DateLayout)
+	k := entryKey{strings.ToLower(strings.TrimSpace(domain)), classification}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	d := a.days[date]
+	if d == nil {
+		d = &day{counts: map[entryKey]int{}}
+		a.days[date] = d
+	}
+	d.version, d.dirty = policyVersion, true
+	if _, ok := d.counts[k]; !ok && len(d.counts) >= MaxEntriesPerDay {
+		d.dropped++
+		return
+	}
+	d.counts[k]++
+}
+
+// Flush writes every day with new hits to disk, forgets days before today once written,
+// and deletes files past the retention period.
+func (a *Aggregator) Flush() error {
+	now := a.now()
+	today := now.Format(DateLayout)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var errs []error
+	for date, d := range a.days {
+		if d.dirty {
+			if err := a.writeFile(summarize(date, d)); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			d.dirty = false
+		}
+		if date != today {
+			delete(a.days, date)
+		}
+	}
+	if err := a.prune(now); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Summary returns the counts for date (YYYY-MM-DD), including hits not yet flushed.
+// It returns ErrNoData when nothing was recorded that day.
+func (a *Aggregator) Summary(date string) (Summary, error) {
+	if _, err := time.Parse(DateLayout, date); err != nil {
+		return Summary{}, fmt.Errorf("invalid date %q (want YYYY-MM-DD)", date)
+	}
+	a.mu.Lock()
+	if d, ok := a.days[date]; ok {
+		s := summarize(date, d)
+		a.mu.Unlock()
+		return s, nil
+	}
+	a.mu.Unlock()
+	return a.readFile(date)
+}
+
+// ReadSummary returns the flushed counts for date from the hits directory under cacheDir
+// (default "cache") without creating or changing anything there, for reading the files of
+// a server that may be running. It returns ErrNoData when nothing was recorded that day.
+func ReadSummary(cacheDir, date string) (Summary, error) {
+	if cacheDir == "" {
+		cacheDir = "cache"
+	}
+	a := &Aggregator{dir: filepath.Join(cacheDir, "hits")}
+	if _, err := time.Parse(DateLayout, date); err != nil {
+		return Summary{}, fmt.Errorf("invalid date %q (want YYYY-MM-DD)", date)
+	}
+	return a.readFile(date)
+}
+
+func summarize(date string, d *day) Summary {
+	s := Summary{Date: date, PolicyVersion: d.version, Entries: make([]Entry, 0, len(d.counts)), Dropped: d.dropped}
+	for k, n := range d.counts {
+		s.Entries = append(s.Entries, Entry{Domain: k.domain, Classification: k.class, Count: n})
+	}
+	sort.Slice(s.Entries, func(i, j int) bool {
+		a, b := s.Entries[i], s.Entries[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		return a.Classification < b.Classification
+	})
+	return s
+}
+
+func (a *Aggregator) path(date string) string {
+	return filepath.Join(a.dir, date+".json")
+}
+
+func (a *Aggregator) readFile(date string) (Summary, error) {
+	b, err := os.ReadFile(a.path(date))
+	if errors.Is(err, os.ErrNotExist) {
+		return Summary{}, ErrNoData
+	}
+	if err != nil {
+		return Summary{}, fmt.Errorf("read hits: %w", err)
+	}
+	var s Summary
+	if err := json.Unmarshal(b, &s); err != nil {
+		return Summary{}, fmt.Errorf("decode hits %s: %w", date, err)
+	}
+	if s.Entries == nil {
+		s.Entries = []Entry{}
+	}
+	return s, nil
+}
+
+func (a *Aggregator) writeFile(s Summary) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("encode hits: %w", err)
+	}
+	p := a.path(s.Date)
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("write hits: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return fmt.Errorf("write hits: %w", err)
+	}
+	return nil
+}
+
+// prune deletes day files older than the retention period.
+func (a *Aggregator) prune(now time.Time) error {
+	if a.retention <= 0 {
+		return nil
+	}
+	cutoff := now.AddDate(0, 0, -a.retention).Format(DateLayout)
+	files, err := filepath.Glob(filepath.Join(a.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, f := range files {
+		date := strings.TrimSuffix(filepath.Base(f), ".json")
+		if _, err := time.Parse(DateLayout, date); err != nil || date >= cutoff {
+			continue
+		}
+		if err := os.Remove(f); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("prune hits: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
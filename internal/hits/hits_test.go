@@ -0,0 +1,131 @@
+package hits
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordSummaryAndReopen(t *testing.T) {
+	dir := t.TempDir()
+	a, err := Open(dir, 30)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	a.Record("ExampleTool.com", "NO_DPA", "0.1.0")
+	a.Record("exampletool.com", "NO_DPA", "0.1.0")
+	a.Record("*.trackingwidgets.io", "EXPIRED_DPA", "0.2.0")
+	today := time.Now().Format(DateLayout)
+	s, err := a.Summary(today)
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	if s.PolicyVersion != "0.2.0" || len(s.Entries) != 2 || s.Entries[0] != (Entry{"exampletool.com", "NO_DPA", 2}) {
+		t.Fatalf("unexpected summary: %#v", s)
+	}
+	if err := a.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	// A restart picks up today's counts and keeps adding to them.
+	b, err := Open(dir, 30)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	b.Record("exampletool.com", "NO_DPA", "0.2.0")
+	s, _ = b.Summary(today)
+	if s.Entries[0].Count != 3 || s.Entries[1].Count != 1 {
+		t.Fatalf("counts not carried over: %#v", s)
+	}
+	if _, err := b.Summary("2001-01-01"); !errors.Is(err, ErrNoData) {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+	if _, err := b.Summary("yesterday"); err == nil {
+		t.Fatalf("expected invalid date error")
+	}
+}
+
+func TestFlushRollsDaysAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	a, err := Open(dir, 7)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	now := time.Date(2025, 9, 10, 23, 59, 0, 0, time.Local)
+	a.now = func() time.Time { return now }
+	stale := filepath.Join(a.Dir(), "2025-09-01.json")
+	kept := filepath.Join(a.Dir(), "2025-09-04.json")
+	for _, f := range []string{stale, kept} {
+		if err := os.WriteFile(f, []byte(`{"date":"x","entries":[]}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.Record("exampletool.com", "NO_DPA", "0.1.0")
+	now = now.Add(2 * time.Minute) // past midnight
+	a.Record("exampletool.com", "NO_DPA", "0.1.0")
+	a.Record("exampletool.com", "NO_DPA", "0.1.0")
+	if err := a.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if _, ok := a.days["2025-09-10"]; ok {
+		t.Fatalf("previous day should be dropped from memory once written")
+	}
+	s, err := a.Summary("2025-09-10")
+	if err != nil || s.Entries[0].Count != 1 {
+		t.Fatalf("previous day from disk: %v %#v", err, s)
+	}
+	s, err = a.Summary("2025-09-11")
+	if err != nil || s.Entries[0].Count != 2 {
+		t.Fatalf("today: %v %#v", err, s)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("file past retention should be pruned: %v", err)
+	}
+	if _, err := os.Stat(kept); err != nil {
+		t.Fatalf("file within retention should be kept: %v", err)
+	}
+}
+
+func TestEntryLimitCountsDropped(t *testing.T) {
+	a, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	for i := 0; i < MaxEntriesPerDay; i++ {
+		a.Record(fmt.Sprintf("d%d.example", i), "NO_DPA", "v")
+	}
+	a.Record("one-too-many.example", "NO_DPA", "v")
+	a.Record("d0.example", "NO_DPA", "v") // existing entries keep counting
+	s, _ := a.Summary(time.Now().Format(DateLayout))
+	if len(s.Entries) != MaxEntriesPerDay || s.Dropped != 1 || s.Entries[0] != (Entry{"d0.example", "NO_DPA", 2}) {
+		t.Fatalf("entries=%d dropped=%d", len(s.Entries), s.Dropped)
+	}
+}
+
+func TestReadSummaryCreatesNothing(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	if _, err := ReadSummary(dir, "2025-08-01"); !errors.Is(err, ErrNoData) {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+	if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("ReadSummary should not create %s: %v", dir, err)
+	}
+	a, err := Open(dir, 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	a.Record("exampletool.com", "NO_DPA", "0.1.0")
+	if err := a.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	s, err := ReadSummary(dir, time.Now().Format(DateLayout))
+	if err != nil || len(s.Entries) != 1 || s.Entries[0].Count != 1 {
+		t.Fatalf("unexpected summary: %v %#v", err, s)
+	}
+	if _, err := ReadSummary(dir, "today"); err == nil {
+		t.Fatalf("expected invalid date error")
+	}
+}
@@ -0,0 +1,319 @@
+// Package i18n holds the message catalogs for the explain and landing pages and picks the
+// language for a request. English, Spanish, Somali and Nepali are embedded; a directory of
+// <tag>.json files (see Load) adds languages or replaces individual strings.
+//
+// A catalog file looks like:
+//
+//	{
+//	  "name": "Español",
+//	  "dir": "ltr",
+//	  "messages": {"access_to": "El acceso a", ...},
+//	  "classifications": {"NO_DPA": {"label": "...", "description": "..."}}
+//	}
+//
+// Messages missing from a catalog fall back to English. Classification texts are only taken
+// from non-English catalogs; in English the policy taxonomy (including a district's own labels)
+// is shown as is.
+package i18n
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLang is the fallback language; its catalog must define every message.
+const DefaultLang = "en"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// tagPattern accepts lowercase BCP 47 style tags such as "es", "so", "ne" or "pt-br".
+var tagPattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8}){0,2}$`)
+
+// NormalizeTag lowercases a language tag and turns underscores into hyphens ("pt_BR" -> "pt-br").
+func NormalizeTag(s string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), "_", "-"))
+}
+
+// ValidTag reports whether s is a well-formed, normalized language tag.
+func ValidTag(s string) bool { return tagPattern.MatchString(s) }
+
+// ClassText is the translated label and description of a classification key.
+type ClassText struct {
+	Label       string `json:"label,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Catalog is the text of one language. Catalogs returned by a Bundle have Messages merged
+// with the English fallback.
+type Catalog struct {
+	Lang            string               `json:"-"`
+	Name            string               `json:"name"`
+	Dir             string               `json:"dir,omitempty"` // ltr (default) or rtl
+	Messages        map[string]string    `json:"messages"`
+	Classifications map[string]ClassText `json:"classifications,omitempty"`
+}
+
+// Classification returns the translated text for key, if the catalog has one.
+func (c *Catalog) Classification(key string) (ClassText, bool) {
+	t, ok := c.Classifications[key]
+	return t, ok
+}
+
+// Format returns message id with {domain} replaced by domain.
+func (c *Catalog) Format(id, domain string) string {
+	return strings.ReplaceAll(c.Messages[id], "{domain}", domain)
+}
+
+// Language names an available catalog.
+type Language struct {
+	Tag  string `json:"tag"`
+	Name string `json:"name"`
+}
+
+// Bundle is a set of catalogs keyed by language tag. It is immutable once built.
+type Bundle struct {
+	cats  map[string]*Catalog
+	langs []Language
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultBundle *Bundle
+)
+
+// Default returns the embedded catalogs.
+func Default() *Bundle {
+	defaultOnce.Do(func() {
+		b, err := Load("")
+		if err != nil {
+			panic(fmt.Sprintf("embedded locales: %v", err))
+		}
+		defaultBundle = b
+	})
+	return defaultBundle
+}
+
+// Load returns the embedded catalogs overlaid by the <tag>.json files in dir (none when dir is
+// empty). An override file only needs the strings it changes; a file for a new tag adds a
+// language and should set name.
+func Load(dir string) (*Bundle, error) {
+	raw := map[string]*Catalog{}
+	embedded, _ := fs.Glob(localeFS, "locales/*.json")
+	for _, name := range embedded {
+		data, _ := localeFS.ReadFile(name)
+		if err := overlay(raw, name, data); err != nil {
+			return nil, err
+		}
+	}
+	if dir != "" {
+		files, err := fs.Glob(os.DirFS(dir), "*.json")
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("%s: no <lang>.json catalogs", dir)
+		}
+		for _, name := range files {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				return nil, err
+			}
+			if err := overlay(raw, name, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return build(raw)
+}
+
+// overlay merges the catalog file name (data) into raw.
+func overlay(raw map[string]*Catalog, name string, data []byte) error {
+	tag := NormalizeTag(strings.TrimSuffix(path.Base(name), ".json"))
+	if !ValidTag(tag) {
+		return fmt.Errorf("%s: file name is not a language tag", name)
+	}
+	var c Catalog
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	switch c.Dir {
+	case "", "ltr", "rtl":
+	default:
+		return fmt.Errorf("%s: dir must be ltr or rtl", name)
+	}
+	cur := raw[tag]
+	if cur == nil {
+		cur = &Catalog{Lang: tag, Messages: map[string]string{}, Classifications: map[string]ClassText{}}
+		raw[tag] = cur
+	}
+	if c.Name != "" {
+		cur.Name = c.Name
+	}
+	if c.Dir != "" {
+		cur.Dir = c.Dir
+	}
+	for k, v := range c.Messages {
+		cur.Messages[k] = v
+	}
+	for k, v := range c.Classifications {
+		t := cur.Classifications[k]
+		if v.Label != "" {
+			t.Label = v.Label
+		}
+		if v.Description != "" {
+			t.Description = v.Description
+		}
+		cur.Classifications[k] = t
+	}
+	return nil
+}
+
+func build(raw map[string]*Catalog) (*Bundle, error) {
+	def, ok := raw[DefaultLang]
+	if !ok {
+		return nil, errors.New("missing " + DefaultLang + " catalog")
+	}
+	b := &Bundle{cats: map[string]*Catalog{}}
+	for tag, c := range raw {
+		merged := &Catalog{Lang: tag, Name: c.Name, Dir: c.Dir, Messages: map[string]string{}, Classifications: c.Classifications}
+		if merged.Name == "" {
+			merged.Name = tag
+		}
+		if merged.Dir == "" {
+			merged.Dir = "ltr"
+		}
+		for k, v := range def.Messages {
+			merged.Messages[k] = v
+		}
+		for k, v := range c.Messages {
+			merged.Messages[k] = v
+		}
+		if tag == DefaultLang {
+			merged.Classifications = nil
+		}
+		b.cats[tag] = merged
+		b.langs = append(b.langs, Language{Tag: tag, Name: merged.Name})
+	}
+	// English first, then by tag, so switchers list languages in a stable order.
+	sort.Slice(b.langs, func(i, j int) bool {
+		if (b.langs[i].Tag == DefaultLang) != (b.langs[j].Tag == DefaultLang) {
+			return b.langs[i].Tag == DefaultLang
+		}
+		return b.langs[i].Tag < b.langs[j].Tag
+	})
+	return b, nil
+}
+
+// Languages lists the available catalogs, English first.
+func (b *Bundle) Languages() []Language { return append([]Language(nil), b.langs...) }
+
+// Catalog returns the catalog for lang, or the English one when lang is not available.
+func (b *Bundle) Catalog(lang string) *Catalog {
+	if c, ok := b.cats[b.match(lang)]; ok {
+		return c
+	}
+	return b.cats[DefaultLang]
+}
+
+// Negotiate picks the language for a request: explicit (the ?lang= parameter) when it names an
+// available catalog, else the best Accept-Language match, else DefaultLang.
+func (b *Bundle) Negotiate(explicit, acceptLanguage string) string {
+	if tag := b.match(explicit); tag != "" {
+		return tag
+	}
+	for _, want := range parseAcceptLanguage(acceptLanguage) {
+		if tag := b.match(want); tag != "" {
+			return tag
+		}
+	}
+	return DefaultLang
+}
+
+// match returns the available tag for want: an exact match, then its base language ("es-mx"
+// -> "es"), then a regional catalog of the same language ("pt" -> "pt-br"). "" when none.
+func (b *Bundle) match(want string) string {
+	want = NormalizeTag(want)
+	if want == "" {
+		return ""
+	}
+	if _, ok := b.cats[want]; ok {
+		return want
+	}
+	base, _, _ := strings.Cut(want, "-")
+	if _, ok := b.cats[base]; ok {
+		return base
+	}
+	for _, l := range b.langs {
+		if strings.HasPrefix(l.Tag, base+"-") {
+			return l.Tag
+		}
+	}
+	return ""
+}
+
+// parseAcceptLanguage returns the tags of an Accept-Language header by descending quality,
+// dropping "*" and q=0 entries.
+func parseAcceptLanguage(h string) []string {
+	type pref struct {
+		tag string
+		q   float64
+	}
+	var prefs []pref
+	for _, part := range strings.Split(h, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(f), "q="); ok {
+				if q, _ = strconv.ParseFloat(strings.TrimSpace(v), 64); q > 1 {
+					q = 1
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		prefs = append(prefs, pref{tag, q})
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+	out := make([]string, len(prefs))
+	for i, p := range prefs {
+		out[i] = p.tag
+	}
+	return out
+}
+
+// Pick returns the entry of m (keyed by language tag) for lang: an exact match, then the base
+// language. Policy records use it to choose a translated rationale.
+func Pick(m map[string]string, lang string) (string, bool) {
+	if len(m) == 0 {
+		return "", false
+	}
+	lang = NormalizeTag(lang)
+	if v, ok := m[lang]; ok && v != "" {
+		return v, true
+	}
+	base, _, _ := strings.Cut(lang, "-")
+	if v, ok := m[base]; ok && v != "" {
+		return v, true
+	}
+	return "", false
+}
@@ -0,0 +1,121 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	b := Default()
+	cases := []struct{ explicit, accept, want string }{
+		{"", "", "en"},
+		{"", "es-MX,es;q=0.9,en;q=0.8", "es"},
+		{"", "fr-FR, so;q=0.7, es;q=0.5", "so"},
+		{"", "ne-NP", "ne"},
+		{"", "es;q=0, ne;q=0.2, *;q=0.9", "ne"},
+		{"", "fr, de", "en"},
+		{"so", "es", "so"},
+		{"SO_so", "", "so"},
+		{"xx", "es", "es"},
+		{"", "en;q=0.4, es;level=1;q=0.6", "es"},
+	}
+	for _, c := range cases {
+		if got := b.Negotiate(c.explicit, c.accept); got != c.want {
+			t.Errorf("Negotiate(%q, %q) = %q, want %q", c.explicit, c.accept, got, c.want)
+		}
+	}
+}
+
+func TestEmbeddedCatalogsAreComplete(t *testing.T) {
+	b := Default()
+	en := b.Catalog("en")
+	if _, ok := en.Classification("NO_DPA"); ok {
+		t.Fatalf("English catalog must not override the policy taxonomy")
+	}
+	langs := b.Languages()
+	if len(langs) < 4 || langs[0].Tag != "en" {
+		t.Fatalf("unexpected languages %v", langs)
+	}
+	raw, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range langs[1:] {
+		c := raw.Catalog(l.Tag)
+		if c.Lang != l.Tag || c.Name == l.Tag {
+			t.Errorf("%s: missing name", l.Tag)
+		}
+		for _, key := range []string{"NO_DPA", "PENDING_REVIEW", "EXPIRED_DPA", "LEGAL_HOLD", "OTHER"} {
+			if ct, ok := c.Classification(key); !ok || ct.Label == "" || ct.Description == "" {
+				t.Errorf("%s: classification %s not translated", l.Tag, key)
+			}
+		}
+	}
+	if got := b.Catalog("es").Format("explain_title", "tool.com"); got != "Bloqueado: tool.com" {
+		t.Fatalf("Format: %q", got)
+	}
+	if got := b.Catalog("zz").Lang; got != "en" {
+		t.Fatalf("unknown language should fall back to English, got %s", got)
+	}
+}
+
+func TestLoadOverrideDirectory(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("es.json", `{"messages":{"contact":"Llame a la oficina del distrito."},"classifications":{"NO_DPA":{"label":"Sin DPA"},"PARENT_CONSENT":{"label":"Requiere permiso"}}}`)
+	write("pt_BR.json", `{"name":"Português","messages":{"access_to":"O acesso a"}}`)
+	b, err := Load(dir)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	es := b.Catalog("es")
+	if es.Messages["contact"] != "Llame a la oficina del distrito." || es.Messages["access_to"] != "El acceso a" {
+		t.Fatalf("override not merged: %v", es.Messages)
+	}
+	if ct, _ := es.Classification("NO_DPA"); ct.Label != "Sin DPA" || !strings.Contains(ct.Description, "proveedor") {
+		t.Fatalf("classification override should keep the embedded description: %+v", ct)
+	}
+	if ct, _ := es.Classification("PARENT_CONSENT"); ct.Label != "Requiere permiso" {
+		t.Fatalf("district classification not added: %+v", ct)
+	}
+	pt := b.Catalog(b.Negotiate("", "pt"))
+	if pt.Lang != "pt-br" || pt.Messages["access_to"] != "O acesso a" || pt.Messages["is_restricted"] != "is restricted" {
+		t.Fatalf("new language should fall back to English per message: %s %v", pt.Lang, pt.Messages)
+	}
+	if Default().Catalog("es").Messages["contact"] == "Llame a la oficina del distrito." {
+		t.Fatalf("override leaked into the embedded bundle")
+	}
+
+	for name, body := range map[string]string{
+		"english.json": `{}`,
+		"fr.json":      `{"mesages":{}}`,
+		"de.json":      `{"dir":"up"}`,
+	} {
+		bad := t.TempDir()
+		if err := os.WriteFile(filepath.Join(bad, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(bad); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: expected error naming the file, got %v", name, err)
+		}
+	}
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Errorf("expected error for a directory without catalogs")
+	}
+}
+
+func TestPick(t *testing.T) {
+	m := map[string]string{"es": "Sin acuerdo", "pt-br": "Sem acordo"}
+	for lang, want := range map[string]string{"es": "Sin acuerdo", "es-MX": "Sin acuerdo", "pt-BR": "Sem acordo", "pt": "", "so": ""} {
+		if got, _ := Pick(m, lang); got != want {
+			t.Errorf("Pick(%q) = %q, want %q", lang, got, want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Package journal persists accepted policy snapshots so operators can see what
+// changed over time and re-activate an earlier policy.
+//
+// Layout under <cacheDir>/journal:
+//
+//	history.jsonl            one Entry per accepted policy, oldest first
+//	snapshots/<content>.json  full policy as accepted, keyed by a hash of the whole snapshot
+//
+// The canonical hash only covers the fields that decide blocking, so two snapshots may share
+// it (e.g. after an expires or tags edit); the content key keeps both.
+package journal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+)
+
+// ErrNotFound is returned (wrapped) by Load when ref names no single snapshot: it is unknown,
+// too short, or an ambiguous prefix.
+var ErrNotFound = errors.New("snapshot not found")
+
+// Entry describes one accepted policy snapshot.
+type Entry struct {
+	Hash     string    `json:"hash"`
+	Snapshot string    `json:"snapshot,omitempty"` // content hash naming the snapshot file; empty in older journals, which used Hash
+	Time     time.Time `json:"time"`
+	Source   string    `json:"source"`
+	Version  string    `json:"version"`
+	Records  int       `json:"records"`
+}
+
+// Journal records snapshots on disk. It is safe for concurrent use.
+type Journal struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// Open prepares the journal directory under cacheDir (default "cache").
+func Open(cacheDir string) (*Journal, error) {
+	if cacheDir == "" {
+		cacheDir = "cache"
+	}
+	dir := filepath.Join(cacheDir, "journal")
+	if err := os.MkdirAll(filepath.Join(dir, "snapshots"), 0o755); err != nil {
+		return nil, fmt.Errorf("create journal dir: %w", err)
+	}
+	return &Journal{dir: dir}, nil
+}
+
+// Dir returns the journal directory.
+func (j *Journal) Dir() string { return j.dir }
+
+// Record stores p (if its snapshot is not already on disk) and appends a history entry.
+// Consecutive acceptances of identical content are collapsed: the entry is not repeated
+// and added is false.
+func (j *Journal) Record(p *policy.Policy, source string) (e Entry, added bool, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	b, err := json.Marshal(p)
+	if err != nil {
+		return e, false, fmt.Errorf("encode snapshot: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	e = Entry{Hash: p.CanonicalHash(), Snapshot: hex.EncodeToString(sum[:]), Time: time.Now().UTC(), Source: source, Version: p.Version, Records: len(p.Records)}
+	snap := j.snapshotPath(e.Snapshot)
+	if _, statErr := os.Stat(snap); errors.Is(statErr, os.ErrNotExist) {
+		tmp := snap + ".tmp"
+		if werr := os.WriteFile(tmp, b, 0o644); werr != nil {
+			return e, false, fmt.Errorf("write snapshot: %w", werr)
+		}
+		if rerr := os.Rename(tmp, snap); rerr != nil {
+			return e, false, fmt.Errorf("write snapshot: %w", rerr)
+		}
+	}
+	hist, err := j.readHistory()
+	if err != nil {
+		return e, false, err
+	}
+	if n := len(hist); n > 0 && hist[n-1].snapshotID() == e.Snapshot {
+		return hist[n-1], false, nil
+	}
+	f, err := os.OpenFile(filepath.Join(j.dir, "history.jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return e, false, fmt.Errorf("open history: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	line, _ := json.Marshal(e)
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return e, false, fmt.Errorf("append history: %w", err)
+	}
+	return e, true, nil
+}
+
+// History returns all entries, oldest first.
+func (j *Journal) History() ([]Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.readHistory()
+}
+
+// Load returns the snapshot named by ref and its canonical hash. ref is a snapshot content
+// hash or a canonical hash, or a unique prefix of at least 6 characters of either; a
+// canonical hash shared by several snapshots selects the most recently accepted one.
+func (j *Journal) Load(ref string) (*policy.Policy, string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	ref = strings.ToLower(strings.TrimSpace(ref))
+	if ref == "" {
+		return nil, "", fmt.Errorf("%w: snapshot hash required", ErrNotFound)
+	}
+	hist, err := j.readHistory()
+	if err != nil {
+		return nil, "", err
+	}
+	e, err := resolve(hist, ref)
+	if err != nil {
+		return nil, "", err
+	}
+	b, err := os.ReadFile(j.snapshotPath(e.snapshotID()))
+	if err != nil {
+		return nil, "", fmt.Errorf("read snapshot: %w", err)
+	}
+	var p policy.Policy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, "", fmt.Errorf("decode snapshot: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, "", fmt.Errorf("snapshot %s: %w", e.snapshotID(), err)
+	}
+	return &p, e.Hash, nil
+}
+
+// Latest returns the most recently accepted snapshot, or nil when the journal is empty.
+func (j *Journal) Latest() (*policy.Policy, error) {
+	j.mu.Lock()
+	hist, err := j.readHistory()
+	j.mu.Unlock()
+	if err != nil || len(hist) == 0 {
+		return nil, err
+	}
+	p, _, err := j.Load(hist[len(hist)-1].snapshotID())
+	return p, err
+}
+
+// resolve picks the newest entry matching ref, preferring snapshot ids over canonical hashes.
+func resolve(hist []Entry, ref string) (Entry, error) {
+	exact := func(e Entry) (string, bool) { return e.snapshotID(), e.Snapshot == ref || e.Hash == ref }
+	bySnapshot := func(e Entry) (string, bool) { return e.snapshotID(), strings.HasPrefix(e.Snapshot, ref) }
+	byHash := func(e Entry) (string, bool) { return e.Hash, strings.HasPrefix(e.Hash, ref) }
+	for i, match := range []func(Entry) (string, bool){exact, bySnapshot, byHash} {
+		if i > 0 && len(ref) < 6 {
+			return Entry{}, fmt.Errorf("%w: %s (use at least 6 hash characters)", ErrNotFound, ref)
+		}
+		var found Entry
+		keys := map[string]bool{}
+		for n := len(hist) - 1; n >= 0; n-- {
+			if key, ok := match(hist[n]); ok {
+				if len(keys) == 0 {
+					found = hist[n]
+				}
+				keys[key] = true
+			}
+		}
+		switch {
+		case len(keys) == 1 || (i == 0 && len(keys) > 0):
+			return found, nil
+		case len(keys) > 1:
+			return Entry{}, fmt.Errorf("%w: prefix %s is ambiguous (%d matches)", ErrNotFound, ref, len(keys))
+		}
+	}
+	return Entry{}, fmt.Errorf("%w: %s", ErrNotFound, ref)
+}
+
+// snapshotID names e's snapshot file.
+func (e Entry) snapshotID() string {
+	if e.Snapshot != "" {
+		return e.Snapshot
+	}
+	return e.Hash
+}
+
+func (j *Journal) snapshotPath(hash string) string {
+	return filepath.Join(j.dir, "snapshots", hash+".json")
+}
+
+func (j *Journal) readHistory() ([]Entry, error) {
+	f, err := os.Open(filepath.Join(j.dir, "history.jsonl"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open history: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	var out []Entry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			continue // tolerate a torn final line
+		}
+		out = append(out, e)
+	}
+	return out, sc.Err()
+}
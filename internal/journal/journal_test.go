@@ -0,0 +1,85 @@
+package journal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+)
+
+func testPolicy(domains ...string) *policy.Policy {
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08"}
+	for _, d := range domains {
+		p.Records = append(p.Records, policy.Record{Domain: d, Classification: "NO_DPA", Rationale: "r", LastReview: "2025-08-01", Status: "active"})
+	}
+	return p
+}
+
+func TestRecordHistoryAndLoad(t *testing.T) {
+	j, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	a := testPolicy("exampletool.com", "other.example.org")
+	b := testPolicy("exampletool.com")
+	if _, added, err := j.Record(a, "file"); err != nil || !added {
+		t.Fatalf("record a: added=%v err=%v", added, err)
+	}
+	if _, added, err := j.Record(a, "file"); err != nil || added {
+		t.Fatalf("repeat of current hash should not add an entry: added=%v err=%v", added, err)
+	}
+	if _, added, err := j.Record(b, "csv"); err != nil || !added {
+		t.Fatalf("record b: added=%v err=%v", added, err)
+	}
+	if _, added, err := j.Record(a, "rollback"); err != nil || !added {
+		t.Fatalf("record a again after b: added=%v err=%v", added, err)
+	}
+	hist, err := j.History()
+	if err != nil || len(hist) != 3 {
+		t.Fatalf("history: %v %#v", err, hist)
+	}
+	if hist[0].Hash != a.CanonicalHash() || hist[1].Source != "csv" || hist[2].Source != "rollback" || hist[0].Records != 2 {
+		t.Fatalf("unexpected history: %#v", hist)
+	}
+	p, full, err := j.Load(a.CanonicalHash()[:8])
+	if err != nil || full != a.CanonicalHash() || len(p.Records) != 2 {
+		t.Fatalf("load by prefix: %v %s %#v", err, full, p)
+	}
+	if _, _, err := j.Load("abc"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected short prefix to be rejected")
+	}
+	if _, _, err := j.Load("ffffffffffff"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected unknown hash error, got %v", err)
+	}
+}
+
+func TestRecordKeepsEditsOutsideCanonicalHash(t *testing.T) {
+	j, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	a := testPolicy("exampletool.com")
+	b := testPolicy("exampletool.com")
+	b.Records[0].Expires = "2030-01-01"
+	b.Records[0].Tags = []string{"math"}
+	if a.CanonicalHash() != b.CanonicalHash() {
+		t.Fatalf("test needs an edit the canonical hash ignores")
+	}
+	ea, _, err := j.Record(a, "file")
+	if err != nil {
+		t.Fatalf("record a: %v", err)
+	}
+	eb, added, err := j.Record(b, "file")
+	if err != nil || !added || eb.Snapshot == ea.Snapshot {
+		t.Fatalf("edited content should get its own entry: added=%v err=%v %#v", added, err, eb)
+	}
+	// The canonical hash selects the newest content; the snapshot id selects either.
+	p, full, err := j.Load(a.CanonicalHash())
+	if err != nil || full != a.CanonicalHash() || p.Records[0].Expires != "2030-01-01" {
+		t.Fatalf("load by canonical hash: %v %s %#v", err, full, p)
+	}
+	p, _, err = j.Load(ea.Snapshot[:10])
+	if err != nil || p.Records[0].Expires != "" || len(p.Records[0].Tags) != 0 {
+		t.Fatalf("load by snapshot id: %v %#v", err, p)
+	}
+}
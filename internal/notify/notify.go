@@ -0,0 +1,186 @@
+// Package notify delivers operational events (failing refreshes, policy changes, expiring
+// records, review requests) to outbound webhooks and SMTP mail. Events are queued and sent
+// by a background worker with retries and exponential backoff, so callers never block on a
+// slow receiver.
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	RefreshFailing   = "refresh.failing"
+	PolicyChanged    = "policy.changed"
+	RecordsExpiring  = "records.expiring"
+	RequestSubmitted = "request.submitted"
+)
+
+// Event is one notification. Text is a one-line human summary; chat webhooks (Slack, Teams)
+// display it as the message.
+type Event struct {
+	Type string                 `json:"type"`
+	Time time.Time              `json:"time"`
+	Text string                 `json:"text"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// Sink delivers events to one receiver.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, e Event) error
+}
+
+// permanentError marks a failure that retrying cannot fix (e.g. a 4xx answer).
+type permanentError struct{ err error }
+
+func (p permanentError) Error() string { return p.err.Error() }
+func (p permanentError) Unwrap() error { return p.err }
+
+// Permanent wraps err so the dispatcher does not retry it.
+func Permanent(err error) error { return permanentError{err} }
+
+// IsPermanent reports whether err was wrapped by Permanent.
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+// Defaults for Options fields left zero.
+const (
+	DefaultRetries   = 3
+	DefaultBackoff   = 2 * time.Second
+	DefaultQueueSize = 100
+	DefaultTimeout   = 10 * time.Second
+)
+
+// Options configure a Dispatcher.
+type Options struct {
+	Sinks []Sink
+	// Retries after the first attempt; negative means none.
+	Retries int
+	// Backoff before the first retry, doubled for each further retry.
+	Backoff time.Duration
+	// QueueSize bounds events waiting for delivery; Notify drops events beyond it.
+	QueueSize int
+	// Timeout bounds a single delivery attempt.
+	Timeout time.Duration
+	// OnError is called (from the worker) when a sink gives up on an event.
+	OnError func(sink string, e Event, err error)
+}
+
+// Dispatcher queues events and delivers each to every sink. A nil *Dispatcher drops
+// everything, so callers need not check whether notifications are configured.
+type Dispatcher struct {
+	opts   Options
+	queue  chan Event
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// New starts a dispatcher for o.Sinks. Call Close to flush and stop it.
+func New(o Options) *Dispatcher {
+	if o.Retries == 0 {
+		o.Retries = DefaultRetries
+	}
+	if o.Retries < 0 {
+		o.Retries = 0
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = DefaultBackoff
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = DefaultQueueSize
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{opts: o, queue: make(chan Event, o.QueueSize), ctx: ctx, cancel: cancel, done: make(chan struct{})}
+	go d.run()
+	return d
+}
+
+// Notify queues e for delivery, stamping Time when unset. It never blocks and reports false
+// when the event was dropped (nil or closed dispatcher, or a full queue).
+func (d *Dispatcher) Notify(e Event) bool {
+	if d == nil {
+		return false
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return false
+	}
+	select {
+	case d.queue <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops accepting events and waits until the queued ones are delivered or ctx ends, in
+// which case pending retries are abandoned.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-d.done
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	defer d.cancel()
+	for e := range d.queue {
+		for _, s := range d.opts.Sinks {
+			if err := d.deliver(s, e); err != nil && d.opts.OnError != nil {
+				d.opts.OnError(s.Name(), e, err)
+			}
+		}
+	}
+}
+
+// deliver sends e to s, retrying transient failures with exponential backoff.
+func (d *Dispatcher) deliver(s Sink, e Event) error {
+	wait := d.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(d.ctx, d.opts.Timeout)
+		err := s.Send(ctx, e)
+		cancel()
+		if err == nil || IsPermanent(err) || attempt >= d.opts.Retries {
+			return err
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-d.ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		wait *= 2
+	}
+}
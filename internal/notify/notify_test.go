@@ -0,0 +1,209 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collect starts an httptest receiver that answers with codes in order (then 200) and sends
+// every request it accepts on the returned channel.
+func collect(t *testing.T, codes ...int) (*httptest.Server, chan *http.Request, chan []byte) {
+	t.Helper()
+	reqs, bodies := make(chan *http.Request, 10), make(chan []byte, 10)
+	var mu sync.Mutex
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		code := http.StatusOK
+		if len(codes) > 0 {
+			code, codes = codes[0], codes[1:]
+		}
+		mu.Unlock()
+		w.WriteHeader(code)
+		if code == http.StatusOK {
+			reqs <- r
+			bodies <- b
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts, reqs, bodies
+}
+
+func TestWebhookSignedRetriedAndPermanent(t *testing.T) {
+	ts, reqs, bodies := collect(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	var errs []string
+	var mu sync.Mutex
+	d := New(Options{
+		Sinks:   []Sink{&Webhook{URL: ts.URL + "/hooks/T0KEN", Secret: []byte("s3cret")}},
+		Backoff: time.Millisecond,
+		OnError: func(sink string, e Event, err error) {
+			mu.Lock()
+			errs = append(errs, sink+" "+e.Type+" "+err.Error())
+			mu.Unlock()
+		},
+	})
+	if !d.Notify(Event{Type: PolicyChanged, Text: "Policy changed", Data: map[string]interface{}{"version": "0.2.0"}}) {
+		t.Fatalf("event dropped")
+	}
+	var r *http.Request
+	var body []byte
+	select {
+	case r = <-reqs:
+		body = <-bodies
+	case <-time.After(5 * time.Second):
+		t.Fatalf("webhook not delivered")
+	}
+	if r.Header.Get(HeaderEvent) != PolicyChanged || r.Header.Get(HeaderSignature) != Sign([]byte("s3cret"), r.Header.Get(HeaderTimestamp), body) {
+		t.Fatalf("bad headers: %v", r.Header)
+	}
+	var e Event
+	if err := json.Unmarshal(body, &e); err != nil || e.Text != "Policy changed" || e.Time.IsZero() || e.Data["version"] != "0.2.0" {
+		t.Fatalf("bad body %s: %v", body, err)
+	}
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if d.Notify(Event{Type: PolicyChanged}) {
+		t.Fatalf("closed dispatcher accepted an event")
+	}
+
+	// A 4xx answer is not retried; the error names the host but not the tokenized path.
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusGone) }))
+	defer bad.Close()
+	attempts := 0
+	d = New(Options{
+		Sinks:   []Sink{&Webhook{URL: bad.URL + "/hooks/T0KEN"}, &countingSink{n: &attempts}},
+		Backoff: time.Millisecond,
+		OnError: func(sink string, e Event, err error) {
+			mu.Lock()
+			errs = append(errs, sink+" "+err.Error())
+			mu.Unlock()
+		},
+	})
+	d.Notify(Event{Type: RefreshFailing})
+	_ = d.Close(context.Background())
+	if len(errs) != 2 || !strings.Contains(errs[0], "410") || strings.Contains(errs[0], "T0KEN") || attempts != 4 {
+		t.Fatalf("unexpected errors %q, attempts %d", errs, attempts)
+	}
+}
+
+// countingSink fails every attempt with a retryable error.
+type countingSink struct{ n *int }
+
+func (c *countingSink) Name() string { return "counting" }
+func (c *countingSink) Send(context.Context, Event) error {
+	*c.n++
+	return io.ErrUnexpectedEOF
+}
+
+func TestCloseAbandonsRetriesAtDeadline(t *testing.T) {
+	n := 0
+	d := New(Options{Sinks: []Sink{&countingSink{n: &n}}, Backoff: time.Hour})
+	d.Notify(Event{Type: RecordsExpiring})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := d.Close(ctx); err == nil {
+		t.Fatalf("expected deadline error")
+	}
+	if n != 1 {
+		t.Fatalf("expected one attempt, got %d", n)
+	}
+}
+
+// fakeSMTP accepts one mail per connection and sends the DATA of each on the channel. A
+// first RCPT for reject@ is answered with a permanent 550.
+func fakeSMTP(t *testing.T) (string, chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	mails := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer func() { _ = conn.Close() }()
+				r := bufio.NewReader(conn)
+				say := func(s string) { _, _ = io.WriteString(conn, s+"\r\n") }
+				say("220 fake ESMTP")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					cmd := strings.ToUpper(strings.TrimSpace(line))
+					switch {
+					case strings.HasPrefix(cmd, "EHLO"):
+						say("250-fake\r\n250 8BITMIME")
+					case strings.HasPrefix(cmd, "RCPT TO:<REJECT@"):
+						say("550 no such user")
+					case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"), strings.HasPrefix(cmd, "RSET"), strings.HasPrefix(cmd, "NOOP"):
+						say("250 ok")
+					case cmd == "DATA":
+						say("354 go ahead")
+						var b strings.Builder
+						for {
+							l, err := r.ReadString('\n')
+							if err != nil {
+								return
+							}
+							if l == ".\r\n" {
+								break
+							}
+							b.WriteString(l)
+						}
+						mails <- b.String()
+						say("250 queued")
+					case cmd == "QUIT":
+						say("221 bye")
+						return
+					default:
+						say("502 unknown")
+					}
+				}
+			}(conn)
+		}
+	}()
+	return ln.Addr().String(), mails
+}
+
+func TestSMTPSendsPlainTextMail(t *testing.T) {
+	addr, mails := fakeSMTP(t)
+	m := &SMTP{Addr: addr, From: "guard@school.org", To: []string{"privacy@school.org", "it@school.org"}}
+	e := Event{Type: RequestSubmitted, Time: time.Date(2025, 8, 8, 14, 0, 0, 0, time.UTC), Text: "Review requested\r\nBcc: evil@example.com",
+		Data: map[string]interface{}{"domain": "exampletool.com", "justification": "line one\nline two"}}
+	if err := m.Send(context.Background(), e); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	mail := <-mails
+	for _, want := range []string{
+		"From: guard@school.org\r\n",
+		"To: privacy@school.org, it@school.org\r\n",
+		"Subject: [sb29guard] Review requested  Bcc: evil@example.com\r\n",
+		"X-SB29Guard-Event: request.submitted\r\n",
+		"domain: exampletool.com\r\n",
+		"justification: line one\r\n  line two\r\n",
+	} {
+		if !strings.Contains(mail, want) {
+			t.Fatalf("mail missing %q:\n%s", want, mail)
+		}
+	}
+	m.To = []string{"reject@school.org"}
+	if err := m.Send(context.Background(), e); !IsPermanent(err) {
+		t.Fatalf("expected permanent error for 550, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SMTP mails each event as plain text. STARTTLS is used when the server offers it; Username
+// (with Password) enables PLAIN auth, which net/smtp only sends over TLS or to localhost.
+type SMTP struct {
+	Addr     string // host:port
+	From     string
+	To       []string
+	Username string
+	Password string
+	// TLSConfig overrides the STARTTLS configuration (default: verify Addr's host name).
+	TLSConfig *tls.Config
+}
+
+// Name identifies the sink in errors.
+func (m *SMTP) Name() string { return "smtp:" + m.Addr }
+
+// Send delivers e. 5xx replies are permanent; connection failures and 4xx replies are retried.
+func (m *SMTP) Send(ctx context.Context, e Event) error {
+	host, _, err := net.SplitHostPort(m.Addr)
+	if err != nil {
+		return Permanent(fmt.Errorf("smtp address: %w", err))
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.Addr)
+	if err != nil {
+		return err
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(dl)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return smtpError(err)
+	}
+	defer func() { _ = c.Close() }()
+	if err := c.Hello("localhost"); err != nil {
+		return smtpError(err)
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		cfg := m.TLSConfig
+		if cfg == nil {
+			cfg = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+		}
+		if err := c.StartTLS(cfg); err != nil {
+			return smtpError(err)
+		}
+	}
+	if m.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.Username, m.Password, host)); err != nil {
+			return smtpError(err)
+		}
+	}
+	if err := c.Mail(m.From); err != nil {
+		return smtpError(err)
+	}
+	for _, to := range m.To {
+		if err := c.Rcpt(to); err != nil {
+			return smtpError(err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return smtpError(err)
+	}
+	if _, err := w.Write(m.message(e)); err != nil {
+		return smtpError(err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpError(err)
+	}
+	return smtpError(c.Quit())
+}
+
+// message renders e as an RFC 5322 plain text mail.
+func (m *SMTP) message(e Event) []byte {
+	var b bytes.Buffer
+	subject := strings.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' {
+			return ' '
+		}
+		return r
+	}, e.Text)
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&b, "Subject: [sb29guard] %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", e.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: 8bit\r\n")
+	fmt.Fprintf(&b, "%s: %s\r\n\r\n", HeaderEvent, e.Type)
+	b.WriteString(e.Text + "\r\n\r\n")
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := strings.ReplaceAll(fmt.Sprint(e.Data[k]), "\n", "\r\n  ")
+		fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+	}
+	return b.Bytes()
+}
+
+// smtpError marks 5xx protocol replies as permanent.
+func smtpError(err error) error {
+	var te *textproto.Error
+	if errors.As(err, &te) && te.Code >= 500 {
+		return Permanent(err)
+	}
+	return err
+}
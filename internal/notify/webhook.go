@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Webhook headers. The signature is "sha256=" + hex HMAC-SHA256 of "<timestamp>.<body>" keyed
+// with the shared secret; receivers should also reject stale timestamps.
+const (
+	HeaderEvent     = "X-SB29Guard-Event"
+	HeaderTimestamp = "X-SB29Guard-Timestamp"
+	HeaderSignature = "X-SB29Guard-Signature"
+)
+
+// Webhook POSTs each event as JSON to URL. The body has a top-level "text" field, which
+// Slack and Teams incoming webhooks display.
+type Webhook struct {
+	URL    string
+	Secret []byte
+	Client *http.Client
+}
+
+// Name identifies the sink in errors; it is the URL's scheme and host, never its path, which
+// often embeds a token.
+func (w *Webhook) Name() string {
+	if u, err := url.Parse(w.URL); err == nil {
+		return "webhook:" + u.Scheme + "://" + u.Host
+	}
+	return "webhook"
+}
+
+// Send delivers e. Network errors, 429 and 5xx answers are retryable; other non-2xx answers
+// are permanent.
+func (w *Webhook) Send(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return Permanent(fmt.Errorf("encode event: %w", err))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	ts := strconv.FormatInt(e.Time.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, e.Type)
+	req.Header.Set(HeaderTimestamp, ts)
+	if len(w.Secret) > 0 {
+		req.Header.Set(HeaderSignature, Sign(w.Secret, ts, body))
+	}
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			return fmt.Errorf("webhook %s: %w", ue.Op, ue.Err) // keep the URL out of logs
+		}
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("webhook answered %s", resp.Status)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return Permanent(err)
+}
+
+// Sign returns the HeaderSignature value for body sent at timestamp ts.
+func Sign(secret []byte, ts string, body []byte) string {
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(ts))
+	m.Write([]byte("."))
+	m.Write(body)
+	return "sha256=" + hex.EncodeToString(m.Sum(nil))
+}
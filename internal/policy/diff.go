@@ -0,0 +1,162 @@
+package policy
+
+import (
+	"sort"
+	"strings"
+)
+
+// Change kinds reported by Diff.
+const (
+	ChangeAdded        = "added"
+	ChangeRemoved      = "removed"
+	ChangeSuspended    = "suspended"
+	ChangeUnsuspended  = "unsuspended"
+	ChangeReclassified = "reclassified"
+	ChangeRationale    = "rationale"
+	ChangeTranslations = "rationale_translations" // a translation added, removed or edited; Old/New list the languages
+	ChangeExpires      = "expires"
+	ChangeAction       = "action" // switched between block and allow (exemption)
+	ChangeScopes       = "scopes" // audiences changed; Old/New are comma-separated ("" = all)
+)
+
+// Change describes one record-level difference between two policies.
+// Old/New carry the changed value (classification, status, rationale, expiry or
+// action depending on Kind); for added/removed they hold the record's classification.
+type Change struct {
+	Domain string `json:"domain"`
+	Kind   string `json:"kind"`
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new,omitempty"`
+}
+
+// Diff compares two policies record by record, keyed by lowercased domain.
+// Both policies are expected to have passed Validate. When a domain appears
+// more than once on a side (one record per classification), records are paired
+// by classification instead, so reclassification is only reported for domains
+// with a single record on each side. Results are sorted by domain, then kind.
+func Diff(old, cur *Policy) []Change {
+	before := groupByDomain(old)
+	after := groupByDomain(cur)
+	var out []Change
+	for d, olds := range before {
+		news, ok := after[d]
+		if !ok {
+			for _, r := range olds {
+				out = append(out, Change{Domain: d, Kind: ChangeRemoved, Old: r.Classification})
+			}
+			continue
+		}
+		if len(olds) == 1 && len(news) == 1 {
+			out = append(out, diffRecord(olds[0], news[0])...)
+			continue
+		}
+		oldByClass := map[string]Record{}
+		for _, r := range olds {
+			oldByClass[r.Classification] = r
+		}
+		newByClass := map[string]Record{}
+		for _, r := range news {
+			newByClass[r.Classification] = r
+		}
+		for c, r := range oldByClass {
+			if n, ok := newByClass[c]; ok {
+				out = append(out, diffRecord(r, n)...)
+			} else {
+				out = append(out, Change{Domain: d, Kind: ChangeRemoved, Old: c})
+			}
+		}
+		for c := range newByClass {
+			if _, ok := oldByClass[c]; !ok {
+				out = append(out, Change{Domain: d, Kind: ChangeAdded, New: c})
+			}
+		}
+	}
+	for d, news := range after {
+		if _, ok := before[d]; ok {
+			continue
+		}
+		for _, r := range news {
+			out = append(out, Change{Domain: d, Kind: ChangeAdded, New: r.Classification})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Domain != out[j].Domain {
+			return out[i].Domain < out[j].Domain
+		}
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Old+"|"+out[i].New < out[j].Old+"|"+out[j].New
+	})
+	return out
+}
+
+func groupByDomain(p *Policy) map[string][]Record {
+	m := map[string][]Record{}
+	if p == nil {
+		return m
+	}
+	for _, r := range p.Records {
+		d := strings.ToLower(r.Domain)
+		m[d] = append(m[d], r)
+	}
+	return m
+}
+
+// diffRecord reports field-level changes between two records for the same domain.
+func diffRecord(a, b Record) []Change {
+	d := strings.ToLower(b.Domain)
+	var out []Change
+	if wasActive, isActive := a.Status != "suspended", b.Status != "suspended"; wasActive != isActive {
+		kind := ChangeSuspended
+		if isActive {
+			kind = ChangeUnsuspended
+		}
+		out = append(out, Change{Domain: d, Kind: kind, Old: statusOrActive(a.Status), New: statusOrActive(b.Status)})
+	}
+	if actionOrBlock(a.Action) != actionOrBlock(b.Action) {
+		out = append(out, Change{Domain: d, Kind: ChangeAction, Old: actionOrBlock(a.Action), New: actionOrBlock(b.Action)})
+	}
+	if a.Classification != b.Classification {
+		out = append(out, Change{Domain: d, Kind: ChangeReclassified, Old: a.Classification, New: b.Classification})
+	}
+	if strings.TrimSpace(a.Rationale) != strings.TrimSpace(b.Rationale) {
+		out = append(out, Change{Domain: d, Kind: ChangeRationale, Old: a.Rationale, New: b.Rationale})
+	}
+	if !sameTranslations(a.RationaleTranslations, b.RationaleTranslations) {
+		out = append(out, Change{Domain: d, Kind: ChangeTranslations, Old: strings.Join(sortedKeys(a.RationaleTranslations), ","), New: strings.Join(sortedKeys(b.RationaleTranslations), ",")})
+	}
+	if sa, sb := strings.Join(a.Scopes, ","), strings.Join(b.Scopes, ","); sa != sb {
+		out = append(out, Change{Domain: d, Kind: ChangeScopes, Old: sa, New: sb})
+	}
+	if a.Expires != b.Expires {
+		out = append(out, Change{Domain: d, Kind: ChangeExpires, Old: a.Expires, New: b.Expires})
+	}
+	return out
+}
+
+func sameTranslations(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || strings.TrimSpace(v) != strings.TrimSpace(w) {
+			return false
+		}
+	}
+	return true
+}
+
+func statusOrActive(s string) string {
+	if s == "" {
+		return "active"
+	}
+	return s
+}
+
+func actionOrBlock(a string) string {
+	if a == "" {
+		return ActionBlock
+	}
+	return a
+}
@@ -0,0 +1,62 @@
+package policy
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	old := &Policy{Version: "0.1.0", Updated: "2025-08-01", Records: []Record{
+		{Domain: "exampletool.com", Classification: "NO_DPA", Rationale: "Vendor has not signed", LastReview: "2025-08-01", Status: "active"},
+		{Domain: "gone.com", Classification: "OTHER", Rationale: "r", LastReview: "2025-08-01", Status: "active"},
+		{Domain: "paused.com", Classification: "OTHER", Rationale: "r", LastReview: "2025-08-01", Status: "suspended"},
+		{Domain: "*.trackingwidgets.io", Classification: "PENDING_REVIEW", Rationale: "Under review", LastReview: "2025-08-01", Status: "active"},
+	}}
+	cur := &Policy{Version: "0.1.0", Updated: "2025-08-02", Records: []Record{
+		{Domain: "exampletool.com", Classification: "NO_DPA", Rationale: "Vendor declined", LastReview: "2025-08-02", Status: "suspended", Expires: "2026-01-01"},
+		{Domain: "new.com", Classification: "LEGAL_HOLD", Rationale: "r", LastReview: "2025-08-02", Status: "active"},
+		{Domain: "paused.com", Classification: "OTHER", Rationale: "r", LastReview: "2025-08-01", Status: ""},
+		{Domain: "*.trackingwidgets.io", Classification: "EXPIRED_DPA", Rationale: "Under review", LastReview: "2025-08-02", Status: "active"},
+	}}
+	got := Diff(old, cur)
+	want := []Change{
+		{Domain: "*.trackingwidgets.io", Kind: ChangeReclassified, Old: "PENDING_REVIEW", New: "EXPIRED_DPA"},
+		{Domain: "exampletool.com", Kind: ChangeExpires, New: "2026-01-01"},
+		{Domain: "exampletool.com", Kind: ChangeRationale, Old: "Vendor has not signed", New: "Vendor declined"},
+		{Domain: "exampletool.com", Kind: ChangeSuspended, Old: "active", New: "suspended"},
+		{Domain: "gone.com", Kind: ChangeRemoved, Old: "OTHER"},
+		{Domain: "new.com", Kind: ChangeAdded, New: "LEGAL_HOLD"},
+		{Domain: "paused.com", Kind: ChangeUnsuspended, Old: "suspended", New: "active"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d changes, want %d: %#v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("change %d: got %#v want %#v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDiffMultipleClassificationsPerDomain(t *testing.T) {
+	old := &Policy{Records: []Record{
+		{Domain: "dual.com", Classification: "NO_DPA", Status: "active"},
+		{Domain: "dual.com", Classification: "LEGAL_HOLD", Status: "active"},
+	}}
+	cur := &Policy{Records: []Record{
+		{Domain: "dual.com", Classification: "NO_DPA", Status: "active"},
+	}}
+	got := Diff(old, cur)
+	if len(got) != 1 || got[0].Kind != ChangeRemoved || got[0].Old != "LEGAL_HOLD" {
+		t.Fatalf("unexpected diff: %#v", got)
+	}
+	if d := Diff(cur, cur); len(d) != 0 {
+		t.Fatalf("expected no changes for identical policies, got %#v", d)
+	}
+}
+
+func TestDiffAction(t *testing.T) {
+	old := &Policy{Records: []Record{{Domain: "classroom.bigvendor.com", Classification: "NO_DPA", Status: "active"}}}
+	cur := &Policy{Records: []Record{{Domain: "classroom.bigvendor.com", Classification: "NO_DPA", Status: "active", Action: ActionAllow}}}
+	got := Diff(old, cur)
+	if len(got) != 1 || got[0].Kind != ChangeAction || got[0].Old != ActionBlock || got[0].New != ActionAllow {
+		t.Fatalf("unexpected diff: %#v", got)
+	}
+}
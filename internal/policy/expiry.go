@@ -0,0 +1,146 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SetExpiredClassification selects grace mode for p's expired records. When class is empty
+// (the default), a record whose expires date has passed is treated as inactive everywhere
+// (Lookup, ActiveRecords). When set, expired records stay enforced but are reported with
+// class instead, e.g. "EXPIRED_DPA"; class must be defined in p's taxonomy. The setting is
+// not part of the policy document: callers apply it after loading, and WithoutExpired and
+// ForScope carry it over.
+func (p *Policy) SetExpiredClassification(class string) error {
+	if class != "" {
+		if _, ok := p.Classification(class); !ok {
+			return fmt.Errorf("expired classification %s is not defined in the taxonomy", class)
+		}
+	}
+	p.expiredClass = class
+	return nil
+}
+
+// ExpiredClassification returns the grace classification set by SetExpiredClassification,
+// or "" when grace mode is off.
+func (p *Policy) ExpiredClassification() string { return p.expiredClass }
+
+// ExpiresTime parses the record's expires date (YYYY-MM-DD) in loc. ok is false when the
+// field is empty or malformed.
+func (r Record) ExpiresTime(loc *time.Location) (t time.Time, ok bool) {
+	s := strings.TrimSpace(r.Expires)
+	if s == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Expired reports whether the record's expires date lies before now's calendar day;
+// a record is still valid on its expires date and lapses at the following midnight.
+func (r Record) Expired(now time.Time) bool {
+	t, ok := r.ExpiresTime(now.Location())
+	if !ok {
+		return false
+	}
+	return !now.Before(t.AddDate(0, 0, 1))
+}
+
+// enforced returns the record as it applies at now and whether it applies at all:
+// suspended records never do, expired ones only in grace mode (reclassified as grace). An
+// expired exemption always lapses, so the block it carved out resumes.
+func enforced(r Record, now time.Time, grace string) (Record, bool) {
+	if r.Status == "suspended" {
+		return r, false
+	}
+	if r.Expired(now) {
+		if grace == "" || r.Exempt() {
+			return r, false
+		}
+		r.Classification = grace
+	}
+	return r, true
+}
+
+// ActiveRecords returns the records enforced at now, in policy order, with expiry applied.
+// Exemptions are included; generators that only emit blocks should skip r.Exempt() records.
+func (p *Policy) ActiveRecords(now time.Time) []Record {
+	var out []Record
+	for _, r := range p.Records {
+		if e, ok := enforced(r, now, p.expiredClass); ok {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// WithoutExpired returns a copy of p, index built, without the records that have stopped
+// applying at now because their expires date passed. In grace mode expired block records
+// are kept (lookups reclassify them); expired exemptions always go. When nothing is
+// dropped p itself is returned.
+func (p *Policy) WithoutExpired(now time.Time) *Policy {
+	var keep []Record
+	for _, r := range p.Records {
+		if r.Status != "suspended" && r.Expired(now) && (p.expiredClass == "" || r.Exempt()) {
+			continue
+		}
+		keep = append(keep, r)
+	}
+	if len(keep) == len(p.Records) {
+		return p
+	}
+	v := &Policy{Version: p.Version, Updated: p.Updated, Records: keep, Classifications: p.Classifications,
+		Includes: p.Includes, Overrides: p.Overrides, Files: p.Files, Metadata: p.Metadata,
+		expiredClass: p.expiredClass, dropped: p.dropped + len(p.Records) - len(keep)}
+	v.BuildIndex()
+	return v
+}
+
+// ExpiredCount returns how many non-suspended records have passed their expires date at now,
+// including those WithoutExpired already dropped.
+func (p *Policy) ExpiredCount(now time.Time) int {
+	n := p.dropped
+	for _, r := range p.Records {
+		if r.Status != "suspended" && r.Expired(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// ExpiringWithin lists non-suspended records that expire within window of now (still valid
+// today) followed by those already expired, each group sorted by expires date then domain.
+func (p *Policy) ExpiringWithin(now time.Time, window time.Duration) (expiring, expired []Record) {
+	horizon := now.Add(window)
+	for _, r := range p.Records {
+		if r.Status == "suspended" {
+			continue
+		}
+		t, ok := r.ExpiresTime(now.Location())
+		if !ok {
+			continue
+		}
+		switch {
+		case r.Expired(now):
+			expired = append(expired, r)
+		case t.Before(horizon):
+			expiring = append(expiring, r)
+		}
+	}
+	byDate := func(rs []Record) {
+		sort.Slice(rs, func(i, j int) bool {
+			if rs[i].Expires != rs[j].Expires {
+				return rs[i].Expires < rs[j].Expires
+			}
+			return rs[i].Domain < rs[j].Domain
+		})
+	}
+	byDate(expiring)
+	byDate(expired)
+	return expiring, expired
+}
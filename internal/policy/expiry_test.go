@@ -0,0 +1,123 @@
+package policy
+
+import (
+	"testing"
+	"time"
+)
+
+func expiryPolicy() *Policy {
+	return &Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []Record{
+		{Domain: "current.com", Classification: "NO_DPA", Status: "active"},
+		{Domain: "lapsed.com", Classification: "NO_DPA", Status: "active", Expires: "2025-08-31"},
+		{Domain: "*.lapsing.io", Classification: "PENDING_REVIEW", Status: "active", Expires: "2025-09-10"},
+		{Domain: "paused.com", Classification: "NO_DPA", Status: "suspended", Expires: "2025-08-01"},
+	}}
+}
+
+func TestRecordExpired(t *testing.T) {
+	r := Record{Expires: "2025-08-31"}
+	if r.Expired(time.Date(2025, 8, 31, 23, 59, 0, 0, time.UTC)) {
+		t.Fatalf("record should still be valid on its expires date")
+	}
+	if !r.Expired(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("record should lapse at the following midnight")
+	}
+	if (Record{}).Expired(time.Now()) || (Record{Expires: "soon"}).Expired(time.Now()) {
+		t.Fatalf("empty or malformed expires should never expire")
+	}
+}
+
+func TestLookupAndActiveRecordsHonorExpiry(t *testing.T) {
+	p := expiryPolicy()
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	if _, ok := p.LookupAt("lapsed.com", now); ok {
+		t.Fatalf("expired record should not match")
+	}
+	if rec, ok := p.LookupAt("api.lapsing.io", now); !ok || rec.Classification != "PENDING_REVIEW" {
+		t.Fatalf("unexpired wildcard should match, got %#v ok=%v", rec, ok)
+	}
+	if n := len(p.ActiveRecords(now)); n != 2 {
+		t.Fatalf("expected 2 active records, got %d", n)
+	}
+	if n := p.ExpiredCount(now); n != 1 {
+		t.Fatalf("expected 1 expired record, got %d", n)
+	}
+
+	if err := p.SetExpiredClassification("EXPIRED_DPA"); err != nil {
+		t.Fatalf("set grace: %v", err)
+	}
+	rec, ok := p.LookupAt("lapsed.com", now)
+	if !ok || rec.Classification != "EXPIRED_DPA" {
+		t.Fatalf("grace mode should keep the record under the grace classification, got %#v ok=%v", rec, ok)
+	}
+	if p.Records[1].Classification != "NO_DPA" {
+		t.Fatalf("grace mode must not mutate the stored policy")
+	}
+	if n := len(p.ActiveRecords(now)); n != 3 {
+		t.Fatalf("expected 3 active records in grace mode, got %d", n)
+	}
+}
+
+func TestExpiringWithin(t *testing.T) {
+	p := expiryPolicy()
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	expiring, expired := p.ExpiringWithin(now, 30*24*time.Hour)
+	if len(expiring) != 1 || expiring[0].Domain != "*.lapsing.io" {
+		t.Fatalf("unexpected expiring: %#v", expiring)
+	}
+	if len(expired) != 1 || expired[0].Domain != "lapsed.com" {
+		t.Fatalf("unexpected expired: %#v", expired)
+	}
+	if expiring, _ := p.ExpiringWithin(now, 24*time.Hour); len(expiring) != 0 {
+		t.Fatalf("nothing should expire within a day, got %#v", expiring)
+	}
+}
+
+func TestWithoutExpiredRebuildsPolicy(t *testing.T) {
+	p := expiryPolicy()
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	before := time.Date(2025, 8, 20, 12, 0, 0, 0, time.UTC)
+	if p.WithoutExpired(before) != p {
+		t.Fatalf("nothing expired yet: expected the same policy")
+	}
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	v := p.WithoutExpired(now)
+	if len(v.Records) != 3 || len(p.Records) != 4 {
+		t.Fatalf("expected lapsed.com dropped from a copy, got %d (source %d)", len(v.Records), len(p.Records))
+	}
+	if _, ok := v.MatchAt("lapsed.com", before); ok {
+		t.Fatalf("dropped record should be gone from the index")
+	}
+	if v.ExpiredCount(now) != p.ExpiredCount(now) {
+		t.Fatalf("dropped records should still be counted: %d vs %d", v.ExpiredCount(now), p.ExpiredCount(now))
+	}
+
+	if err := p.SetExpiredClassification("EXPIRED_DPA"); err != nil {
+		t.Fatalf("set grace: %v", err)
+	}
+	if p.WithoutExpired(now) != p {
+		t.Fatalf("grace mode keeps expired block records")
+	}
+}
+
+func TestGraceModeIsPerPolicy(t *testing.T) {
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	grace, plain := expiryPolicy(), expiryPolicy()
+	if err := grace.SetExpiredClassification("EXPIRED_DPA"); err != nil {
+		t.Fatalf("set grace: %v", err)
+	}
+	if rec, ok := grace.LookupAt("lapsed.com", now); !ok || rec.Classification != "EXPIRED_DPA" {
+		t.Fatalf("grace policy should reclassify, got %#v ok=%v", rec, ok)
+	}
+	if _, ok := plain.LookupAt("lapsed.com", now); ok {
+		t.Fatalf("another policy in the same process must keep dropping expired records")
+	}
+	// Grace mode keeps lapsed.com, so WithoutExpired has to drop the expired exemption to copy anything.
+	grace.Records = append(grace.Records, Record{Domain: "old.lapsing.io", Action: ActionAllow, Status: "active", Expires: "2025-08-01"})
+	v := grace.WithoutExpired(now)
+	if rec, ok := v.LookupAt("lapsed.com", now); v == grace || !ok || rec.Classification != "EXPIRED_DPA" || v.ExpiredClassification() != "EXPIRED_DPA" {
+		t.Fatalf("WithoutExpired should carry grace mode over, got %#v ok=%v", rec, ok)
+	}
+}
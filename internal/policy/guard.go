@@ -0,0 +1,108 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Guard rejection reasons reported in GuardViolation.Reason.
+const (
+	GuardRecordDrop   = "record_drop"
+	GuardReclassified = "reclassified"
+	GuardRequiredTag  = "required_tag"
+)
+
+// Guardrails bound how far a refreshed policy may move away from the active one before it
+// is treated as a mistake (e.g. rows accidentally deleted from the sheet). Zero values disable
+// the corresponding check.
+type Guardrails struct {
+	MaxDropPercent  float64  // reject when active records shrink by more than this percentage
+	MaxDrop         int      // reject when active records shrink by more than this many
+	MaxReclassified int      // reject when more than this many domains change classification
+	RequiredTags    []string // reject when no active record carries one of these tags
+}
+
+// GuardViolation explains why Guardrails.Check refused a policy.
+type GuardViolation struct {
+	Reason string
+	Detail string
+}
+
+func (v *GuardViolation) Error() string {
+	return fmt.Sprintf("guard rejected refresh (%s): %s", v.Reason, v.Detail)
+}
+
+// Enabled reports whether any check is configured.
+func (g Guardrails) Enabled() bool {
+	return g.MaxDropPercent > 0 || g.MaxDrop > 0 || g.MaxReclassified > 0 || len(g.RequiredTags) > 0
+}
+
+// Check compares the candidate policy cur against the active policy old and returns a
+// *GuardViolation for the first guardrail it breaks, or nil.
+func (g Guardrails) Check(old, cur *Policy) error {
+	if old != nil {
+		before, after := ActiveCount(old), ActiveCount(cur)
+		if drop := before - after; drop > 0 {
+			pct := float64(drop) * 100 / float64(before)
+			if g.MaxDropPercent > 0 && pct > g.MaxDropPercent {
+				return &GuardViolation{Reason: GuardRecordDrop, Detail: fmt.Sprintf("active records %d -> %d (-%.1f%%, limit %.1f%%)", before, after, pct, g.MaxDropPercent)}
+			}
+			if g.MaxDrop > 0 && drop > g.MaxDrop {
+				return &GuardViolation{Reason: GuardRecordDrop, Detail: fmt.Sprintf("active records %d -> %d (-%d, limit %d)", before, after, drop, g.MaxDrop)}
+			}
+		}
+		if g.MaxReclassified > 0 {
+			n := 0
+			for _, c := range Diff(old, cur) {
+				if c.Kind == ChangeReclassified {
+					n++
+				}
+			}
+			if n > g.MaxReclassified {
+				return &GuardViolation{Reason: GuardReclassified, Detail: fmt.Sprintf("%d domains reclassified (limit %d)", n, g.MaxReclassified)}
+			}
+		}
+	}
+	for _, tag := range g.RequiredTags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if !hasActiveTag(cur, tag) {
+			return &GuardViolation{Reason: GuardRequiredTag, Detail: fmt.Sprintf("no active record tagged %q", tag)}
+		}
+	}
+	return nil
+}
+
+// ActiveCount returns the number of block records that are not suspended; exemptions
+// (action: allow) do not count, since losing one widens rather than narrows enforcement.
+func ActiveCount(p *Policy) int {
+	if p == nil {
+		return 0
+	}
+	n := 0
+	for _, r := range p.Records {
+		if r.Status != "suspended" && !r.Exempt() {
+			n++
+		}
+	}
+	return n
+}
+
+func hasActiveTag(p *Policy, tag string) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.Records {
+		if r.Status == "suspended" {
+			continue
+		}
+		for _, t := range r.Tags {
+			if strings.EqualFold(strings.TrimSpace(t), tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
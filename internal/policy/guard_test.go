@@ -0,0 +1,55 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func guardPolicy(n int, class string, tags ...string) *Policy {
+	p := &Policy{Version: "0.1.0", Updated: "2025-08-08"}
+	for i := 0; i < n; i++ {
+		p.Records = append(p.Records, Record{Domain: fmt.Sprintf("d%d.example.com", i), Classification: class, Status: "active", Tags: tags})
+	}
+	return p
+}
+
+func TestGuardrailsCheck(t *testing.T) {
+	old := guardPolicy(10, "NO_DPA", "k12")
+	cases := []struct {
+		name   string
+		rails  Guardrails
+		cur    *Policy
+		reason string
+	}{
+		{"disabled", Guardrails{}, guardPolicy(0, "NO_DPA"), ""},
+		{"drop within percent", Guardrails{MaxDropPercent: 50}, guardPolicy(5, "NO_DPA"), ""},
+		{"drop over percent", Guardrails{MaxDropPercent: 50}, guardPolicy(4, "NO_DPA"), GuardRecordDrop},
+		{"drop over absolute", Guardrails{MaxDrop: 2}, guardPolicy(7, "NO_DPA"), GuardRecordDrop},
+		{"growth ignored", Guardrails{MaxDropPercent: 1, MaxDrop: 1}, guardPolicy(20, "NO_DPA"), ""},
+		{"reclassified", Guardrails{MaxReclassified: 3}, guardPolicy(10, "LEGAL_HOLD"), GuardReclassified},
+		{"required tag present", Guardrails{RequiredTags: []string{"K12"}}, guardPolicy(10, "NO_DPA", "k12"), ""},
+		{"required tag missing", Guardrails{RequiredTags: []string{"k12"}}, guardPolicy(10, "NO_DPA"), GuardRequiredTag},
+	}
+	for _, tc := range cases {
+		err := tc.rails.Check(old, tc.cur)
+		var v *GuardViolation
+		switch {
+		case tc.reason == "" && err != nil:
+			t.Errorf("%s: unexpected rejection: %v", tc.name, err)
+		case tc.reason != "" && (!errors.As(err, &v) || v.Reason != tc.reason):
+			t.Errorf("%s: expected %s violation, got %v", tc.name, tc.reason, err)
+		}
+	}
+}
+
+func TestGuardrailsSuspendedCountAsDropped(t *testing.T) {
+	old := guardPolicy(4, "NO_DPA")
+	cur := guardPolicy(4, "NO_DPA")
+	for i := range cur.Records[:3] {
+		cur.Records[i].Status = "suspended"
+	}
+	if err := (Guardrails{MaxDropPercent: 50}).Check(old, cur); err == nil {
+		t.Fatalf("expected suspending 3 of 4 records to trip the drop guard")
+	}
+}
@@ -0,0 +1,75 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// NormalizeDomain lowercases a policy or query domain and, for internationalized names,
+// converts it to its A-label (punycode) form using IDNA2008 / UTS-46 lookup rules. It returns
+// the ASCII form used for matching and generation plus the Unicode (U-label) form for display;
+// unicode is empty when the name has no internationalized labels. A leading "*." is preserved.
+// Plain ASCII names are returned as-is so existing policies validate exactly as before.
+func NormalizeDomain(s string) (ascii, unicode string, err error) {
+	d := strings.ToLower(strings.TrimSpace(s))
+	prefix := ""
+	if rest, ok := strings.CutPrefix(d, "*."); ok {
+		prefix, d = "*.", rest
+	}
+	if !needsIDNA(d) {
+		return prefix + d, "", nil
+	}
+	a, err := idna.Lookup.ToASCII(d)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid internationalized domain %q: %w", s, err)
+	}
+	u, err := idna.Lookup.ToUnicode(a)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid internationalized domain %q: %w", s, err)
+	}
+	if u == a {
+		u = ""
+	} else {
+		u = prefix + u
+	}
+	return prefix + a, u, nil
+}
+
+// DisplayDomain returns the Unicode form of an A-label domain for presentation, or d unchanged
+// when it has no punycode labels or cannot be decoded.
+func DisplayDomain(d string) string {
+	if !strings.Contains(d, "xn--") {
+		return d
+	}
+	if _, u, err := NormalizeDomain(d); err == nil && u != "" {
+		return u
+	}
+	return d
+}
+
+// needsIDNA reports whether d contains non-ASCII characters or punycode labels.
+func needsIDNA(d string) bool {
+	for i := 0; i < len(d); i++ {
+		if d[i] >= 0x80 {
+			return true
+		}
+	}
+	return strings.HasPrefix(d, "xn--") || strings.Contains(d, ".xn--")
+}
+
+// lookupName maps an incoming host to the A-label form used by the index. ASCII input is
+// returned without allocating; Unicode input is converted (and left as-is if invalid, so it
+// simply fails to match).
+func lookupName(d string) string {
+	for i := 0; i < len(d); i++ {
+		if d[i] >= 0x80 {
+			if a, err := idna.Lookup.ToASCII(d); err == nil {
+				return a
+			}
+			return d
+		}
+	}
+	return d
+}
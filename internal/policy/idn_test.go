@@ -0,0 +1,64 @@
+package policy
+
+import "testing"
+
+func TestNormalizeDomain(t *testing.T) {
+	cases := []struct{ in, ascii, unicode string }{
+		{"bücher.de", "xn--bcher-kva.de", "bücher.de"},
+		{"BÜCHER.de", "xn--bcher-kva.de", "bücher.de"},
+		{"*.münchen.de", "*.xn--mnchen-3ya.de", "*.münchen.de"},
+		{"xn--bcher-kva.de", "xn--bcher-kva.de", "bücher.de"},
+		{"пример.рф", "xn--e1afmkfd.xn--p1ai", "пример.рф"},
+		{"r3--sn-abc.example.com", "r3--sn-abc.example.com", ""},
+		{" Example.COM ", "example.com", ""},
+	}
+	for _, c := range cases {
+		a, u, err := NormalizeDomain(c.in)
+		if err != nil || a != c.ascii || u != c.unicode {
+			t.Errorf("NormalizeDomain(%q) = %q, %q, %v; want %q, %q", c.in, a, u, err, c.ascii, c.unicode)
+		}
+	}
+	if _, _, err := NormalizeDomain("bad\u200d.de"); err == nil {
+		t.Errorf("expected error for disallowed joiner")
+	}
+	if got := DisplayDomain("www.xn--bcher-kva.de"); got != "www.bücher.de" {
+		t.Errorf("DisplayDomain = %q", got)
+	}
+}
+
+func TestLoadValidateAndLookupIDN(t *testing.T) {
+	y := `version: 0.1.0
+updated: 2025-08-08
+records:
+  - domain: "bücher.de"
+    classification: NO_DPA
+    rationale: "Vendor has not signed"
+    last_review: 2025-08-01
+    status: active
+  - domain: "*.пример.рф"
+    classification: LEGAL_HOLD
+    rationale: "Under legal hold"
+    last_review: 2025-08-01
+    status: active
+`
+	p, err := Load([]byte(y))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if p.Records[0].Domain != "xn--bcher-kva.de" || p.Records[0].UnicodeDomain != "bücher.de" {
+		t.Fatalf("expected both forms stored, got %#v", p.Records[0])
+	}
+	for _, q := range []string{"bücher.de", "xn--bcher-kva.de", "BÜCHER.DE"} {
+		if _, ok := p.Lookup(q); !ok {
+			t.Errorf("Lookup(%q) should match", q)
+		}
+	}
+	for _, q := range []string{"shop.пример.рф", "shop.xn--e1afmkfd.xn--p1ai"} {
+		if rec, ok := p.Lookup(q); !ok || rec.Classification != "LEGAL_HOLD" {
+			t.Errorf("Lookup(%q) = %#v, %v", q, rec, ok)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Override adjusts a domain defined by an earlier layer (an include or an earlier record).
+// Empty fields leave the existing value unchanged; Tags and Scopes, when set, replace the existing
+// lists, and RationaleTranslations entries replace those for the same language.
+type Override struct {
+	Domain                string            `yaml:"domain" json:"domain"`
+	Classification        string            `yaml:"classification,omitempty" json:"classification,omitempty"`
+	Status                string            `yaml:"status,omitempty" json:"status,omitempty"`
+	Rationale             string            `yaml:"rationale,omitempty" json:"rationale,omitempty"`
+	RationaleTranslations map[string]string `yaml:"rationale_translations,omitempty" json:"rationale_translations,omitempty"`
+	Notes                 string            `yaml:"notes,omitempty" json:"notes,omitempty"`
+	SourceRef             string            `yaml:"source_ref,omitempty" json:"source_ref,omitempty"`
+	Expires               string            `yaml:"expires,omitempty" json:"expires,omitempty"`
+	Tags                  []string          `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Scopes                []string          `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+}
+
+// CSVFetcher loads an included CSV source: a published sheet URL or a local .csv path.
+// It lives outside this package (see internal/sheets) to avoid an import cycle.
+type CSVFetcher func(ref string) (*Policy, error)
+
+// IsCSVRef reports whether an include reference names a CSV source rather than a YAML file.
+func IsCSVRef(ref string) bool {
+	return strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") || strings.EqualFold(filepath.Ext(ref), ".csv")
+}
+
+// LoadFile loads the YAML policy at path and resolves its layers into one flat policy:
+//  1. each entry of includes, in order (YAML paths are relative to the including file and
+//     may include further files; sheet URLs and .csv paths go through fetch),
+//  2. the file's own records,
+//  3. the file's overrides.
+//
+// A later layer that lists a domain replaces every earlier record for that domain; an override
+// edits the existing records and fails if no earlier layer defines the domain. Every record
+// carries its provenance in Origin (and OverriddenBy). version, updated and metadata come from
+// the root file; classifications from every YAML layer are merged in the same order, so a shared
+// taxonomy file can be included. Every local file read is listed in Files. The result is not
+// yet validated; call Validate as for Load.
+func LoadFile(path string, fetch CSVFetcher) (*Policy, error) {
+	return loadLayered(path, fetch, nil)
+}
+
+func loadLayered(path string, fetch CSVFetcher, stack []string) (*Policy, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range stack {
+		if s == abs {
+			return nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), abs)
+		}
+	}
+	stack = append(stack, abs)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := Load(b)
+	if err != nil {
+		if len(stack) > 1 {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return nil, err
+	}
+	if len(doc.Includes) == 0 && len(doc.Overrides) == 0 {
+		for i := range doc.Records {
+			if doc.Records[i].Origin == "" {
+				doc.Records[i].Origin = path
+			}
+		}
+		doc.Files = []string{path}
+		return doc, nil
+	}
+	merged := &Policy{Version: doc.Version, Updated: doc.Updated, Metadata: doc.Metadata, Files: []string{path}}
+	dir := filepath.Dir(path)
+	for _, ref := range doc.Includes {
+		var layer *Policy
+		if IsCSVRef(ref) {
+			if fetch == nil {
+				return nil, fmt.Errorf("%s: include %s: csv includes not supported here", path, ref)
+			}
+			if !strings.Contains(ref, "://") && !filepath.IsAbs(ref) {
+				ref = filepath.Join(dir, ref)
+			}
+			layer, err = fetch(ref)
+			if err != nil {
+				return nil, fmt.Errorf("%s: include %s: %w", path, ref, err)
+			}
+			for i := range layer.Records {
+				layer.Records[i].Origin = ref
+			}
+			if !strings.Contains(ref, "://") {
+				merged.Files = append(merged.Files, ref)
+			}
+		} else {
+			if !filepath.IsAbs(ref) {
+				ref = filepath.Join(dir, ref)
+			}
+			layer, err = loadLayered(ref, fetch, stack)
+			if err != nil {
+				return nil, err
+			}
+			merged.Files = append(merged.Files, layer.Files...)
+		}
+		mergeLayer(merged, layer.Records)
+		merged.Classifications = mergeTaxonomy(merged.Classifications, layer.Classifications)
+	}
+	merged.Classifications = mergeTaxonomy(merged.Classifications, doc.Classifications)
+	own := doc.Records
+	for i := range own {
+		own[i].Origin = path
+	}
+	mergeLayer(merged, own)
+	for _, o := range doc.Overrides {
+		if err := applyOverride(merged, o, path); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// mergeLayer appends layer's records, first dropping earlier records for any domain the layer lists.
+func mergeLayer(p *Policy, layer []Record) {
+	replaced := map[string]bool{}
+	for _, r := range layer {
+		replaced[layerKey(r.Domain)] = true
+	}
+	kept := p.Records[:0]
+	for _, r := range p.Records {
+		if !replaced[layerKey(r.Domain)] {
+			kept = append(kept, r)
+		}
+	}
+	p.Records = append(kept, layer...)
+}
+
+func applyOverride(p *Policy, o Override, origin string) error {
+	if strings.TrimSpace(o.Domain) == "" {
+		return fmt.Errorf("%s: override with empty domain", origin)
+	}
+	key := layerKey(o.Domain)
+	found := false
+	for i := range p.Records {
+		r := &p.Records[i]
+		if layerKey(r.Domain) != key {
+			continue
+		}
+		found = true
+		if o.Classification != "" {
+			r.Classification = o.Classification
+		}
+		if o.Status != "" {
+			r.Status = o.Status
+		}
+		if o.Rationale != "" {
+			r.Rationale = o.Rationale
+		}
+		if len(o.RationaleTranslations) > 0 {
+			merged := make(map[string]string, len(r.RationaleTranslations)+len(o.RationaleTranslations))
+			for k, v := range r.RationaleTranslations {
+				merged[k] = v
+			}
+			for k, v := range o.RationaleTranslations {
+				merged[k] = v
+			}
+			r.RationaleTranslations = merged
+		}
+		if o.Notes != "" {
+			r.Notes = o.Notes
+		}
+		if o.SourceRef != "" {
+			r.SourceRef = o.SourceRef
+		}
+		if o.Expires != "" {
+			r.Expires = o.Expires
+		}
+		if o.Tags != nil {
+			r.Tags = append([]string(nil), o.Tags...)
+		}
+		if o.Scopes != nil {
+			r.Scopes = append([]string(nil), o.Scopes...)
+		}
+		r.OverriddenBy = append(r.OverriddenBy, origin)
+	}
+	if !found {
+		return errors.New(origin + ": override for " + o.Domain + ": domain not defined by an earlier layer")
+	}
+	return nil
+}
+
+// layerKey compares domains across layers regardless of case or Unicode/punycode spelling.
+func layerKey(d string) string {
+	if a, _, err := NormalizeDomain(d); err == nil {
+		return a
+	}
+	return strings.ToLower(strings.TrimSpace(d))
+}
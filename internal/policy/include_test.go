@@ -0,0 +1,101 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePolicyFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return p
+}
+
+const includeRecord = "    rationale: vendor unsigned\n    last_review: 2025-08-01\n    status: active\n"
+
+func TestLoadFileMergesIncludesAndOverrides(t *testing.T) {
+	d := t.TempDir()
+	if err := os.Mkdir(filepath.Join(d, "district"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writePolicyFile(t, d, "district/base.yaml", "version: 0.1.0\nupdated: 2025-08-01\nrecords:\n"+
+		"  - domain: shared.com\n    classification: NO_DPA\n"+includeRecord+
+		"  - domain: replaced.com\n    classification: NO_DPA\n"+includeRecord+
+		"  - domain: paused.com\n    classification: NO_DPA\n"+includeRecord)
+	root := writePolicyFile(t, d, "school.yaml", "version: 0.2.0\nupdated: 2025-08-08\n"+
+		"includes:\n  - district/base.yaml\n"+
+		"records:\n  - domain: replaced.com\n    classification: LEGAL_HOLD\n"+includeRecord+
+		"overrides:\n  - domain: paused.com\n    status: suspended\n    notes: pilot approved locally\n"+
+		"  - domain: shared.com\n    classification: PENDING_REVIEW\n")
+	p, err := LoadFile(root, nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if p.Version != "0.2.0" || len(p.Records) != 3 || p.Includes != nil || p.Overrides != nil {
+		t.Fatalf("unexpected merged policy: %+v", p)
+	}
+	byDomain := map[string]Record{}
+	for _, r := range p.Records {
+		byDomain[r.Domain] = r
+	}
+	if r := byDomain["replaced.com"]; r.Classification != "LEGAL_HOLD" || r.Origin != root {
+		t.Fatalf("later record should replace included one: %+v", r)
+	}
+	if r := byDomain["paused.com"]; r.Status != "suspended" || r.Notes != "pilot approved locally" ||
+		!strings.HasSuffix(r.Origin, "base.yaml") || len(r.OverriddenBy) != 1 || r.OverriddenBy[0] != root {
+		t.Fatalf("override not applied with provenance: %+v", r)
+	}
+	if r := byDomain["shared.com"]; r.Classification != "PENDING_REVIEW" || r.Rationale != "vendor unsigned" {
+		t.Fatalf("reclassify override should keep other fields: %+v", r)
+	}
+	if _, ok := p.Lookup("paused.com"); ok {
+		t.Fatalf("suspended override should stop enforcement")
+	}
+}
+
+func TestLoadFileCSVIncludeUsesFetcher(t *testing.T) {
+	d := t.TempDir()
+	root := writePolicyFile(t, d, "policy.yaml", "version: 0.1.0\nupdated: 2025-08-08\n"+
+		"includes:\n  - https://example.com/sheet.csv\n  - local.csv\n")
+	var refs []string
+	fetch := func(ref string) (*Policy, error) {
+		refs = append(refs, ref)
+		return &Policy{Records: []Record{{Domain: filepath.Base(ref) + ".example.org", Classification: "NO_DPA", Rationale: "from sheet", LastReview: "2025-08-01", Status: "active"}}}, nil
+	}
+	p, err := LoadFile(root, fetch)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(refs) != 2 || refs[0] != "https://example.com/sheet.csv" || refs[1] != filepath.Join(d, "local.csv") {
+		t.Fatalf("unexpected fetch refs: %v", refs)
+	}
+	if len(p.Records) != 2 || p.Records[0].Origin != "https://example.com/sheet.csv" {
+		t.Fatalf("unexpected records: %+v", p.Records)
+	}
+	if _, err := LoadFile(root, nil); err == nil {
+		t.Fatalf("expected error for csv include without fetcher")
+	}
+}
+
+func TestLoadFileRejectsCyclesAndDanglingOverrides(t *testing.T) {
+	d := t.TempDir()
+	writePolicyFile(t, d, "a.yaml", "version: 0.1.0\nupdated: 2025-08-08\nincludes:\n  - b.yaml\n")
+	writePolicyFile(t, d, "b.yaml", "version: 0.1.0\nupdated: 2025-08-08\nincludes:\n  - a.yaml\n")
+	if _, err := LoadFile(filepath.Join(d, "a.yaml"), nil); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("expected include cycle error, got %v", err)
+	}
+	root := writePolicyFile(t, d, "c.yaml", "version: 0.1.0\nupdated: 2025-08-08\nrecords:\n"+
+		"  - domain: known.com\n    classification: NO_DPA\n"+includeRecord+
+		"overrides:\n  - domain: unknown.com\n    status: suspended\n")
+	if _, err := LoadFile(root, nil); err == nil || !strings.Contains(err.Error(), "unknown.com") {
+		t.Fatalf("expected dangling override error, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package policy
+
+import "strings"
+
+// index maps lowercased names to record positions so Lookup does not scan every record.
+// exact holds non-wildcard domains; wildcard holds the base of "*.base" records. Each slice
+// keeps policy order so the first enforced record wins among same-name duplicates.
+// Expiry and suspension are checked at lookup time, so the index stays valid across midnight.
+// classes caches the effective taxonomy by key.
+type index struct {
+	exact    map[string][]int
+	wildcard map[string][]int
+	classes  map[string]ClassificationDef
+}
+
+func buildIndex(p *Policy) *index {
+	records := p.Records
+	idx := &index{exact: make(map[string][]int, len(records)), wildcard: map[string][]int{}, classes: map[string]ClassificationDef{}}
+	for _, c := range p.Taxonomy() {
+		idx.classes[c.Key] = c
+	}
+	for i, r := range records {
+		d := lookupName(strings.ToLower(strings.TrimSpace(r.Domain)))
+		if base, ok := strings.CutPrefix(d, "*."); ok {
+			idx.wildcard[base] = append(idx.wildcard[base], i)
+		} else {
+			idx.exact[d] = append(idx.exact[d], i)
+		}
+	}
+	return idx
+}
+
+// BuildIndex (re)builds the lookup index from the current records. Call it after mutating
+// Records; Validate and the servers' UpdatePolicy do so automatically. Lookup builds the
+// index on first use if it is missing.
+func (p *Policy) BuildIndex() {
+	p.idx.Store(buildIndex(p))
+}
+
+func (p *Policy) index() *index {
+	if idx := p.idx.Load(); idx != nil {
+		return idx
+	}
+	idx := buildIndex(p)
+	p.idx.Store(idx)
+	return idx
+}
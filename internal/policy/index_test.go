@@ -0,0 +1,197 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLookupMostSpecificWildcard(t *testing.T) {
+	p := &Policy{Records: []Record{
+		{Domain: "*.example.com", Classification: "NO_DPA", Status: "active"},
+		{Domain: "*.cdn.example.com", Classification: "LEGAL_HOLD", Status: "active"},
+		{Domain: "assets.cdn.example.com", Classification: "OTHER", Status: "active"},
+		{Domain: "*.paused.example.com", Classification: "PENDING_REVIEW", Status: "suspended"},
+	}}
+	cases := map[string]string{
+		"example.com":              "NO_DPA",
+		"www.example.com":          "NO_DPA",
+		"cdn.example.com":          "LEGAL_HOLD",
+		"img.cdn.example.com":      "LEGAL_HOLD",
+		"assets.cdn.example.com":   "OTHER",
+		"x.assets.cdn.example.com": "LEGAL_HOLD",
+		"a.paused.example.com":     "NO_DPA", // suspended wildcard falls through to the broader one
+		"ASSETS.CDN.EXAMPLE.COM ":  "OTHER",
+		"notexample.com":           "",
+		"example.com.evil.test":    "",
+	}
+	for in, want := range cases {
+		rec, ok := p.Lookup(in)
+		got := ""
+		if ok {
+			got = rec.Classification
+		}
+		if got != want {
+			t.Errorf("Lookup(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildIndexAfterMutation(t *testing.T) {
+	p := &Policy{Records: []Record{{Domain: "one.com", Classification: "NO_DPA", Status: "active"}}}
+	if _, ok := p.Lookup("one.com"); !ok {
+		t.Fatalf("expected match")
+	}
+	p.Records = append(p.Records, Record{Domain: "two.com", Classification: "NO_DPA", Status: "active"})
+	p.BuildIndex()
+	if _, ok := p.Lookup("two.com"); !ok {
+		t.Fatalf("expected match after BuildIndex")
+	}
+}
+
+func TestLookupDoesNotAllocate(t *testing.T) {
+	p := benchPolicy(1000)
+	now := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	allocs := testing.AllocsPerRun(200, func() {
+		_, _ = p.LookupAt("d500.example.org", now)
+		_, _ = p.LookupAt("a.b.w250.example.net", now)
+		_, _ = p.LookupAt("miss.example.test", now)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected 0 allocations per lookup, got %v", allocs)
+	}
+}
+
+// benchPolicy builds n exact and n/10 wildcard records, some with expiry dates.
+func benchPolicy(n int) *Policy {
+	p := &Policy{Version: "0.1.0", Updated: "2025-08-08"}
+	for i := 0; i < n; i++ {
+		r := Record{Domain: fmt.Sprintf("d%d.example.org", i), Classification: "NO_DPA", Status: "active"}
+		if i%7 == 0 {
+			r.Expires = "2030-01-01"
+		}
+		p.Records = append(p.Records, r)
+	}
+	for i := 0; i < n/10; i++ {
+		p.Records = append(p.Records, Record{Domain: fmt.Sprintf("*.w%d.example.net", i), Classification: "EXPIRED_DPA", Status: "active"})
+	}
+	p.BuildIndex()
+	return p
+}
+
+// lookupLinear is the previous scan-every-record implementation, kept as the benchmark baseline.
+func lookupLinear(p *Policy, domain string) (*Record, bool) {
+	d := strings.ToLower(strings.TrimSpace(domain))
+	var wildcardMatch *Record
+	for i := range p.Records {
+		r := &p.Records[i]
+		if r.Status == "suspended" {
+			continue
+		}
+		if r.Domain == d {
+			return r, true
+		}
+		if strings.HasPrefix(r.Domain, "*.") {
+			base := strings.TrimPrefix(r.Domain, "*.")
+			if (d == base || strings.HasSuffix(d, "."+base)) && wildcardMatch == nil {
+				wildcardMatch = r
+			}
+		}
+	}
+	return wildcardMatch, wildcardMatch != nil
+}
+
+var benchNames = []string{"d49999.example.org", "a.b.w4000.example.net", "d12.example.org", "miss.example.test"}
+
+func BenchmarkLookupLinear50k(b *testing.B) {
+	p := benchPolicy(50000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = lookupLinear(p, benchNames[i%len(benchNames)])
+	}
+}
+
+func BenchmarkLookupIndexed50k(b *testing.B) {
+	p := benchPolicy(50000)
+	now := time.Now()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = p.LookupAt(benchNames[i%len(benchNames)], now)
+	}
+}
+
+func BenchmarkBuildIndex50k(b *testing.B) {
+	p := benchPolicy(50000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.BuildIndex()
+	}
+}
+
+func TestLookupExemptionPrecedence(t *testing.T) {
+	p := &Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []Record{
+		{Domain: "*.bigvendor.com", Classification: "NO_DPA", Status: "active"},
+		{Domain: "classroom.bigvendor.com", Action: ActionAllow, Status: "active"},
+		{Domain: "*.edu.bigvendor.com", Action: ActionAllow, Status: "active"},
+		{Domain: "*.ads.edu.bigvendor.com", Classification: "LEGAL_HOLD", Status: "active"},
+		{Domain: "tracker.edu.bigvendor.com", Classification: "OTHER", Status: "active"},
+		{Domain: "lapsed.bigvendor.com", Action: ActionAllow, Status: "active", Expires: "2025-01-31"},
+		{Domain: "paused.bigvendor.com", Action: ActionAllow, Status: "suspended"},
+	}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		domain  string
+		blocked bool
+		class   string
+	}{
+		{"bigvendor.com", true, "NO_DPA"},
+		{"mail.bigvendor.com", true, "NO_DPA"},
+		{"classroom.bigvendor.com", false, ""},        // exact exemption beats wildcard block
+		{"x.classroom.bigvendor.com", true, "NO_DPA"}, // exact exemption does not cover subdomains
+		{"edu.bigvendor.com", false, ""},              // more specific wildcard exemption
+		{"app.edu.bigvendor.com", false, ""},
+		{"ads.edu.bigvendor.com", true, "LEGAL_HOLD"}, // more specific block inside the exemption
+		{"tracker.edu.bigvendor.com", true, "OTHER"},  // exact block beats wildcard exemption
+		{"lapsed.bigvendor.com", true, "NO_DPA"},      // expired exemption lapses
+		{"paused.bigvendor.com", true, "NO_DPA"},      // suspended exemption is ignored
+	}
+	for _, c := range cases {
+		rec, ok := p.LookupAt(c.domain, now)
+		if ok != c.blocked || (ok && rec.Classification != c.class) {
+			t.Errorf("%s: got %#v ok=%v, want blocked=%v class=%s", c.domain, rec, ok, c.blocked, c.class)
+		}
+	}
+	if rec, ok := p.MatchAt("app.edu.bigvendor.com", now); !ok || !rec.Exempt() || rec.Domain != "*.edu.bigvendor.com" {
+		t.Fatalf("Match should return the winning exemption, got %#v ok=%v", rec, ok)
+	}
+	if err := p.SetExpiredClassification("EXPIRED_DPA"); err != nil {
+		t.Fatalf("set grace: %v", err)
+	}
+	if rec, ok := p.LookupAt("lapsed.bigvendor.com", now); !ok || rec.Classification != "NO_DPA" {
+		t.Fatalf("expired exemption should lapse in grace mode too, got %#v ok=%v", rec, ok)
+	}
+}
+
+func TestValidateAction(t *testing.T) {
+	p := &Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []Record{
+		{Domain: "ok.com", Action: ActionAllow, Status: "active"},
+	}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("exemption without classification should validate: %v", err)
+	}
+	p.Records[0].Action = "permit"
+	if err := p.Validate(); err == nil {
+		t.Fatalf("expected invalid action error")
+	}
+	p.Records[0].Action = ActionBlock
+	if err := p.Validate(); err == nil {
+		t.Fatalf("block record without classification should fail")
+	}
+}
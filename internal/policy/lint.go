@@ -0,0 +1,130 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Lint finding kinds.
+const (
+	LintConflict  = "conflict"  // same domain listed with different classifications; only the first applies
+	LintRedundant = "redundant" // rule already covered by an enclosing wildcard with the same classification
+	LintOverlap   = "overlap"   // rule overrides an enclosing wildcard with a different classification
+)
+
+// Finding is one lint diagnostic about overlapping or shadowed rules.
+type Finding struct {
+	Severity string `json:"severity"` // warning|info
+	Kind     string `json:"kind"`
+	Domain   string `json:"domain"`
+	Other    string `json:"other,omitempty"` // the related rule, when there is one
+	Detail   string `json:"detail"`
+}
+
+// Lint reports rules that overlap or shadow each other, independent of record order:
+//   - conflict (warning): the same domain appears with different classifications; Lookup
+//     returns the first and the rest never apply.
+//   - redundant (warning): an exact record or nested wildcard adds nothing because the
+//     nearest enclosing wildcard already applies the same classification.
+//   - overlap (info): a rule deliberately overrides its enclosing wildcard with a
+//     different classification (exact beats wildcard, most specific wildcard wins).
+//     Exemptions (action: allow) compare as the pseudo-classification "allow", so an
+//     exemption under a block is an overlap, and one with no enclosing block is redundant.
+//
+// Rules whose scopes are disjoint (e.g. students-only vs staff-only) never apply to the same
+// audience and are not compared.
+//
+// Suspended records are skipped. Findings are sorted by domain, then kind.
+func (p *Policy) Lint() []Finding {
+	var out []Finding
+	byName := map[string][]Record{}
+	var names []string
+	for _, r := range p.Records {
+		if r.Status == "suspended" {
+			continue
+		}
+		d := strings.ToLower(strings.TrimSpace(r.Domain))
+		if _, ok := byName[d]; !ok {
+			names = append(names, d)
+		}
+		byName[d] = append(byName[d], r)
+	}
+	// enclosing returns the nearest wildcard strictly above name (for "*.b", above b's own base).
+	enclosing := func(name string) (string, Record, bool) {
+		base, isWildcard := strings.CutPrefix(name, "*.")
+		if isWildcard {
+			dot := strings.IndexByte(base, '.')
+			if dot < 0 {
+				return "", Record{}, false
+			}
+			base = base[dot+1:]
+		}
+		for {
+			if rs, ok := byName["*."+base]; ok {
+				return "*." + base, rs[0], true
+			}
+			dot := strings.IndexByte(base, '.')
+			if dot < 0 {
+				return "", Record{}, false
+			}
+			base = base[dot+1:]
+		}
+	}
+	for _, d := range names {
+		rs := byName[d]
+		if len(rs) > 1 && conflicting(rs) {
+			classes := make([]string, len(rs))
+			for i, r := range rs {
+				classes[i] = ruleLabel(r)
+			}
+			out = append(out, Finding{Severity: "warning", Kind: LintConflict, Domain: d,
+				Detail: fmt.Sprintf("listed with classifications %s; only %s applies", strings.Join(classes, ", "), classes[0])})
+		}
+		other, w, ok := enclosing(d)
+		if !ok {
+			if rs[0].Exempt() {
+				out = append(out, Finding{Severity: "warning", Kind: LintRedundant, Domain: d,
+					Detail: "exemption is not covered by any wildcard block"})
+			}
+			continue
+		}
+		if !scopesOverlap(w, rs[0]) {
+			continue
+		}
+		if ruleLabel(w) == ruleLabel(rs[0]) {
+			out = append(out, Finding{Severity: "warning", Kind: LintRedundant, Domain: d, Other: other,
+				Detail: fmt.Sprintf("already covered by %s with classification %s", other, ruleLabel(w))})
+		} else {
+			out = append(out, Finding{Severity: "info", Kind: LintOverlap, Domain: d, Other: other,
+				Detail: fmt.Sprintf("overrides %s (%s) with %s", other, ruleLabel(w), ruleLabel(rs[0]))})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Domain != out[j].Domain {
+			return out[i].Domain < out[j].Domain
+		}
+		return out[i].Kind < out[j].Kind
+	})
+	return out
+}
+
+// ruleLabel is the classification used when comparing rules; exemptions compare as "allow".
+func ruleLabel(r Record) string {
+	if r.Exempt() {
+		return ActionAllow
+	}
+	return r.Classification
+}
+
+// conflicting reports whether two records for the same name can apply to the same audience.
+func conflicting(rs []Record) bool {
+	for i := range rs {
+		for j := i + 1; j < len(rs); j++ {
+			if scopesOverlap(rs[i], rs[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
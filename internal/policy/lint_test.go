@@ -0,0 +1,55 @@
+package policy
+
+import "testing"
+
+func TestLint(t *testing.T) {
+	p := &Policy{Records: []Record{
+		{Domain: "*.example.com", Classification: "NO_DPA", Status: "active"},
+		{Domain: "*.cdn.example.com", Classification: "LEGAL_HOLD", Status: "active"},
+		{Domain: "www.example.com", Classification: "NO_DPA", Status: "active"},
+		{Domain: "*.img.example.com", Classification: "NO_DPA", Status: "active"},
+		{Domain: "dual.org", Classification: "NO_DPA", Status: "active"},
+		{Domain: "dual.org", Classification: "OTHER", Status: "active"},
+		{Domain: "paused.example.com", Classification: "NO_DPA", Status: "suspended"},
+		{Domain: "standalone.net", Classification: "OTHER", Status: "active"},
+	}}
+	got := p.Lint()
+	want := []Finding{
+		{Severity: "info", Kind: LintOverlap, Domain: "*.cdn.example.com", Other: "*.example.com"},
+		{Severity: "warning", Kind: LintRedundant, Domain: "*.img.example.com", Other: "*.example.com"},
+		{Severity: "warning", Kind: LintConflict, Domain: "dual.org"},
+		{Severity: "warning", Kind: LintRedundant, Domain: "www.example.com", Other: "*.example.com"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d findings, want %d: %#v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Severity != w.Severity || g.Kind != w.Kind || g.Domain != w.Domain || g.Other != w.Other || g.Detail == "" {
+			t.Errorf("finding %d: got %#v want %#v", i, g, w)
+		}
+	}
+}
+
+func TestLintOrderIndependent(t *testing.T) {
+	a := &Policy{Records: []Record{
+		{Domain: "*.cdn.example.com", Classification: "NO_DPA", Status: "active"},
+		{Domain: "*.example.com", Classification: "NO_DPA", Status: "active"},
+	}}
+	if f := a.Lint(); len(f) != 1 || f[0].Kind != LintRedundant || f[0].Domain != "*.cdn.example.com" {
+		t.Fatalf("unexpected findings: %#v", f)
+	}
+}
+
+func TestLintExemptions(t *testing.T) {
+	p := &Policy{Records: []Record{
+		{Domain: "*.bigvendor.com", Classification: "NO_DPA", Status: "active"},
+		{Domain: "classroom.bigvendor.com", Action: ActionAllow, Status: "active"},
+		{Domain: "orphan.org", Action: ActionAllow, Status: "active"},
+	}}
+	got := p.Lint()
+	if len(got) != 2 || got[0].Domain != "classroom.bigvendor.com" || got[0].Kind != LintOverlap ||
+		got[1].Domain != "orphan.org" || got[1].Kind != LintRedundant {
+		t.Fatalf("unexpected findings: %#v", got)
+	}
+}
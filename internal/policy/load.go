@@ -28,7 +28,7 @@ func Load(b []byte) (*Policy, error) {
 	if err := yaml.Unmarshal(b, &p); err != nil {
 		return nil, err
 	}
-	if len(p.Records) == 0 {
+	if len(p.Records) == 0 && len(p.Includes) == 0 && len(p.Classifications) == 0 {
 		return nil, errors.New("no records")
 	}
 	return &p, nil
@@ -8,43 +8,113 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // Record represents a single policy entry in the policy dataset
 type Record struct {
-	Domain         string   `yaml:"domain" json:"domain"`
-	Classification string   `yaml:"classification" json:"classification"`
-	Rationale      string   `yaml:"rationale" json:"rationale"`
-	LastReview     string   `yaml:"last_review" json:"last_review"`
-	Status         string   `yaml:"status" json:"status"`
-	Notes          string   `yaml:"notes,omitempty" json:"notes,omitempty"`
-	SourceRef      string   `yaml:"source_ref,omitempty" json:"source_ref,omitempty"`
-	Expires        string   `yaml:"expires,omitempty" json:"expires,omitempty"`
-	Tags           []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Domain         string `yaml:"domain" json:"domain"`
+	Classification string `yaml:"classification" json:"classification"`
+	Rationale      string `yaml:"rationale" json:"rationale"`
+	// RationaleTranslations holds the rationale in other languages, keyed by language tag
+	// ("es", "so", "ne"); the explain page shows the one matching the reader's language.
+	RationaleTranslations map[string]string `yaml:"rationale_translations,omitempty" json:"rationale_translations,omitempty"`
+	LastReview            string            `yaml:"last_review" json:"last_review"`
+	Status                string            `yaml:"status" json:"status"`
+	Notes                 string            `yaml:"notes,omitempty" json:"notes,omitempty"`
+	SourceRef             string            `yaml:"source_ref,omitempty" json:"source_ref,omitempty"`
+	Expires               string            `yaml:"expires,omitempty" json:"expires,omitempty"`
+	Tags                  []string          `yaml:"tags,omitempty" json:"tags,omitempty"`
+	// Action is ActionBlock (default when empty) or ActionAllow. An allow record is an
+	// exemption that carves a hole in a broader block, e.g. classroom.bigvendor.com under
+	// *.bigvendor.com; its classification is optional.
+	Action string `yaml:"action,omitempty" json:"action,omitempty"`
+	// Scopes limits the record to audiences (e.g. students, staff, building-12); empty means
+	// every audience. See ForScope and LookupScopedAt.
+	Scopes []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+	// passive is set by Validate when the record's classification is non-blocking.
+	passive bool
+	// UnicodeDomain is the U-label form of an internationalized Domain, derived by Validate
+	// (Domain always holds the A-label form). Empty for plain ASCII names.
+	UnicodeDomain string `yaml:"-" json:"unicode_domain,omitempty"`
+	// Origin names the file or sheet that defined the record and OverriddenBy the files whose
+	// overrides changed it (set by LoadFile; empty for single-document loads).
+	Origin       string   `yaml:"-" json:"origin,omitempty"`
+	OverriddenBy []string `yaml:"-" json:"overridden_by,omitempty"`
 }
 
+// Record actions.
+const (
+	ActionBlock = "block"
+	ActionAllow = "allow"
+)
+
+// Exempt reports whether the record lets matching names through rather than blocking them:
+// an allow (exemption) record, or one whose classification the taxonomy marks as
+// non-blocking (blocks: false). Use r.Action == ActionAllow to tell the two apart.
+func (r Record) Exempt() bool { return r.Action == ActionAllow || r.passive }
+
 // Policy is the root structure for a policy file
 type Policy struct {
-	Version  string   `yaml:"version" json:"version"`
-	Updated  string   `yaml:"updated" json:"updated"`
-	Records  []Record `yaml:"records" json:"records"`
+	Version string   `yaml:"version" json:"version"`
+	Updated string   `yaml:"updated" json:"updated"`
+	Records []Record `yaml:"records" json:"records"`
+	// Classifications adds keys to, or restyles, the built-in taxonomy; see Taxonomy.
+	Classifications []ClassificationDef `yaml:"classifications,omitempty" json:"classifications,omitempty"`
+	// Includes and Overrides layer other sources under this file; see LoadFile.
+	Includes  []string   `yaml:"includes,omitempty" json:"includes,omitempty"`
+	Overrides []Override `yaml:"overrides,omitempty" json:"overrides,omitempty"`
+	// Files lists the local files LoadFile read: the root file first, then YAML and CSV
+	// includes in load order. Empty for single-document loads.
+	Files    []string `yaml:"-" json:"-"`
 	Metadata *struct {
 		GeneratedHash string `yaml:"generated_hash,omitempty" json:"generated_hash,omitempty"`
 		Source        string `yaml:"source,omitempty" json:"source,omitempty"`
 		Notes         string `yaml:"notes,omitempty" json:"notes,omitempty"`
 	} `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+
+	idx atomic.Pointer[index]
+	// expiredClass is the grace classification; see SetExpiredClassification.
+	expiredClass string
+	// dropped counts expired records removed by WithoutExpired.
+	dropped int
 }
 
-var domainPattern = regexp.MustCompile(`^(\*\.)?([a-z0-9-]{1,63}\.)+[a-z]{2,63}$`)
+// domainPattern validates the A-label (ASCII) form; IDN TLDs appear as "xn--" labels.
+var domainPattern = regexp.MustCompile(`^(\*\.)?([a-z0-9-]{1,63}\.)+([a-z]{2,63}|xn--[a-z0-9-]{1,59})$`)
 
 // CanonicalHash computes a deterministic SHA-256 over active records (simple normalization of select fields)
+// and any classifications the policy defines or restyles
 func (p *Policy) CanonicalHash() string {
 	var lines []string
 	for _, r := range p.Records {
 		if r.Status != "active" && r.Status != "" { // include empty as active until full validation exists
 			continue
 		}
-		lines = append(lines, fmt.Sprintf("%s|%s|%s|%s|%s", r.Domain, r.Classification, r.Rationale, r.LastReview, r.Status))
+		line := fmt.Sprintf("%s|%s|%s|%s|%s", r.Domain, r.Classification, r.Rationale, r.LastReview, r.Status)
+		// Appended only when set so hashes of policies without these fields are unchanged.
+		if r.Action == ActionAllow {
+			line += "|" + ActionAllow
+		}
+		if len(r.Scopes) > 0 {
+			line += "|scopes=" + strings.Join(r.Scopes, ",")
+		}
+		for _, tag := range sortedKeys(r.RationaleTranslations) {
+			line += "|rationale." + tag + "=" + r.RationaleTranslations[tag]
+		}
+		lines = append(lines, line)
+	}
+	// The policy's taxonomy decides what blocks and how it is labelled; only customised keys
+	// are hashed so policies on the built-in taxonomy keep their hashes.
+	custom := map[string]bool{}
+	for _, c := range p.Classifications {
+		custom[c.Key] = true
+	}
+	for _, c := range p.Taxonomy() {
+		if custom[c.Key] {
+			lines = append(lines, fmt.Sprintf("classification=%s|%s|%s|%s|%s|blocks=%t", c.Key, c.Label, c.Description, c.Color, c.Severity, c.Blocking()))
+		}
 	}
 	sort.Strings(lines)
 	h := sha256.Sum256([]byte(fmt.Sprintln(lines)))
@@ -59,24 +129,58 @@ func (p *Policy) Validate() error {
 	if p.Updated == "" {
 		return errors.New("updated required")
 	}
+	classes, err := p.validateTaxonomy()
+	if err != nil {
+		return err
+	}
+	if p.expiredClass != "" {
+		if _, ok := classes[p.expiredClass]; !ok {
+			return fmt.Errorf("expired classification %s is not defined in the taxonomy", p.expiredClass)
+		}
+	}
 	seen := map[string]struct{}{}
 	for i, r := range p.Records {
 		if r.Domain == "" {
 			return fmt.Errorf("record %d: domain empty", i)
 		}
-		d := strings.ToLower(r.Domain)
+		d, u, err := NormalizeDomain(r.Domain)
+		if err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
 		if !domainPattern.MatchString(d) {
 			return fmt.Errorf("record %d: domain invalid: %s", i, r.Domain)
 		}
-		if _, ok := seen[d+"|"+r.Classification]; ok {
+		scopes := make([]string, 0, len(r.Scopes))
+		for _, s := range r.Scopes {
+			s = NormalizeScope(s)
+			if !scopePattern.MatchString(s) {
+				return fmt.Errorf("record %d: invalid scope %q", i, s)
+			}
+			scopes = append(scopes, s)
+		}
+		sort.Strings(scopes)
+		translations, err := normalizeTranslations(r.RationaleTranslations)
+		if err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+		key := d + "|" + r.Classification + "|" + strings.Join(scopes, ",")
+		if _, ok := seen[key]; ok {
 			return fmt.Errorf("record %d: duplicate domain+classification combo: %s", i, r.Domain)
 		}
-		seen[d+"|"+r.Classification] = struct{}{}
-		// Basic classification/status checks
-		switch r.Classification {
-		case "NO_DPA", "PENDING_REVIEW", "EXPIRED_DPA", "LEGAL_HOLD", "OTHER":
+		seen[key] = struct{}{}
+		// Basic action/classification/status checks
+		class, known := classes[r.Classification]
+		switch r.Action {
+		case "", ActionBlock:
+			if !known {
+				return fmt.Errorf("record %d: invalid classification %s", i, r.Classification)
+			}
+		case ActionAllow:
+			if r.Classification != "" && !known {
+				return fmt.Errorf("record %d: invalid classification %s", i, r.Classification)
+			}
 		default:
-			return fmt.Errorf("record %d: invalid classification %s", i, r.Classification)
+			return fmt.Errorf("record %d: invalid action %s", i, r.Action)
 		}
 		switch r.Status {
 		case "active", "suspended", "": // empty treated as active
@@ -84,45 +188,105 @@ func (p *Policy) Validate() error {
 			return fmt.Errorf("record %d: invalid status %s", i, r.Status)
 		}
 		p.Records[i].Domain = d
+		p.Records[i].UnicodeDomain = u
+		p.Records[i].passive = known && !class.Blocking()
+		if len(scopes) > 0 {
+			p.Records[i].Scopes = scopes
+		}
+		p.Records[i].RationaleTranslations = translations
 	}
+	p.BuildIndex()
 	return nil
 }
 
+// ValidClassification reports whether c is one of the built-in classification keys
+// (DefaultTaxonomy). Policies may define more; see Policy.Taxonomy.
+func ValidClassification(c string) bool {
+	for _, d := range DefaultTaxonomy {
+		if d.Key == c {
+			return true
+		}
+	}
+	return false
+}
+
 // Lookup attempts to find a record for the provided domain (case-insensitive),
 // supporting wildcard entries of the form "*.example.com". Matching rules:
 //  1. Exact domain match (after lowercasing)
 //  2. Wildcard record where record.Domain is "*.example.com" matches either
 //     the base domain "example.com" or any subdomain that ends with ".example.com".
+//     When several wildcards cover the name, the most specific (longest base) wins.
 //
-// Suspended records are ignored. Returns the first matching active record
-// (prioritizing exact match over wildcard matches). If multiple wildcard
-// records could match (should not happen under validation rules), the first
-// encountered is returned.
+// Suspended records are ignored, as are expired records unless grace mode
+// (SetExpiredClassification) is on, in which case the returned record carries
+// the grace classification. Exact matches take priority over wildcards; among
+// records for the same name the first in policy order wins. When the winning
+// rule is an exemption (action: allow) the domain is not blocked and Lookup
+// reports no match; use Match to see the exemption itself. Lookups use an
+// index built once per policy and do not allocate outside grace mode.
 func (p *Policy) Lookup(domain string) (*Record, bool) {
-	d := strings.ToLower(strings.TrimSpace(domain))
+	return p.LookupAt(domain, time.Now())
+}
+
+// LookupAt is Lookup with expiry evaluated at now.
+func (p *Policy) LookupAt(domain string, now time.Time) (*Record, bool) {
+	r, ok := p.MatchAt(domain, now)
+	if !ok || r.Exempt() {
+		return nil, false
+	}
+	return r, true
+}
+
+// Match returns the most specific rule covering domain, block or exemption, using the
+// same precedence as Lookup.
+func (p *Policy) Match(domain string) (*Record, bool) {
+	return p.MatchAt(domain, time.Now())
+}
+
+// MatchAt is Match with expiry evaluated at now.
+func (p *Policy) MatchAt(domain string, now time.Time) (*Record, bool) {
+	return p.match(domain, "", now)
+}
+
+// match implements MatchAt and MatchScopedAt; scope "" considers every record.
+func (p *Policy) match(domain, scope string, now time.Time) (*Record, bool) {
+	d := lookupName(strings.ToLower(strings.TrimSpace(domain)))
 	if d == "" {
 		return nil, false
 	}
-	var wildcardMatch *Record
-	for i := range p.Records {
+	idx := p.index()
+	if r, ok := p.firstEnforced(idx.exact[d], scope, now); ok {
+		return r, true
+	}
+	// Walk from the full name up through parent domains: the first wildcard base hit is the most specific.
+	for name := d; ; {
+		if r, ok := p.firstEnforced(idx.wildcard[name], scope, now); ok {
+			return r, true
+		}
+		dot := strings.IndexByte(name, '.')
+		if dot < 0 {
+			return nil, false
+		}
+		name = name[dot+1:]
+	}
+}
+
+// firstEnforced returns the first record among positions that applies to scope at now.
+func (p *Policy) firstEnforced(positions []int, scope string, now time.Time) (*Record, bool) {
+	for _, i := range positions {
 		r := &p.Records[i]
-		if r.Status == "suspended" { // ignore suspended
+		if r.Status == "suspended" || !r.InScope(scope) {
 			continue
 		}
-		if r.Domain == d { // exact
-			return r, true
-		}
-		if strings.HasPrefix(r.Domain, "*.") {
-			base := strings.TrimPrefix(r.Domain, "*.")
-			if d == base || strings.HasSuffix(d, "."+base) {
-				if wildcardMatch == nil { // keep first wildcard match
-					wildcardMatch = r
-				}
+		if r.Expired(now) {
+			if p.expiredClass == "" || r.Exempt() {
+				continue
 			}
+			g := *r
+			g.Classification = p.expiredClass
+			return &g, true
 		}
-	}
-	if wildcardMatch != nil {
-		return wildcardMatch, true
+		return r, true
 	}
 	return nil, false
 }
@@ -0,0 +1,106 @@
+package policy
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// scopePattern constrains audience names such as "students", "staff" or "building-12".
+var scopePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// InScope reports whether the record applies to the audience scope. Records without scopes
+// apply to every audience, and the empty scope selects every record (the unscoped view).
+func (r Record) InScope(scope string) bool {
+	if scope == "" || len(r.Scopes) == 0 {
+		return true
+	}
+	for _, s := range r.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Scopes returns the distinct audience names declared by any record, sorted.
+func (p *Policy) Scopes() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, r := range p.Records {
+		for _, s := range r.Scopes {
+			if !seen[s] {
+				seen[s] = true
+				out = append(out, s)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// HasScope reports whether scope is empty or declared by at least one record, so callers can
+// reject a mistyped audience instead of silently serving only the unscoped records.
+func (p *Policy) HasScope(scope string) bool {
+	if scope == "" {
+		return true
+	}
+	for _, r := range p.Records {
+		for _, s := range r.Scopes {
+			if s == scope {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ForScope returns the view of the policy for one audience: the records that apply to scope,
+// in policy order, with the index built. Everything else about p (taxonomy, source files, grace mode)
+// carries over, so the view validates and looks up like p. The empty scope returns p itself.
+func (p *Policy) ForScope(scope string) *Policy {
+	scope = NormalizeScope(scope)
+	if scope == "" {
+		return p
+	}
+	v := &Policy{Version: p.Version, Updated: p.Updated, Classifications: p.Classifications, Includes: p.Includes,
+		Overrides: p.Overrides, Files: p.Files, Metadata: p.Metadata, expiredClass: p.expiredClass}
+	for _, r := range p.Records {
+		if r.InScope(scope) {
+			v.Records = append(v.Records, r)
+		}
+	}
+	v.BuildIndex()
+	return v
+}
+
+// LookupScopedAt is LookupAt restricted to the records that apply to scope.
+func (p *Policy) LookupScopedAt(domain, scope string, now time.Time) (*Record, bool) {
+	r, ok := p.MatchScopedAt(domain, scope, now)
+	if !ok || r.Exempt() {
+		return nil, false
+	}
+	return r, true
+}
+
+// MatchScopedAt is MatchAt restricted to the records that apply to scope.
+func (p *Policy) MatchScopedAt(domain, scope string, now time.Time) (*Record, bool) {
+	return p.match(domain, NormalizeScope(scope), now)
+}
+
+// NormalizeScope returns the canonical (trimmed, lower-case) form of an audience name.
+func NormalizeScope(s string) string { return strings.ToLower(strings.TrimSpace(s)) }
+
+// scopesOverlap reports whether two records can apply to the same audience.
+func scopesOverlap(a, b Record) bool {
+	if len(a.Scopes) == 0 || len(b.Scopes) == 0 {
+		return true
+	}
+	for _, s := range a.Scopes {
+		if b.InScope(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package policy
+
+import (
+	"testing"
+	"time"
+)
+
+func scopedPolicy(t *testing.T) *Policy {
+	t.Helper()
+	p := &Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []Record{
+		{Domain: "everyone.com", Classification: "NO_DPA", Status: "active"},
+		{Domain: "*.games.com", Classification: "OTHER", Status: "active", Scopes: []string{"Students"}},
+		{Domain: "staffonly.com", Classification: "PENDING_REVIEW", Status: "active", Scopes: []string{"staff"}},
+		{Domain: "*.bigvendor.com", Classification: "NO_DPA", Status: "active"},
+		{Domain: "grading.bigvendor.com", Action: ActionAllow, Status: "active", Scopes: []string{"staff"}},
+	}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	return p
+}
+
+func TestLookupScoped(t *testing.T) {
+	p := scopedPolicy(t)
+	now := time.Now()
+	cases := []struct {
+		domain, scope string
+		blocked       bool
+	}{
+		{"everyone.com", "students", true},
+		{"everyone.com", "staff", true},
+		{"play.games.com", "students", true},
+		{"play.games.com", "staff", false},
+		{"play.games.com", "", true}, // unscoped view sees every record
+		{"staffonly.com", "students", false},
+		{"staffonly.com", "STAFF", true},
+		{"grading.bigvendor.com", "staff", false}, // staff-only exemption
+		{"grading.bigvendor.com", "students", true},
+	}
+	for _, c := range cases {
+		if _, ok := p.LookupScopedAt(c.domain, c.scope, now); ok != c.blocked {
+			t.Errorf("%s scope=%q: blocked=%v want %v", c.domain, c.scope, ok, c.blocked)
+		}
+	}
+}
+
+func TestForScopeAndScopes(t *testing.T) {
+	p := scopedPolicy(t)
+	if got := p.Scopes(); len(got) != 2 || got[0] != "staff" || got[1] != "students" {
+		t.Fatalf("unexpected scopes (should be lowercased, sorted): %v", got)
+	}
+	if !p.HasScope("") || !p.HasScope("staff") || p.HasScope("studnets") {
+		t.Fatalf("unexpected HasScope results")
+	}
+	v := p.ForScope("students")
+	if len(v.Records) != 3 || v.Version != p.Version {
+		t.Fatalf("students view should keep 3 records, got %+v", v.Records)
+	}
+	if _, ok := v.Lookup("grading.bigvendor.com"); !ok {
+		t.Fatalf("students view should not carry the staff exemption")
+	}
+	if p.ForScope("") != p {
+		t.Fatalf("empty scope should return the policy itself")
+	}
+	if p.CanonicalHash() == v.CanonicalHash() {
+		t.Fatalf("scoped view should hash differently")
+	}
+}
+
+func TestForScopeKeepsPolicySettings(t *testing.T) {
+	p := scopedPolicy(t)
+	p.Files = []string{"domains.yaml"}
+	p.Records[1].Expires = "2025-08-01"
+	if err := p.SetExpiredClassification("EXPIRED_DPA"); err != nil {
+		t.Fatalf("set grace: %v", err)
+	}
+	v := p.ForScope("students")
+	if len(v.Files) != 1 || v.ExpiredClassification() != "EXPIRED_DPA" {
+		t.Fatalf("scoped view lost policy settings: files=%v grace=%q", v.Files, v.ExpiredClassification())
+	}
+	if rec, ok := v.Lookup("play.games.com"); !ok || rec.Classification != "EXPIRED_DPA" {
+		t.Fatalf("scoped view should keep grace mode, got %#v ok=%v", rec, ok)
+	}
+	if err := v.Validate(); err != nil {
+		t.Fatalf("scoped view should validate: %v", err)
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	p := &Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []Record{
+		{Domain: "a.com", Classification: "NO_DPA", Status: "active", Scopes: []string{"bad scope"}},
+	}}
+	if err := p.Validate(); err == nil {
+		t.Fatalf("expected invalid scope error")
+	}
+	p.Records = []Record{
+		{Domain: "a.com", Classification: "NO_DPA", Status: "active", Scopes: []string{"students"}},
+		{Domain: "a.com", Classification: "NO_DPA", Status: "active", Scopes: []string{"staff"}},
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("same domain+classification in different scopes should validate: %v", err)
+	}
+	if got := p.Lint(); len(got) != 0 {
+		t.Fatalf("disjoint scopes should not lint as conflicts: %#v", got)
+	}
+}
@@ -0,0 +1,130 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ClassificationDef describes one classification key: how it is presented on the explain page
+// and whether records carrying it block. A policy can add keys or restyle built-in ones through
+// its classifications section (see Taxonomy).
+type ClassificationDef struct {
+	Key         string `yaml:"key" json:"key"`
+	Label       string `yaml:"label,omitempty" json:"label,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Color       string `yaml:"color,omitempty" json:"color,omitempty"`       // hex badge colour, e.g. "#d9534f"
+	Severity    string `yaml:"severity,omitempty" json:"severity,omitempty"` // low|medium|high
+	// Blocks defaults to true. When false, records with this classification are informational:
+	// they match (and win over broader rules) but do not redirect, like an exemption.
+	Blocks *bool `yaml:"blocks,omitempty" json:"blocks,omitempty"`
+}
+
+// Blocking reports whether records with this classification are enforced.
+func (c ClassificationDef) Blocking() bool { return c.Blocks == nil || *c.Blocks }
+
+// DisplayLabel returns the human label, falling back to the key.
+func (c ClassificationDef) DisplayLabel() string {
+	if c.Label != "" {
+		return c.Label
+	}
+	return c.Key
+}
+
+// DefaultTaxonomy is the built-in classification set every policy starts from.
+var DefaultTaxonomy = []ClassificationDef{
+	{Key: "NO_DPA", Label: "No Data Privacy Agreement", Description: "The provider has not signed a student data privacy agreement with the district.", Color: "#d9534f", Severity: "high"},
+	{Key: "PENDING_REVIEW", Label: "Pending Review", Description: "The service is being reviewed by the district and is unavailable until the review completes.", Color: "#e0a100", Severity: "medium"},
+	{Key: "EXPIRED_DPA", Label: "Data Privacy Agreement Expired", Description: "The provider's data privacy agreement has expired and has not yet been renewed.", Color: "#e8590c", Severity: "high"},
+	{Key: "LEGAL_HOLD", Label: "Legal Hold", Description: "Access is suspended while a legal or compliance matter is resolved.", Color: "#7048e8", Severity: "high"},
+	{Key: "OTHER", Label: "Restricted", Description: "The district has restricted this service.", Color: "#6c757d", Severity: "medium"},
+}
+
+var (
+	classKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,63}$`)
+	colorPattern    = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+)
+
+// ValidClassificationKey reports whether c is well-formed as a classification key; whether it
+// is defined depends on the policy's taxonomy.
+func ValidClassificationKey(c string) bool { return classKeyPattern.MatchString(c) }
+
+// Taxonomy returns the effective classification set: DefaultTaxonomy overlaid by the policy's
+// classifications (non-empty fields of an entry with a built-in key replace the built-in ones;
+// new keys are appended in order).
+func (p *Policy) Taxonomy() []ClassificationDef {
+	return mergeTaxonomy(DefaultTaxonomy, p.Classifications)
+}
+
+// Classification returns the definition for key from the policy's taxonomy.
+func (p *Policy) Classification(key string) (ClassificationDef, bool) {
+	if idx := p.idx.Load(); idx != nil && idx.classes != nil {
+		c, ok := idx.classes[key]
+		return c, ok
+	}
+	for _, c := range p.Taxonomy() {
+		if c.Key == key {
+			return c, true
+		}
+	}
+	return ClassificationDef{}, false
+}
+
+func mergeTaxonomy(base, extra []ClassificationDef) []ClassificationDef {
+	out := append([]ClassificationDef(nil), base...)
+	pos := map[string]int{}
+	for i, c := range out {
+		pos[c.Key] = i
+	}
+	for _, c := range extra {
+		i, ok := pos[c.Key]
+		if !ok {
+			pos[c.Key] = len(out)
+			out = append(out, c)
+			continue
+		}
+		cur := &out[i]
+		if c.Label != "" {
+			cur.Label = c.Label
+		}
+		if c.Description != "" {
+			cur.Description = c.Description
+		}
+		if c.Color != "" {
+			cur.Color = c.Color
+		}
+		if c.Severity != "" {
+			cur.Severity = c.Severity
+		}
+		if c.Blocks != nil {
+			cur.Blocks = c.Blocks
+		}
+	}
+	return out
+}
+
+// validateTaxonomy checks the policy's classification entries and returns the effective set by key.
+func (p *Policy) validateTaxonomy() (map[string]ClassificationDef, error) {
+	seen := map[string]bool{}
+	for i, c := range p.Classifications {
+		if !ValidClassificationKey(c.Key) {
+			return nil, fmt.Errorf("classification %d: invalid key %q", i, c.Key)
+		}
+		if seen[c.Key] {
+			return nil, fmt.Errorf("classification %d: duplicate key %s", i, c.Key)
+		}
+		seen[c.Key] = true
+		if c.Color != "" && !colorPattern.MatchString(c.Color) {
+			return nil, fmt.Errorf("classification %s: invalid color %q (use #rgb or #rrggbb)", c.Key, c.Color)
+		}
+		switch c.Severity {
+		case "", "low", "medium", "high":
+		default:
+			return nil, fmt.Errorf("classification %s: invalid severity %s", c.Key, c.Severity)
+		}
+	}
+	classes := map[string]ClassificationDef{}
+	for _, c := range p.Taxonomy() {
+		classes[c.Key] = c
+	}
+	return classes, nil
+}
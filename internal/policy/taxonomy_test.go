@@ -0,0 +1,131 @@
+package policy
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTaxonomyExtendsAndRestylesBuiltins(t *testing.T) {
+	no := false
+	p := &Policy{Version: "0.1.0", Updated: "2025-08-08",
+		Classifications: []ClassificationDef{
+			{Key: "PARENT_CONSENT_REQUIRED", Label: "Parent Consent Required", Description: "A guardian must opt in.", Color: "#1c7ed6", Severity: "medium"},
+			{Key: "NO_DPA", Label: "No Signed DPA"},
+			{Key: "MONITOR", Label: "Monitored", Blocks: &no},
+		},
+		Records: []Record{
+			{Domain: "consent.com", Classification: "PARENT_CONSENT_REQUIRED", Status: "active"},
+			{Domain: "*.bigvendor.com", Classification: "NO_DPA", Status: "active"},
+			{Domain: "watched.bigvendor.com", Classification: "MONITOR", Status: "active"},
+		}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	c, ok := p.Classification("PARENT_CONSENT_REQUIRED")
+	if !ok || c.DisplayLabel() != "Parent Consent Required" || !c.Blocking() {
+		t.Fatalf("custom class not resolved: %+v ok=%v", c, ok)
+	}
+	if c, _ := p.Classification("NO_DPA"); c.Label != "No Signed DPA" || c.Color == "" || c.Severity != "high" {
+		t.Fatalf("restyled built-in should keep unset fields: %+v", c)
+	}
+	if _, ok := p.Lookup("consent.com"); !ok {
+		t.Fatalf("custom blocking class should block")
+	}
+	if _, ok := p.Lookup("watched.bigvendor.com"); ok {
+		t.Fatalf("non-blocking class should not block, even under a wildcard block")
+	}
+	if rec, ok := p.Match("watched.bigvendor.com"); !ok || rec.Classification != "MONITOR" || !rec.Exempt() {
+		t.Fatalf("Match should return the informational record, got %#v ok=%v", rec, ok)
+	}
+	if n := len(p.Taxonomy()); n != len(DefaultTaxonomy)+2 {
+		t.Fatalf("expected %d taxonomy entries, got %d", len(DefaultTaxonomy)+2, n)
+	}
+}
+
+func TestValidateTaxonomy(t *testing.T) {
+	base := func() *Policy {
+		return &Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []Record{{Domain: "a.com", Classification: "CUSTOM", Status: "active"}}}
+	}
+	p := base()
+	if err := p.Validate(); err == nil {
+		t.Fatalf("undefined classification should fail")
+	}
+	for _, bad := range []ClassificationDef{
+		{Key: "lower"},
+		{Key: "CUSTOM", Color: "red;background:url(x)"},
+		{Key: "CUSTOM", Severity: "extreme"},
+	} {
+		p = base()
+		p.Classifications = []ClassificationDef{bad}
+		if err := p.Validate(); err == nil {
+			t.Fatalf("expected error for %+v", bad)
+		}
+	}
+	p = base()
+	p.Classifications = []ClassificationDef{{Key: "CUSTOM"}, {Key: "CUSTOM"}}
+	if err := p.Validate(); err == nil {
+		t.Fatalf("expected duplicate key error")
+	}
+	p = base()
+	p.Classifications = []ClassificationDef{{Key: "CUSTOM", Color: "#abc"}}
+	if err := p.SetExpiredClassification("GRACE_PERIOD"); err == nil || p.ExpiredClassification() != "" {
+		t.Fatalf("expected error for undefined grace classification")
+	}
+	p.Classifications = append(p.Classifications, ClassificationDef{Key: "GRACE_PERIOD"})
+	if err := p.SetExpiredClassification("GRACE_PERIOD"); err != nil {
+		t.Fatalf("set grace: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	// A taxonomy that later loses the grace key no longer validates.
+	p.Classifications = p.Classifications[:1]
+	if err := p.Validate(); err == nil {
+		t.Fatalf("expected error once the grace classification is gone")
+	}
+}
+
+func TestLoadFileMergesIncludedTaxonomy(t *testing.T) {
+	d := t.TempDir()
+	writePolicyFile(t, d, "taxonomy.yaml", "version: 0.1.0\nupdated: 2025-08-01\nclassifications:\n"+
+		"  - key: PARENT_CONSENT_REQUIRED\n    label: Parent Consent Required\n")
+	root := writePolicyFile(t, d, "policy.yaml", "version: 0.1.0\nupdated: 2025-08-08\nincludes:\n  - taxonomy.yaml\n"+
+		"classifications:\n  - key: PARENT_CONSENT_REQUIRED\n    color: \"#1c7ed6\"\n"+
+		"records:\n  - domain: consent.com\n    classification: PARENT_CONSENT_REQUIRED\n"+includeRecord)
+	p, err := LoadFile(filepath.Clean(root), nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if c, _ := p.Classification("PARENT_CONSENT_REQUIRED"); c.Label != "Parent Consent Required" || c.Color != "#1c7ed6" {
+		t.Fatalf("included taxonomy not merged: %+v", c)
+	}
+}
+
+func TestCanonicalHashCoversTaxonomy(t *testing.T) {
+	yes, no := true, false
+	build := func(defs ...ClassificationDef) *Policy {
+		return &Policy{Version: "0.1.0", Updated: "2025-08-08", Classifications: defs,
+			Records: []Record{{Domain: "watched.com", Classification: "MONITOR", Rationale: "r", LastReview: "2025-08-01", Status: "active"}}}
+	}
+	blocking := build(ClassificationDef{Key: "MONITOR", Label: "Monitored", Blocks: &yes})
+	passive := build(ClassificationDef{Key: "MONITOR", Label: "Monitored", Blocks: &no})
+	relabelled := build(ClassificationDef{Key: "MONITOR", Label: "Watched", Blocks: &yes})
+	if blocking.CanonicalHash() == passive.CanonicalHash() {
+		t.Fatalf("blocks: false should change the hash")
+	}
+	if blocking.CanonicalHash() == relabelled.CanonicalHash() {
+		t.Fatalf("a new label should change the hash")
+	}
+	if build(ClassificationDef{Key: "MONITOR", Label: "Monitored"}).CanonicalHash() != blocking.CanonicalHash() {
+		t.Fatalf("an unset blocks flag defaults to true and should hash the same")
+	}
+	plain := &Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []Record{{Domain: "a.com", Classification: "NO_DPA", Status: "active"}}}
+	before := plain.CanonicalHash()
+	plain.Classifications = []ClassificationDef{{Key: "NO_DPA", Label: "No Signed DPA"}}
+	if plain.CanonicalHash() == before {
+		t.Fatalf("restyling a built-in should change the hash")
+	}
+}
@@ -0,0 +1,52 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/i18n"
+)
+
+// LocalizedRationale returns the rationale translated for lang (exact tag, then base language),
+// falling back to Rationale.
+func (r Record) LocalizedRationale(lang string) string {
+	if t, ok := i18n.Pick(r.RationaleTranslations, lang); ok {
+		return t
+	}
+	return r.Rationale
+}
+
+// normalizeTranslations lowercases the language tags of a rationale_translations map and
+// rejects malformed tags, empty texts and tags that collide after normalization. It returns
+// nil for an empty map.
+func normalizeTranslations(m map[string]string) (map[string]string, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(m))
+	for _, raw := range sortedKeys(m) {
+		tag := i18n.NormalizeTag(raw)
+		if !i18n.ValidTag(tag) {
+			return nil, fmt.Errorf("invalid rationale translation language %q", raw)
+		}
+		text := strings.TrimSpace(m[raw])
+		if text == "" {
+			return nil, fmt.Errorf("empty rationale translation for %s", tag)
+		}
+		if _, dup := out[tag]; dup {
+			return nil, fmt.Errorf("duplicate rationale translation for %s", tag)
+		}
+		out[tag] = text
+	}
+	return out, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
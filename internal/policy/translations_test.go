@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRationaleTranslations(t *testing.T) {
+	p, err := Load([]byte("version: 0.1.0\nupdated: 2025-08-08\nrecords:\n" +
+		"  - domain: exampletool.com\n    classification: NO_DPA\n    rationale: Vendor has not signed the agreement\n" +
+		"    rationale_translations:\n      ES: El proveedor no ha firmado el acuerdo\n      pt_BR: O fornecedor não assinou o acordo\n" +
+		"    last_review: 2025-08-01\n    status: active\n"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	plain := p.CanonicalHash()
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	r := p.Records[0]
+	if len(r.RationaleTranslations) != 2 || r.RationaleTranslations["es"] == "" || r.RationaleTranslations["pt-br"] == "" {
+		t.Fatalf("tags not normalized: %v", r.RationaleTranslations)
+	}
+	for lang, want := range map[string]string{
+		"es":    "El proveedor no ha firmado el acuerdo",
+		"es-MX": "El proveedor no ha firmado el acuerdo",
+		"pt-BR": "O fornecedor não assinou o acordo",
+		"so":    "Vendor has not signed the agreement",
+		"":      "Vendor has not signed the agreement",
+	} {
+		if got := r.LocalizedRationale(lang); got != want {
+			t.Errorf("LocalizedRationale(%q) = %q, want %q", lang, got, want)
+		}
+	}
+
+	// Translations count as content: editing one changes the hash and shows in the diff.
+	old := &Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []Record{r}}
+	old.Records[0].RationaleTranslations = map[string]string{"es": "Texto anterior"}
+	if old.CanonicalHash() == p.CanonicalHash() {
+		t.Fatalf("hash ignores translations")
+	}
+	noTr := &Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []Record{r}}
+	noTr.Records[0].RationaleTranslations = nil
+	if noTr.CanonicalHash() == plain {
+		t.Fatalf("hash of a policy with translations must differ from one without")
+	}
+	changes := Diff(old, p)
+	if len(changes) != 1 || changes[0].Kind != ChangeTranslations || changes[0].Old != "es" || changes[0].New != "es,pt-br" {
+		t.Fatalf("unexpected diff: %+v", changes)
+	}
+
+	for _, tc := range []struct {
+		tr   map[string]string
+		want string
+	}{
+		{map[string]string{"spanish": "texto"}, "invalid rationale translation language"},
+		{map[string]string{"so": "  "}, "empty rationale translation"},
+		{map[string]string{"ES": "uno", "es": "dos"}, "duplicate rationale translation"},
+	} {
+		bad := &Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []Record{{Domain: "a.com", Classification: "NO_DPA", Status: "active", RationaleTranslations: tc.tr}}}
+		if err := bad.Validate(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%v: expected %q, got %v", tc.tr, tc.want, err)
+		}
+	}
+	if _, err := Load([]byte("version: 0.1.0\nupdated: 2025-08-08\nrecords:\n  - domain: a.com\n    classification: NO_DPA\n    rationale: Vendor unsigned\n" +
+		"    rationale_translations:\n      Spanish!: texto\n    last_review: 2025-08-01\n    status: active\n")); err == nil {
+		t.Fatalf("schema should reject a malformed language key")
+	}
+}
+
+func TestOverrideMergesRationaleTranslations(t *testing.T) {
+	d := t.TempDir()
+	writePolicyFile(t, d, "base.yaml", "version: 0.1.0\nupdated: 2025-08-01\nrecords:\n"+
+		"  - domain: shared.com\n    classification: NO_DPA\n    rationale_translations:\n      es: Texto del distrito\n      so: Qoraalka degmada\n"+includeRecord)
+	root := writePolicyFile(t, d, "school.yaml", "version: 0.2.0\nupdated: 2025-08-08\nincludes:\n  - base.yaml\n"+
+		"overrides:\n  - domain: shared.com\n    rationale_translations:\n      es: Texto de la escuela\n      ne: विद्यालयको पाठ\n")
+	p, err := LoadFile(filepath.Clean(root), nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	got := p.Records[0].RationaleTranslations
+	if got["es"] != "Texto de la escuela" || got["so"] != "Qoraalka degmada" || got["ne"] != "विद्यालयको पाठ" {
+		t.Fatalf("unexpected merged translations: %v", got)
+	}
+}
@@ -0,0 +1,288 @@
+// Package reviews queues staff requests to review a blocked domain, submitted from the explain
+// page, so the privacy officer can triage them. The queue is an append-only log: submitting and
+// closing a request each add one line, and the current state is rebuilt by replaying it, so the
+// server and the CLI can share the file without coordinating.
+//
+// Layout under <cacheDir>/reviews:
+//
+//	queue.jsonl   one event per submitted or closed request, oldest first
+package reviews
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Request states.
+const (
+	StatusOpen   = "open"
+	StatusClosed = "closed"
+)
+
+// Resolutions accepted by Close.
+var Resolutions = []string{"approved", "denied", "duplicate", "withdrawn"}
+
+// Field limits, in characters.
+const (
+	MaxJustification = 2000
+	MaxEmail         = 254
+	MaxNote          = 1000
+)
+
+// MaxOpen bounds the open requests kept in the queue; Submit fails with ErrQueueFull beyond it
+// so a flood of submissions cannot fill the disk.
+const MaxOpen = 1000
+
+var (
+	// ErrInvalid wraps validation failures of submitted or closing fields.
+	ErrInvalid = errors.New("invalid request")
+	// ErrNotFound reports an unknown request ID.
+	ErrNotFound = errors.New("request not found")
+	// ErrAlreadyClosed reports a second Close of the same request.
+	ErrAlreadyClosed = errors.New("request already closed")
+	// ErrQueueFull reports that MaxOpen requests are waiting for triage.
+	ErrQueueFull = errors.New("review queue is full")
+)
+
+// Request is one review request with its current state.
+type Request struct {
+	ID             string     `json:"id"`
+	Submitted      time.Time  `json:"submitted"`
+	Domain         string     `json:"domain"`
+	Classification string     `json:"classification"`
+	PolicyVersion  string     `json:"policy_version"`
+	Justification  string     `json:"justification"`
+	Email          string     `json:"email,omitempty"`
+	Status         string     `json:"status"`
+	Closed         *time.Time `json:"closed,omitempty"`
+	Resolution     string     `json:"resolution,omitempty"`
+	Note           string     `json:"note,omitempty"`
+}
+
+// event is one queue.jsonl line.
+type event struct {
+	Op         string    `json:"op"` // "submit" or "close"
+	ID         string    `json:"id"`
+	Time       time.Time `json:"time"`
+	Request    *Request  `json:"request,omitempty"`
+	Resolution string    `json:"resolution,omitempty"`
+	Note       string    `json:"note,omitempty"`
+}
+
+// Queue reads and appends the queue file. It is safe for concurrent use within a process;
+// other processes may append concurrently since every event is a single write.
+type Queue struct {
+	dir string
+	now func() time.Time
+	mu  sync.Mutex
+}
+
+// Open prepares the reviews directory under cacheDir (default "cache").
+func Open(cacheDir string) (*Queue, error) {
+	if cacheDir == "" {
+		cacheDir = "cache"
+	}
+	dir := filepath.Join(cacheDir, "reviews")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create reviews dir: %w", err)
+	}
+	return &Queue{dir: dir, now: time.Now}, nil
+}
+
+// Dir returns the reviews directory.
+func (q *Queue) Dir() string { return q.dir }
+
+// Submit validates r, assigns its ID, time and open status, and appends it. Only Domain,
+// Classification, PolicyVersion, Justification and Email are taken from r.
+func (q *Queue) Submit(r Request) (Request, error) {
+	r.Domain = strings.ToLower(strings.TrimSpace(r.Domain))
+	r.Justification = strings.TrimSpace(r.Justification)
+	r.Email = strings.TrimSpace(r.Email)
+	if r.Domain == "" || r.Classification == "" {
+		return Request{}, fmt.Errorf("%w: domain and classification required", ErrInvalid)
+	}
+	if r.Justification == "" {
+		return Request{}, fmt.Errorf("%w: justification required", ErrInvalid)
+	}
+	if utf8.RuneCountInString(r.Justification) > MaxJustification {
+		return Request{}, fmt.Errorf("%w: justification longer than %d characters", ErrInvalid, MaxJustification)
+	}
+	if r.Email != "" {
+		a, err := mail.ParseAddress(r.Email)
+		if err != nil || a.Address != r.Email || len(r.Email) > MaxEmail {
+			return Request{}, fmt.Errorf("%w: email must be a plain address like name@school.org", ErrInvalid)
+		}
+	}
+	id, err := newID()
+	if err != nil {
+		return Request{}, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	all, err := q.read()
+	if err != nil {
+		return Request{}, err
+	}
+	open := 0
+	for _, x := range all {
+		if x.Status == StatusOpen {
+			open++
+		}
+	}
+	if open >= MaxOpen {
+		return Request{}, ErrQueueFull
+	}
+	out := Request{
+		ID:             id,
+		Submitted:      q.now().UTC().Truncate(time.Second),
+		Domain:         r.Domain,
+		Classification: r.Classification,
+		PolicyVersion:  r.PolicyVersion,
+		Justification:  r.Justification,
+		Email:          r.Email,
+		Status:         StatusOpen,
+	}
+	if err := q.append(event{Op: "submit", ID: id, Time: out.Submitted, Request: &out}); err != nil {
+		return Request{}, err
+	}
+	return out, nil
+}
+
+// Close marks the request id closed with resolution (one of Resolutions) and an optional note.
+func (q *Queue) Close(id, resolution, note string) (Request, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	note = strings.TrimSpace(note)
+	if !validResolution(resolution) {
+		return Request{}, fmt.Errorf("%w: resolution must be one of %s", ErrInvalid, strings.Join(Resolutions, "|"))
+	}
+	if utf8.RuneCountInString(note) > MaxNote {
+		return Request{}, fmt.Errorf("%w: note longer than %d characters", ErrInvalid, MaxNote)
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	all, err := q.read()
+	if err != nil {
+		return Request{}, err
+	}
+	for _, r := range all {
+		if r.ID != id {
+			continue
+		}
+		if r.Status == StatusClosed {
+			return r, ErrAlreadyClosed
+		}
+		t := q.now().UTC().Truncate(time.Second)
+		if err := q.append(event{Op: "close", ID: id, Time: t, Resolution: resolution, Note: note}); err != nil {
+			return Request{}, err
+		}
+		r.Status, r.Closed, r.Resolution, r.Note = StatusClosed, &t, resolution, note
+		return r, nil
+	}
+	return Request{}, ErrNotFound
+}
+
+// List returns the requests with the given status (StatusOpen, StatusClosed, or "" for all),
+// oldest first.
+func (q *Queue) List(status string) ([]Request, error) {
+	q.mu.Lock()
+	all, err := q.read()
+	q.mu.Unlock()
+	if err != nil || status == "" {
+		return all, err
+	}
+	out := []Request{}
+	for _, r := range all {
+		if r.Status == status {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (q *Queue) path() string { return filepath.Join(q.dir, "queue.jsonl") }
+
+func (q *Queue) append(e event) error {
+	f, err := os.OpenFile(q.path(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open queue: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	line, _ := json.Marshal(e)
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("append queue: %w", err)
+	}
+	return nil
+}
+
+// read replays the queue file into requests, oldest first.
+func (q *Queue) read() ([]Request, error) {
+	out := []Request{}
+	f, err := os.Open(q.path())
+	if errors.Is(err, os.ErrNotExist) {
+		return out, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open queue: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	index := map[string]int{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var e event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			continue // tolerate a torn final line
+		}
+		switch e.Op {
+		case "submit":
+			if e.Request == nil {
+				continue
+			}
+			if _, dup := index[e.ID]; dup {
+				continue
+			}
+			index[e.ID] = len(out)
+			out = append(out, *e.Request)
+		case "close":
+			i, ok := index[e.ID]
+			if !ok || out[i].Status == StatusClosed {
+				continue
+			}
+			t := e.Time
+			out[i].Status, out[i].Closed, out[i].Resolution, out[i].Note = StatusClosed, &t, e.Resolution, e.Note
+		}
+	}
+	return out, sc.Err()
+}
+
+func validResolution(s string) bool {
+	for _, r := range Resolutions {
+		if s == r {
+			return true
+		}
+	}
+	return false
+}
+
+func newID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
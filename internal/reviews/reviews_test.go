@@ -0,0 +1,84 @@
+package reviews
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSubmitCloseAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	r, err := q.Submit(Request{Domain: " ExampleTool.com ", Classification: "NO_DPA", PolicyVersion: "0.1.0", Justification: " Used for 5th grade math ", Email: "teacher@school.org", Status: StatusClosed, ID: "chosen"})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if len(r.ID) != 12 || r.ID == "chosen" || r.Status != StatusOpen || r.Domain != "exampletool.com" || r.Justification != "Used for 5th grade math" || r.Submitted.IsZero() {
+		t.Fatalf("unexpected request: %+v", r)
+	}
+	r2, err := q.Submit(Request{Domain: "trackingwidgets.io", Classification: "EXPIRED_DPA", Justification: "Reading program"})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	closed, err := q.Close(strings.ToUpper(r.ID), "approved", "DPA signed")
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if closed.Status != StatusClosed || closed.Resolution != "approved" || closed.Closed == nil {
+		t.Fatalf("unexpected closed request: %+v", closed)
+	}
+	if _, err := q.Close(r.ID, "denied", ""); !errors.Is(err, ErrAlreadyClosed) {
+		t.Fatalf("expected ErrAlreadyClosed, got %v", err)
+	}
+	if _, err := q.Close("000000000000", "denied", ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := q.Close(r2.ID, "maybe", ""); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+
+	// A torn final line (crash mid-write) is skipped; a second process sees the same state.
+	f, _ := os.OpenFile(filepath.Join(q.Dir(), "queue.jsonl"), os.O_APPEND|os.O_WRONLY, 0o600)
+	_, _ = f.WriteString(`{"op":"close","id":"` + r2.ID)
+	_ = f.Close()
+	other, _ := Open(dir)
+	open, err := other.List(StatusOpen)
+	if err != nil || len(open) != 1 || open[0].ID != r2.ID {
+		t.Fatalf("unexpected open list: %+v %v", open, err)
+	}
+	all, _ := other.List("")
+	if len(all) != 2 || all[0].Status != StatusClosed || all[0].Note != "DPA signed" {
+		t.Fatalf("unexpected full list: %+v", all)
+	}
+}
+
+func TestSubmitValidation(t *testing.T) {
+	q, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	base := Request{Domain: "exampletool.com", Classification: "NO_DPA", Justification: "why"}
+	cases := map[string]func(r *Request){
+		"no domain":        func(r *Request) { r.Domain = " " },
+		"no justification": func(r *Request) { r.Justification = "  " },
+		"long":             func(r *Request) { r.Justification = strings.Repeat("é", MaxJustification+1) },
+		"bad email":        func(r *Request) { r.Email = "not an address" },
+		"display name":     func(r *Request) { r.Email = "Eve <eve@example.com>" },
+	}
+	for name, mut := range cases {
+		r := base
+		mut(&r)
+		if _, err := q.Submit(r); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", name, err)
+		}
+	}
+	if all, _ := q.List(""); len(all) != 0 {
+		t.Fatalf("invalid requests were stored: %+v", all)
+	}
+}
@@ -0,0 +1,314 @@
+package server
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Route groups whose authentication is configured together.
+const (
+	GroupPublic = "public" // /, /explain, /law, /health, /classify
+	GroupOps    = "ops"    // /metrics, /metrics/history, /domain-list
+	GroupAdmin  = "admin"  // /admin/*
+)
+
+// Methods a route group may accept; a request passing any one of them is let through.
+const (
+	AuthNone     = "none"     // no check
+	AuthLoopback = "loopback" // client connects from a loopback address and was not forwarded by a proxy
+	AuthToken    = "token"    // Authorization: Bearer <token> whose hash is in the token file
+	AuthBasic    = "basic"    // HTTP basic auth against bcrypt password hashes
+	AuthCert     = "cert"     // TLS client certificate verified against the client CA
+	AuthAuto     = "auto"     // every configured credential method, else loopback
+)
+
+// Auth decides which requests may use each route group. Configure it before SetAuth; it is
+// read-only afterwards.
+type Auth struct {
+	tokens    map[[sha256.Size]byte]string // token hash -> token name
+	users     map[string][]byte            // user -> bcrypt hash
+	clientCAs *x509.CertPool
+	groups    map[string][]string
+}
+
+// NewAuth returns the defaults: public routes open, ops and admin routes on AuthAuto.
+func NewAuth() *Auth {
+	return &Auth{
+		tokens: map[[sha256.Size]byte]string{},
+		users:  map[string][]byte{},
+		groups: map[string][]string{
+			GroupPublic: {AuthNone},
+			GroupOps:    {AuthAuto},
+			GroupAdmin:  {AuthAuto},
+		},
+	}
+}
+
+// ParseAuthMethods parses a comma-separated method list such as "token,basic".
+func ParseAuthMethods(s string) ([]string, error) {
+	var out []string
+	for _, m := range strings.Split(s, ",") {
+		m = strings.ToLower(strings.TrimSpace(m))
+		switch m {
+		case "":
+			continue
+		case AuthNone, AuthLoopback, AuthToken, AuthBasic, AuthCert, AuthAuto:
+			out = append(out, m)
+		default:
+			return nil, fmt.Errorf("unknown auth method %q (use none|loopback|token|basic|cert|auto)", m)
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no auth methods given")
+	}
+	return out, nil
+}
+
+// SetGroup replaces the methods accepted by group. Methods that need credentials which are
+// not configured are rejected, so a group cannot silently lock everyone out.
+func (a *Auth) SetGroup(group string, methods []string) error {
+	if _, ok := a.groups[group]; !ok {
+		return fmt.Errorf("unknown route group %q (use public|ops|admin)", group)
+	}
+	for _, m := range methods {
+		switch {
+		case m == AuthToken && len(a.tokens) == 0:
+			return fmt.Errorf("%s: token auth needs a token file", group)
+		case m == AuthBasic && len(a.users) == 0:
+			return fmt.Errorf("%s: basic auth needs a users file", group)
+		case m == AuthCert && a.clientCAs == nil:
+			return fmt.Errorf("%s: cert auth needs a client CA", group)
+		}
+	}
+	a.groups[group] = methods
+	return nil
+}
+
+// Methods returns the methods group accepts, with AuthAuto expanded.
+func (a *Auth) Methods(group string) []string {
+	var out []string
+	for _, m := range a.groups[group] {
+		if m != AuthAuto {
+			out = append(out, m)
+			continue
+		}
+		if len(a.tokens) > 0 {
+			out = append(out, AuthToken)
+		}
+		if len(a.users) > 0 {
+			out = append(out, AuthBasic)
+		}
+		if a.clientCAs != nil {
+			out = append(out, AuthCert)
+		}
+		if len(a.tokens) == 0 && len(a.users) == 0 && a.clientCAs == nil {
+			out = append(out, AuthLoopback)
+		}
+	}
+	return out
+}
+
+// HashToken returns the at-rest form of a bearer token (hex SHA-256) used in token files.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadTokens adds the bearer tokens in path: one "name:sha256-hex" per line (see HashToken);
+// blank lines and lines starting with # are ignored.
+func (a *Auth) LoadTokens(path string) error {
+	return readCredentialFile(path, func(name, hash string) error {
+		b, err := hex.DecodeString(hash)
+		if err != nil || len(b) != sha256.Size {
+			return errors.New("want name:<64 hex characters of SHA-256>")
+		}
+		var k [sha256.Size]byte
+		copy(k[:], b)
+		a.tokens[k] = name
+		return nil
+	})
+}
+
+// LoadUsers adds the users in an htpasswd-style file: one "user:bcrypt-hash" per line
+// (e.g. from `htpasswd -B` or `sb29guard auth passwd`). Other hash schemes are rejected.
+func (a *Auth) LoadUsers(path string) error {
+	return readCredentialFile(path, func(user, hash string) error {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return errors.New("want user:<bcrypt hash>")
+		}
+		a.users[user] = []byte(hash)
+		return nil
+	})
+}
+
+// AddUser adds a user with a plaintext password, hashed with cost.
+func (a *Auth) AddUser(user, password string, cost int) error {
+	h, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return err
+	}
+	a.users[user] = h
+	return nil
+}
+
+// LoadClientCA trusts the PEM certificates in path for client-certificate auth.
+func (a *Auth) LoadClientCA(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return fmt.Errorf("%s: no PEM certificates found", path)
+	}
+	a.clientCAs = pool
+	return nil
+}
+
+// ClientCAs returns the pool for client-certificate auth, or nil when not configured.
+func (a *Auth) ClientCAs() *x509.CertPool { return a.clientCAs }
+
+func readCredentialFile(path string, add func(name, secret string) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, secret, ok := strings.Cut(line, ":")
+		if !ok || name == "" {
+			return fmt.Errorf("%s:%d: want name:hash", path, n)
+		}
+		if err := add(name, secret); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+	}
+	return sc.Err()
+}
+
+// allow reports whether r passes one of group's methods.
+func (a *Auth) allow(group string, r *http.Request) bool {
+	for _, m := range a.Methods(group) {
+		switch m {
+		case AuthNone:
+			return true
+		case AuthLoopback:
+			if isLoopback(r) {
+				return true
+			}
+		case AuthToken:
+			if tok, ok := bearerToken(r); ok {
+				if _, ok := a.tokens[sha256.Sum256([]byte(tok))]; ok {
+					return true
+				}
+			}
+		case AuthBasic:
+			if u, p, ok := r.BasicAuth(); ok {
+				if h, known := a.users[u]; known && bcrypt.CompareHashAndPassword(h, []byte(p)) == nil {
+					return true
+				}
+			}
+		case AuthCert:
+			if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// challenge is the WWW-Authenticate value for group's header-based methods, if any.
+func (a *Auth) challenge(group string) string {
+	var c []string
+	for _, m := range a.Methods(group) {
+		switch m {
+		case AuthBasic:
+			c = append(c, `Basic realm="sb29guard"`)
+		case AuthToken:
+			c = append(c, `Bearer realm="sb29guard"`)
+		}
+	}
+	return strings.Join(c, ", ")
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, tok, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	tok = strings.TrimSpace(tok)
+	return tok, tok != ""
+}
+
+// isLoopback reports whether r came straight from this machine. A local reverse proxy also
+// connects from loopback, so requests carrying forwarding headers are treated as remote.
+func isLoopback(r *http.Request) bool {
+	for _, h := range []string{"Forwarded", "X-Forwarded-For", "X-Forwarded-Host", "X-Real-Ip"} {
+		if r.Header.Get(h) != "" {
+			return false
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// SetAuth replaces the route group authentication (default: DefaultAuth). Call before Start.
+func (s *Server) SetAuth(a *Auth) {
+	s.mu.Lock()
+	s.auth = a
+	s.mu.Unlock()
+}
+
+func (s *Server) getAuth() *Auth {
+	s.mu.RLock()
+	a := s.auth
+	s.mu.RUnlock()
+	return a
+}
+
+// guard wraps h with group's authentication; failures get a 401 JSON error.
+func (s *Server) guard(group string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a := s.getAuth()
+		if a.allow(group, r) {
+			h(w, r)
+			return
+		}
+		if c := a.challenge(group); c != "" {
+			w.Header().Set("WWW-Authenticate", c)
+		}
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", group+" credentials required")
+	}
+}
+
+// DefaultAuth returns NewAuth plus the legacy SB29_ADMIN_USER/SB29_ADMIN_PASS user when
+// both are set.
+func DefaultAuth() *Auth {
+	a := NewAuth()
+	if user, pass := os.Getenv("SB29_ADMIN_USER"), os.Getenv("SB29_ADMIN_PASS"); user != "" && pass != "" {
+		// Only held in memory next to the plaintext in the environment, so the minimum cost is enough.
+		if err := a.AddUser(user, pass, bcrypt.MinCost); err != nil {
+			a.users[user] = nil // unusable password (e.g. over 72 bytes): stay locked rather than fall back to loopback
+		}
+	}
+	return a
+}
@@ -0,0 +1,289 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/certs"
+)
+
+func serveAs(h http.Handler, target string, setup func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if setup != nil {
+		setup(req)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestAuthDefaultsKeepOpsAndAdminOnLoopback(t *testing.T) {
+	h := newTestServer(t).Handler()
+	remote := func(r *http.Request) { r.RemoteAddr = "203.0.113.5:4000" }
+	loop := func(r *http.Request) { r.RemoteAddr = "127.0.0.1:4000" }
+	for _, target := range []string{"/explain?d=exampletool.com", "/health"} {
+		if rr := serveAs(h, target, remote); rr.Code != http.StatusOK {
+			t.Fatalf("%s should be open by default, got %d", target, rr.Code)
+		}
+	}
+	for _, target := range []string{"/metrics", "/metrics/history", "/domain-list"} {
+		if rr := serveAs(h, target, remote); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("%s from remote without credentials: %d", target, rr.Code)
+		}
+	}
+	if rr := serveAs(h, "/metrics", loop); rr.Code != http.StatusOK {
+		t.Fatalf("/metrics from loopback: %d", rr.Code)
+	}
+	if rr := serveAs(h, "/admin/summary", remote); rr.Code != http.StatusUnauthorized || rr.Header().Get("WWW-Authenticate") != "" {
+		t.Fatalf("admin from remote: %d %q", rr.Code, rr.Header().Get("WWW-Authenticate"))
+	}
+	if rr := serveAs(h, "/admin/unknown", remote); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown admin path should not be probeable: %d", rr.Code)
+	}
+	if rr := serveAs(h, "/admin/summary", loop); rr.Code != http.StatusNotFound { // authorized, aggregator not enabled
+		t.Fatalf("admin from loopback: %d", rr.Code)
+	}
+}
+
+func TestAuthLoopbackIgnoresProxiedRequests(t *testing.T) {
+	h := newTestServer(t).Handler()
+	// A reverse proxy on the guard host connects from loopback on behalf of remote clients.
+	for _, hdr := range []string{"X-Forwarded-For", "X-Forwarded-Host", "Forwarded", "X-Real-IP"} {
+		hdr := hdr
+		rr := serveAs(h, "/admin/summary", func(r *http.Request) {
+			r.RemoteAddr = "127.0.0.1:4000"
+			r.Header.Set(hdr, "blocked.local")
+		})
+		if rr.Code != http.StatusUnauthorized {
+			t.Fatalf("proxied admin request with %s: %d", hdr, rr.Code)
+		}
+	}
+	if rr := serveAs(h, "/explain?d=exampletool.com", func(r *http.Request) {
+		r.RemoteAddr = "127.0.0.1:4000"
+		r.Header.Set("X-Forwarded-Host", "exampletool.com")
+	}); rr.Code != http.StatusOK {
+		t.Fatalf("proxied explain request: %d", rr.Code)
+	}
+}
+
+func TestAuthBearerTokens(t *testing.T) {
+	a := NewAuth()
+	tokens := writeFile(t, "tokens", "# scrapers\nprometheus:"+HashToken("tok-123")+"\n\n")
+	if err := a.LoadTokens(tokens); err != nil {
+		t.Fatalf("load tokens: %v", err)
+	}
+	if err := a.SetGroup(GroupOps, []string{AuthToken}); err != nil {
+		t.Fatalf("set group: %v", err)
+	}
+	srv := newTestServer(t)
+	srv.SetAuth(a)
+	h := srv.Handler()
+	rr := serveAs(h, "/metrics", nil)
+	if rr.Code != http.StatusUnauthorized || rr.Header().Get("WWW-Authenticate") != `Bearer realm="sb29guard"` || !strings.Contains(rr.Body.String(), `"error":"unauthorized"`) {
+		t.Fatalf("no token: %d %q %s", rr.Code, rr.Header().Get("WWW-Authenticate"), rr.Body.String())
+	}
+	for tok, want := range map[string]int{"tok-123": 200, "tok-124": 401, "": 401} {
+		tok := tok
+		rr = serveAs(h, "/domain-list", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+tok) })
+		if rr.Code != want {
+			t.Fatalf("token %q: expected %d got %d", tok, want, rr.Code)
+		}
+	}
+	if rr = serveAs(h, "/explain?d=exampletool.com", nil); rr.Code != http.StatusOK {
+		t.Fatalf("public route should stay open: %d", rr.Code)
+	}
+	// The token file holds hashes: the hash itself is not a credential.
+	rr = serveAs(h, "/metrics", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+HashToken("tok-123")) })
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("hash accepted as token: %d", rr.Code)
+	}
+}
+
+func TestAuthBasicBcrypt(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAuth()
+	if err := a.LoadUsers(writeFile(t, "users", "admin:"+string(hash)+"\n")); err != nil {
+		t.Fatalf("load users: %v", err)
+	}
+	if err := a.SetGroup(GroupOps, []string{AuthBasic, AuthLoopback}); err != nil {
+		t.Fatalf("set group: %v", err)
+	}
+	srv := newTestServer(t)
+	srv.SetAuth(a)
+	h := srv.Handler()
+	// Admin is on auto, so configured users replace the loopback default.
+	rr := serveAs(h, "/admin/summary", func(r *http.Request) { r.RemoteAddr = "127.0.0.1:4000" })
+	if rr.Code != http.StatusUnauthorized || rr.Header().Get("WWW-Authenticate") != `Basic realm="sb29guard"` {
+		t.Fatalf("admin without credentials: %d %q", rr.Code, rr.Header().Get("WWW-Authenticate"))
+	}
+	rr = serveAs(h, "/admin/summary", func(r *http.Request) { r.SetBasicAuth("admin", "wrong") })
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong password: %d", rr.Code)
+	}
+	rr = serveAs(h, "/admin/summary", func(r *http.Request) { r.SetBasicAuth("admin", "s3cret") })
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("right password should pass auth: %d", rr.Code)
+	}
+	// ops accepts basic or loopback
+	if rr = serveAs(h, "/metrics", func(r *http.Request) { r.RemoteAddr = "127.0.0.1:4000" }); rr.Code != http.StatusOK {
+		t.Fatalf("loopback on ops: %d", rr.Code)
+	}
+	if rr = serveAs(h, "/metrics", func(r *http.Request) { r.RemoteAddr = "203.0.113.5:4000" }); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("remote on ops: %d", rr.Code)
+	}
+}
+
+func TestAuthLegacyAdminEnv(t *testing.T) {
+	t.Setenv("SB29_ADMIN_USER", "admin")
+	t.Setenv("SB29_ADMIN_PASS", "s3cret")
+	h := newTestServer(t).Handler()
+	if rr := serveAs(h, "/admin/summary", func(r *http.Request) { r.RemoteAddr = "127.0.0.1:4000" }); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("loopback without credentials: %d", rr.Code)
+	}
+	if rr := serveAs(h, "/admin/summary", func(r *http.Request) { r.SetBasicAuth("admin", "s3cret") }); rr.Code != http.StatusNotFound {
+		t.Fatalf("env credentials: %d", rr.Code)
+	}
+}
+
+func TestAuthConfigErrors(t *testing.T) {
+	if _, err := ParseAuthMethods("token,password"); err == nil {
+		t.Fatalf("expected unknown method error")
+	}
+	if m, err := ParseAuthMethods(" Token , basic "); err != nil || len(m) != 2 || m[0] != AuthToken {
+		t.Fatalf("parse: %v %v", m, err)
+	}
+	a := NewAuth()
+	for _, m := range []string{AuthToken, AuthBasic, AuthCert} {
+		if err := a.SetGroup(GroupOps, []string{m}); err == nil {
+			t.Fatalf("%s without credentials should be rejected", m)
+		}
+	}
+	if err := a.SetGroup("private", []string{AuthNone}); err == nil {
+		t.Fatalf("expected unknown group error")
+	}
+	if err := a.LoadTokens(writeFile(t, "tokens", "prometheus:not-a-hash\n")); err == nil || !strings.Contains(err.Error(), ":1:") {
+		t.Fatalf("expected line error, got %v", err)
+	}
+	if err := a.LoadUsers(writeFile(t, "users", "admin:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n")); err == nil {
+		t.Fatalf("non-bcrypt hash should be rejected")
+	}
+	if err := a.LoadClientCA(writeFile(t, "ca.pem", "nothing here")); err == nil {
+		t.Fatalf("expected no PEM error")
+	}
+}
+
+func TestAuthClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "District Clients CA"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour),
+		IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+	clientKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	clientDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "monitoring"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour),
+		KeyUsage: x509.KeyUsageDigitalSignature, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caCert, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(dir, "clients-ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewAuth()
+	if err := a.LoadClientCA(caFile); err != nil {
+		t.Fatalf("load client CA: %v", err)
+	}
+	if err := a.SetGroup(GroupOps, []string{AuthCert}); err != nil {
+		t.Fatalf("set group: %v", err)
+	}
+	certFile, keyFile, _, err := certs.EnsureSelfSigned(dir, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverCert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	leaf, err := x509.ParseCertificate(serverCert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots.AddCert(leaf)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	srv := New("", testPolicy())
+	srv.SetAuth(a)
+	srv.SetTLS(addr, &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{serverCert}})
+	go func() { _ = srv.Start() }()
+	defer func() { _ = srv.Shutdown(context.Background()) }()
+
+	get := func(withCert bool) int {
+		cfg := &tls.Config{RootCAs: roots}
+		if withCert {
+			cfg.Certificates = []tls.Certificate{{Certificate: [][]byte{clientDER}, PrivateKey: clientKey}}
+		}
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}, Timeout: 2 * time.Second}
+		var lastErr error
+		for i := 0; i < 50; i++ {
+			resp, err := c.Get("https://" + addr + "/metrics")
+			if err == nil {
+				_ = resp.Body.Close()
+				return resp.StatusCode
+			}
+			lastErr = err
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("server did not become ready: %v", lastErr)
+		return 0
+	}
+	if code := get(false); code != http.StatusUnauthorized {
+		t.Fatalf("without client certificate: %d", code)
+	}
+	if code := get(true); code != http.StatusOK {
+		t.Fatalf("with client certificate: %d", code)
+	}
+}
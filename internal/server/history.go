@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/journal"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+)
+
+// PolicyTarget activates the policies the server swaps in on its own (/admin/rollback).
+type PolicyTarget interface {
+	UpdatePolicy(p *policy.Policy)
+	RecordRefreshSuccess(source string)
+}
+
+// SetJournal attaches the policy snapshot journal used by /metrics/history and /admin/rollback.
+func (s *Server) SetJournal(j *journal.Journal) {
+	s.mu.Lock()
+	s.journal = j
+	s.mu.Unlock()
+}
+
+// SetPolicyTarget routes rollbacks through t, the target the refresh loops update, so a
+// wrapper around the server sees them too. The default is the server itself. Call before Start.
+func (s *Server) SetPolicyTarget(t PolicyTarget) { s.target = t }
+
+func (s *Server) getJournal() *journal.Journal {
+	s.mu.RLock()
+	j := s.journal
+	s.mu.RUnlock()
+	return j
+}
+
+// handleHistory lists accepted policy snapshots, oldest first, alongside the active hash.
+func (s *Server) handleHistory(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := s.getJournal()
+	if j == nil {
+		writeJSONError(w, http.StatusNotFound, "not_enabled", "policy journal not enabled")
+		return
+	}
+	entries, err := j.History()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "journal_error", err.Error())
+		return
+	}
+	if entries == nil {
+		entries = []journal.Entry{}
+	}
+	p := s.getPolicy()
+	_ = json.NewEncoder(w).Encode(struct {
+		Status      string          `json:"status"`
+		CurrentHash string          `json:"current_hash"`
+		Entries     []journal.Entry `json:"entries"`
+	}{"ok", p.CanonicalHash(), entries})
+}
+
+// handleAdminRollback re-activates a journaled snapshot: POST /admin/rollback?to=<hash or prefix>.
+func (s *Server) handleAdminRollback(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSONError(w, http.StatusMethodNotAllowed, "method_not_allowed", "use POST")
+		return
+	}
+	j := s.getJournal()
+	if j == nil {
+		writeJSONError(w, http.StatusNotFound, "not_enabled", "policy journal not enabled")
+		return
+	}
+	ref := r.URL.Query().Get("to")
+	if ref == "" {
+		ref = r.PostFormValue("to")
+	}
+	if strings.TrimSpace(ref) == "" {
+		writeJSONError(w, http.StatusBadRequest, "invalid_parameter", "to (snapshot hash) required")
+		return
+	}
+	p, full, err := j.Load(ref)
+	if errors.Is(err, journal.ErrNotFound) {
+		writeJSONError(w, http.StatusNotFound, "invalid_parameter", err.Error())
+		return
+	}
+	if err == nil {
+		// The snapshot keeps the grace mode of the policy it replaces.
+		err = p.SetExpiredClassification(s.getPolicy().ExpiredClassification())
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "journal_error", err.Error())
+		return
+	}
+	var t PolicyTarget = s
+	if s.target != nil {
+		t = s.target
+	}
+	t.UpdatePolicy(p)
+	t.RecordRefreshSuccess("rollback")
+	e, _, err := j.Record(p, "rollback")
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "journal_error", err.Error())
+		return
+	}
+	_ = json.NewEncoder(w).Encode(struct {
+		Status string        `json:"status"`
+		Hash   string        `json:"hash"`
+		Entry  journal.Entry `json:"entry"`
+	}{"ok", full, e})
+}
+
+// writeJSONError emits the general error shape from the API contract.
+func writeJSONError(w http.ResponseWriter, code int, kind, detail string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": kind, "detail": detail})
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/hits"
+)
+
+// SetHits attaches the daily hit aggregator fed by /explain and read by /admin/summary.
+func (s *Server) SetHits(a *hits.Aggregator) {
+	s.mu.Lock()
+	s.hits = a
+	s.mu.Unlock()
+}
+
+func (s *Server) getHits() *hits.Aggregator {
+	s.mu.RLock()
+	a := s.hits
+	s.mu.RUnlock()
+	return a
+}
+
+// handleAdminSummary returns one day's aggregated explain hits: GET /admin/summary?date=YYYY-MM-DD
+// (default today).
+func (s *Server) handleAdminSummary(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	a := s.getHits()
+	if a == nil {
+		writeJSONError(w, http.StatusNotFound, "not_enabled", "hit aggregation not enabled")
+		return
+	}
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = time.Now().Format(hits.DateLayout)
+	}
+	if _, err := time.Parse(hits.DateLayout, date); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_parameter", "date must be YYYY-MM-DD")
+		return
+	}
+	sum, err := a.Summary(date)
+	if errors.Is(err, hits.ErrNoData) {
+		writeJSONError(w, http.StatusNotFound, "not_found", "no hits recorded for "+date)
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "hits_error", err.Error())
+		return
+	}
+	_ = json.NewEncoder(w).Encode(sum)
+}
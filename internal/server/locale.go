@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/i18n"
+)
+
+// SetLocales replaces the message catalogs used by the explain and landing pages (default:
+// the embedded ones; see i18n.Load for an override directory).
+func (s *Server) SetLocales(b *i18n.Bundle) {
+	s.mu.Lock()
+	s.locales = b
+	s.mu.Unlock()
+}
+
+func (s *Server) getLocales() *i18n.Bundle {
+	s.mu.RLock()
+	b := s.locales
+	s.mu.RUnlock()
+	if b == nil {
+		return i18n.Default()
+	}
+	return b
+}
+
+// languageLink is one entry of the page's language switcher.
+type languageLink struct {
+	Tag     string
+	Name    string
+	URL     string
+	Current bool
+}
+
+// pageLocale picks the catalog for r from ?lang= and Accept-Language and marks the response
+// as varying by the latter. It returns the catalog and the switcher links, which keep the
+// request's other query parameters.
+func (s *Server) pageLocale(w http.ResponseWriter, r *http.Request) (*i18n.Catalog, []languageLink) {
+	w.Header().Add("Vary", "Accept-Language")
+	b := s.getLocales()
+	c := b.Catalog(b.Negotiate(r.URL.Query().Get("lang"), r.Header.Get("Accept-Language")))
+	langs := b.Languages()
+	links := make([]languageLink, 0, len(langs))
+	for _, l := range langs {
+		q := r.URL.Query()
+		q.Set("lang", l.Tag)
+		links = append(links, languageLink{Tag: l.Tag, Name: l.Name, URL: r.URL.Path + "?" + q.Encode(), Current: l.Tag == c.Lang})
+	}
+	return c, links
+}
+
+// localeData adds the catalog fields every page template may use: Lang and Dir for the html
+// element, T for messages ({{.T.access_to}}) and Languages for the switcher.
+func localeData(data map[string]interface{}, c *i18n.Catalog, links []languageLink) {
+	data["Lang"] = c.Lang
+	data["Dir"] = c.Dir
+	data["T"] = c.Messages
+	data["Languages"] = links
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+)
+
+// promContentType is the Prometheus text exposition format (0.0.4), which Prometheus and
+// OpenMetrics scrapers both accept.
+const promContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// latencyBuckets are the upper bounds, in seconds, of sb29guard_request_duration_seconds.
+var latencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// instrumentedHandlers are the routes counted in the request metrics; their histograms are
+// exported from the start so dashboards see zeroes rather than missing series.
+var instrumentedHandlers = []string{"classify", "explain"}
+
+type requestKey struct {
+	handler, class string
+	code           int
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// requestMetrics counts /explain and /classify requests. The zero value is ready to use.
+type requestMetrics struct {
+	mu       sync.Mutex
+	requests map[requestKey]uint64
+	latency  map[string]*histogram
+
+	// the policy hash is cached per policy pointer; hashing on every scrape is wasteful
+	hashOf *policy.Policy
+	hash   string
+}
+
+func (m *requestMetrics) observe(handler, class string, code int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.requests == nil {
+		m.requests = map[requestKey]uint64{}
+		m.latency = map[string]*histogram{}
+	}
+	m.requests[requestKey{handler, class, code}]++
+	h := m.latency[handler]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(latencyBuckets))}
+		m.latency[handler] = h
+	}
+	secs := d.Seconds()
+	for i, le := range latencyBuckets {
+		if secs <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += secs
+	h.count++
+}
+
+func (m *requestMetrics) policyHash(p *policy.Policy) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.hashOf != p {
+		m.hashOf, m.hash = p, p.CanonicalHash()
+	}
+	return m.hash
+}
+
+// metricsWriter records the status code and the classification a handler answered with.
+type metricsWriter struct {
+	http.ResponseWriter
+	code  int
+	class string
+}
+
+func (w *metricsWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// noteClassification labels the current request's metrics with class; a no-op when the
+// handler is not instrumented.
+func noteClassification(w http.ResponseWriter, class string) {
+	if mw, ok := w.(*metricsWriter); ok {
+		mw.class = class
+	}
+}
+
+// instrument counts requests to h under name by classification and status code, and
+// records their latency.
+func (s *Server) instrument(name string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		mw := &metricsWriter{ResponseWriter: w, code: http.StatusOK}
+		h(mw, r)
+		class := mw.class
+		if class == "" {
+			class = "none"
+		}
+		s.metrics.observe(name, class, mw.code, time.Since(start))
+	}
+}
+
+// wantsPrometheus reports whether a /metrics request asked for the Prometheus exposition:
+// ?format=prometheus (or openmetrics), or an Accept header naming text/plain or
+// application/openmetrics-text as scrapers send. Anything else keeps the JSON response.
+func wantsPrometheus(r *http.Request) bool {
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "prometheus", "openmetrics":
+		return true
+	case "json":
+		return false
+	}
+	accept := strings.ToLower(r.Header.Get("Accept"))
+	return strings.Contains(accept, "application/openmetrics-text") || strings.Contains(accept, "text/plain")
+}
+
+// writePrometheus writes every metric in the Prometheus text format, series sorted by labels.
+func (s *Server) writePrometheus(w io.Writer) {
+	p := s.getPolicy()
+	s.refreshMu.RLock()
+	lrTime := s.lastRefreshTime
+	lrSrc := s.lastRefreshSource
+	rCount := s.refreshCount
+	eCount := s.refreshErrorCount
+	reasons := make([]string, 0, len(s.refreshErrors))
+	byReason := make(map[string]int, len(s.refreshErrors))
+	for k, v := range s.refreshErrors {
+		reasons = append(reasons, k)
+		byReason[k] = v
+	}
+	s.refreshMu.RUnlock()
+	sort.Strings(reasons)
+	if lrSrc == "" {
+		lrSrc = "startup"
+	}
+	hash := s.metrics.policyHash(p)
+
+	m := &s.metrics
+	m.mu.Lock()
+	keys := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.handler != b.handler {
+			return a.handler < b.handler
+		}
+		if a.class != b.class {
+			return a.class < b.class
+		}
+		return a.code < b.code
+	})
+	metricHeader(w, "sb29guard_requests_total", "counter", "Requests to /explain and /classify by classification and HTTP status code.")
+	for _, k := range keys {
+		fmt.Fprintf(w, "sb29guard_requests_total{handler=%s,classification=%s,code=\"%d\"} %d\n",
+			labelValue(k.handler), labelValue(k.class), k.code, m.requests[k])
+	}
+	metricHeader(w, "sb29guard_request_duration_seconds", "histogram", "Latency of /explain and /classify requests.")
+	for _, name := range instrumentedHandlers {
+		h := m.latency[name]
+		if h == nil {
+			h = &histogram{counts: make([]uint64, len(latencyBuckets))}
+		}
+		var cum uint64
+		for i, le := range latencyBuckets {
+			cum += h.counts[i]
+			fmt.Fprintf(w, "sb29guard_request_duration_seconds_bucket{handler=%s,le=%q} %d\n", labelValue(name), formatFloat(le), cum)
+		}
+		fmt.Fprintf(w, "sb29guard_request_duration_seconds_bucket{handler=%s,le=\"+Inf\"} %d\n", labelValue(name), h.count)
+		fmt.Fprintf(w, "sb29guard_request_duration_seconds_sum{handler=%s} %s\n", labelValue(name), formatFloat(h.sum))
+		fmt.Fprintf(w, "sb29guard_request_duration_seconds_count{handler=%s} %d\n", labelValue(name), h.count)
+	}
+	m.mu.Unlock()
+
+	metricHeader(w, "sb29guard_policy_refresh_total", "counter", "Policy refresh attempts by result.")
+	fmt.Fprintf(w, "sb29guard_policy_refresh_total{result=\"error\"} %d\n", eCount)
+	fmt.Fprintf(w, "sb29guard_policy_refresh_total{result=\"success\"} %d\n", rCount)
+	metricHeader(w, "sb29guard_policy_refresh_errors_total", "counter", "Failed policy refreshes by reason.")
+	for _, k := range reasons {
+		fmt.Fprintf(w, "sb29guard_policy_refresh_errors_total{reason=%s} %d\n", labelValue(k), byReason[k])
+	}
+	if !lrTime.IsZero() {
+		metricHeader(w, "sb29guard_policy_last_refresh_timestamp_seconds", "gauge", "Unix time of the last policy refresh attempt.")
+		fmt.Fprintf(w, "sb29guard_policy_last_refresh_timestamp_seconds %s\n", formatFloat(float64(lrTime.UnixMilli())/1000))
+	}
+	metricHeader(w, "sb29guard_policy_records", "gauge", "Records in the active policy.")
+	fmt.Fprintf(w, "sb29guard_policy_records %d\n", len(p.Records))
+	metricHeader(w, "sb29guard_policy_expired_records", "gauge", "Records in the active policy past their expiry date.")
+	fmt.Fprintf(w, "sb29guard_policy_expired_records %d\n", p.ExpiredCount(time.Now()))
+	metricHeader(w, "sb29guard_policy_info", "gauge", "The active policy; always 1.")
+	fmt.Fprintf(w, "sb29guard_policy_info{version=%s,hash=%s,source=%s} 1\n", labelValue(p.Version), labelValue(hash), labelValue(lrSrc))
+}
+
+func metricHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelValue quotes v as a Prometheus label value.
+func labelValue(v string) string { return `"` + labelEscaper.Replace(v) + `"` }
+
+func formatFloat(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
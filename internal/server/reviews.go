@@ -0,0 +1,342 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/i18n"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/notify"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/reviews"
+)
+
+// reviewTokenTTL is how long an explain page's request form stays valid.
+const reviewTokenTTL = time.Hour
+
+// RateLimit bounds review request submissions per client address and in total within each
+// Window. Zero disables the respective limit.
+type RateLimit struct {
+	PerClient int
+	Total     int
+	Window    time.Duration
+}
+
+// DefaultReviewRate allows a handful of requests per staff member per hour and caps the whole
+// building well below what could swamp the privacy officer.
+var DefaultReviewRate = RateLimit{PerClient: 5, Total: 60, Window: time.Hour}
+
+// reviewDesk is the state behind the opt-in request form: the queue, the key signing form
+// tokens and the submission limiter. Client addresses live only in the limiter's memory.
+type reviewDesk struct {
+	queue   *reviews.Queue
+	key     []byte
+	limiter *rateLimiter
+}
+
+// SetReviews enables the review request form on /explain, storing submissions in q and
+// limiting them by rl. Form tokens are signed with a per-process key, so a restart invalidates
+// forms that are already open. Call before Start.
+func (s *Server) SetReviews(q *reviews.Queue, rl RateLimit) error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("generate form key: %w", err)
+	}
+	if rl.Window <= 0 {
+		rl.Window = time.Hour
+	}
+	s.mu.Lock()
+	s.reviews = &reviewDesk{queue: q, key: key, limiter: &rateLimiter{limit: rl, counts: map[string]int{}}}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Server) getReviews() *reviewDesk {
+	s.mu.RLock()
+	d := s.reviews
+	s.mu.RUnlock()
+	return d
+}
+
+// token returns the form token for domain issued at t: "<unix>.<hmac>". It stands in for a
+// CSRF cookie, since the explain page sets none.
+func (d *reviewDesk) token(domain string, t time.Time) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return ts + "." + d.sign(ts, domain)
+}
+
+func (d *reviewDesk) sign(ts, domain string) string {
+	m := hmac.New(sha256.New, d.key)
+	m.Write([]byte(ts + "|" + domain))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+// validToken reports whether tok was issued for domain within reviewTokenTTL of now.
+func (d *reviewDesk) validToken(tok, domain string, now time.Time) bool {
+	ts, mac, ok := strings.Cut(tok, ".")
+	if !ok {
+		return false
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := now.Sub(time.Unix(sec, 0))
+	if age < -time.Minute || age > reviewTokenTTL {
+		return false
+	}
+	return hmac.Equal([]byte(mac), []byte(d.sign(ts, domain)))
+}
+
+// rateLimiter counts submissions in fixed windows; all counts reset when a window ends.
+type rateLimiter struct {
+	limit RateLimit
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[string]int
+	total  int
+}
+
+// allow records one submission from client unless a limit is reached, in which case it
+// returns false and the time until the window resets.
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.start) >= l.limit.Window {
+		l.start, l.counts, l.total = now, map[string]int{}, 0
+	}
+	if (l.limit.PerClient > 0 && l.counts[client] >= l.limit.PerClient) || (l.limit.Total > 0 && l.total >= l.limit.Total) {
+		return false, l.start.Add(l.limit.Window).Sub(now)
+	}
+	l.counts[client]++
+	l.total++
+	return true, 0
+}
+
+// clientAddr is the connection's remote IP. Behind a reverse proxy every client shares the
+// proxy's address, so PerClient then behaves like a second Total.
+func clientAddr(r *http.Request) string {
+	if h, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return h
+	}
+	return r.RemoteAddr
+}
+
+// sameOrigin rejects form posts a browser marks as coming from another site. Requests without
+// Sec-Fetch-Site or Origin (older browsers, curl) fall through to the token check.
+func sameOrigin(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	for _, h := range []string{r.Host, r.Header.Get("X-Forwarded-Host"), r.Header.Get("X-Original-Host")} {
+		if h != "" && strings.EqualFold(u.Host, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleRequestAccess accepts the explain page's review request form (POST /request-access)
+// and redirects back to the explain page with the request's reference.
+func (s *Server) handleRequestAccess(w http.ResponseWriter, r *http.Request) {
+	d := s.getReviews()
+	if d == nil {
+		writeJSONError(w, http.StatusNotFound, "not_enabled", "review requests not enabled")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Frame-Options", "DENY")
+	cat, _ := s.pageLocale(w, r)
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeReviewError(w, r, cat, http.StatusMethodNotAllowed, "method_not_allowed", "", "Review requests are submitted from the explain page.", "request_error_method")
+		return
+	}
+	if !sameOrigin(r) {
+		writeReviewError(w, r, cat, http.StatusForbidden, "forbidden", "", "Review requests must be submitted from this site.", "request_error_origin")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, 16<<10)
+	if err := r.ParseForm(); err != nil {
+		writeReviewError(w, r, cat, http.StatusBadRequest, "invalid_parameter", "", "The form could not be read.", "request_error_form")
+		return
+	}
+	if lang := r.PostForm.Get("lang"); lang != "" {
+		// Answer in the language the form was shown in.
+		b := s.getLocales()
+		cat = b.Catalog(b.Negotiate(lang, r.Header.Get("Accept-Language")))
+	}
+	domain := r.PostForm.Get("domain")
+	now := time.Now()
+	if !d.validToken(r.PostForm.Get("token"), domain, now) {
+		writeReviewError(w, r, cat, http.StatusForbidden, "expired_token", domain, "This form has expired. Reload the page and try again.", "request_error_expired")
+		return
+	}
+	if ok, retry := d.limiter.allow(clientAddr(r), now); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retry.Round(time.Second).Seconds())))
+		writeReviewError(w, r, cat, http.StatusTooManyRequests, "rate_limited", domain, "Too many requests were submitted recently. Please try again later.", "request_error_rate_limited")
+		return
+	}
+	p := s.getPolicy()
+	rec, ok := p.Lookup(domain)
+	if !ok {
+		writeReviewError(w, r, cat, http.StatusBadRequest, "not_classified", domain, "This domain is no longer restricted.", "request_error_not_classified")
+		return
+	}
+	req, err := d.queue.Submit(reviews.Request{
+		Domain:         domain,
+		Classification: rec.Classification,
+		PolicyVersion:  p.Version,
+		Justification:  r.PostForm.Get("justification"),
+		Email:          r.PostForm.Get("email"),
+	})
+	switch {
+	case errors.Is(err, reviews.ErrInvalid):
+		writeReviewError(w, r, cat, http.StatusBadRequest, "invalid_parameter", domain, strings.TrimPrefix(err.Error(), reviews.ErrInvalid.Error()+": "), "request_error_invalid")
+		return
+	case errors.Is(err, reviews.ErrQueueFull):
+		writeReviewError(w, r, cat, http.StatusServiceUnavailable, "queue_full", domain, "The review queue is full. Contact your data privacy team directly.", "request_error_queue_full")
+		return
+	case err != nil:
+		writeReviewError(w, r, cat, http.StatusInternalServerError, "reviews_error", domain, "The request could not be saved. Contact your data privacy team directly.", "request_error_failed")
+		return
+	}
+	// The email stays in the queue; notifications only say whether one was given.
+	s.notifier.Notify(notify.Event{
+		Type: notify.RequestSubmitted,
+		Text: fmt.Sprintf("Review requested for %s (%s), request %s", req.Domain, req.Classification, req.ID),
+		Data: map[string]interface{}{
+			"id":             req.ID,
+			"domain":         req.Domain,
+			"classification": req.Classification,
+			"policy_version": req.PolicyVersion,
+			"justification":  req.Justification,
+			"has_email":      req.Email != "",
+		},
+	})
+	back := "/explain?d=" + url.QueryEscape(domain) + "&ref=" + req.ID
+	if lang := r.PostForm.Get("lang"); i18n.ValidTag(lang) {
+		back += "&lang=" + lang // keep the language the form was shown in
+	}
+	http.Redirect(w, r, back, http.StatusSeeOther)
+}
+
+// writeReviewError renders a minimal page in cat's language explaining why a request was not
+// recorded (message msgID), linking back to the explain page for domain when known. Clients
+// asking for JSON (see wantsJSON) get the general error shape with kind and detail instead.
+func writeReviewError(w http.ResponseWriter, r *http.Request, cat *i18n.Catalog, code int, kind, domain, detail, msgID string) {
+	if wantsJSON(r) {
+		writeJSONError(w, code, kind, detail)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	back := ""
+	if domain != "" {
+		back = fmt.Sprintf(`<p><a href="/explain?d=%s&amp;lang=%s">%s</a></p>`, htmlEscape(url.QueryEscape(domain)), htmlEscape(url.QueryEscape(cat.Lang)), htmlEscape(cat.Messages["request_error_back"]))
+	}
+	_, _ = fmt.Fprintf(w, "<html lang=\"%s\" dir=\"%s\"><body><h1>%s</h1><p>%s</p>%s</body></html>",
+		htmlEscape(cat.Lang), htmlEscape(cat.Dir), htmlEscape(cat.Messages["request_error_title"]), htmlEscape(cat.Messages[msgID]), back)
+}
+
+// handleAdminRequests lists review requests: GET /admin/requests?status=open|closed|all
+// (default open).
+func (s *Server) handleAdminRequests(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	d := s.getReviews()
+	if d == nil {
+		writeJSONError(w, http.StatusNotFound, "not_enabled", "review requests not enabled")
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = reviews.StatusOpen
+	case "all":
+		status = ""
+	case reviews.StatusOpen, reviews.StatusClosed:
+	default:
+		writeJSONError(w, http.StatusBadRequest, "invalid_parameter", "status must be open, closed or all")
+		return
+	}
+	list, err := d.queue.List(status)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "reviews_error", err.Error())
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"requests": list})
+}
+
+// handleAdminRequestClose closes a review request: POST /admin/requests/close with id,
+// resolution and optional note (query or form).
+func (s *Server) handleAdminRequestClose(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSONError(w, http.StatusMethodNotAllowed, "method_not_allowed", "use POST")
+		return
+	}
+	d := s.getReviews()
+	if d == nil {
+		writeJSONError(w, http.StatusNotFound, "not_enabled", "review requests not enabled")
+		return
+	}
+	id := r.FormValue("id")
+	if strings.TrimSpace(id) == "" {
+		writeJSONError(w, http.StatusBadRequest, "invalid_parameter", "id required")
+		return
+	}
+	req, err := d.queue.Close(id, r.FormValue("resolution"), r.FormValue("note"))
+	switch {
+	case errors.Is(err, reviews.ErrInvalid):
+		writeJSONError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	case errors.Is(err, reviews.ErrNotFound):
+		writeJSONError(w, http.StatusNotFound, "not_found", "request "+id+" not found")
+		return
+	case errors.Is(err, reviews.ErrAlreadyClosed):
+		writeJSONError(w, http.StatusConflict, "already_closed", "request "+id+" already closed")
+		return
+	case err != nil:
+		writeJSONError(w, http.StatusInternalServerError, "reviews_error", err.Error())
+		return
+	}
+	_ = json.NewEncoder(w).Encode(req)
+}
+
+// validRequestRef reports whether ref looks like a request ID, so only IDs are echoed on the
+// explain page's confirmation.
+func validRequestRef(ref string) bool {
+	if len(ref) != 12 {
+		return false
+	}
+	for _, c := range ref {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/notify"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/reviews"
+)
+
+func TestRequestAccessFormQueueAndAdmin(t *testing.T) {
+	srv := newTestServer(t)
+	h := srv.Handler()
+	get := func(target, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = remote
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	if body := get("/explain?d=exampletool.com", "192.0.2.1:1234").Body.String(); strings.Contains(body, `action="/request-access"`) {
+		t.Fatalf("form shown without SetReviews")
+	}
+	if rr := get("/request-access", "192.0.2.1:1234"); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 when disabled, got %d", rr.Code)
+	}
+
+	q, err := reviews.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open queue: %v", err)
+	}
+	if err := srv.SetReviews(q, RateLimit{PerClient: 2, Total: 10, Window: time.Hour}); err != nil {
+		t.Fatalf("SetReviews: %v", err)
+	}
+	sink := &recordingSink{}
+	d := notify.New(notify.Options{Sinks: []notify.Sink{sink}})
+	srv.SetNotifier(d)
+	body := get("/explain?d=a.trackingwidgets.io", "192.0.2.1:1234").Body.String()
+	m := regexp.MustCompile(`name="token" value="([^"]+)"`).FindStringSubmatch(body)
+	if m == nil || !strings.Contains(body, `name="domain" value="a.trackingwidgets.io"`) {
+		t.Fatalf("form missing from explain page: %s", body)
+	}
+	token := m[1]
+	post := func(form url.Values, set func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/request-access", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.RemoteAddr = "192.0.2.1:1234"
+		if set != nil {
+			set(req)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	good := url.Values{"domain": {"a.trackingwidgets.io"}, "token": {token}, "justification": {"Reading logs for 3rd grade"}, "email": {"teacher@school.org"}, "lang": {"es"}}
+
+	rr := post(good, func(r *http.Request) {
+		r.Header.Set("Sec-Fetch-Site", "same-origin")
+		r.Header.Set("Origin", "http://example.com")
+	})
+	loc := rr.Header().Get("Location")
+	if rr.Code != http.StatusSeeOther || !strings.HasPrefix(loc, "/explain?d=a.trackingwidgets.io&ref=") || !strings.HasSuffix(loc, "&lang=es") {
+		t.Fatalf("expected redirect, got %d %q %s", rr.Code, loc, rr.Body.String())
+	}
+	ref := strings.TrimSuffix(strings.TrimPrefix(loc, "/explain?d=a.trackingwidgets.io&ref="), "&lang=es")
+	_ = d.Close(context.Background())
+	if len(sink.events) != 1 || sink.events[0].Type != notify.RequestSubmitted || sink.events[0].Data["id"] != ref ||
+		sink.events[0].Data["has_email"] != true || strings.Contains(fmt.Sprint(sink.events[0]), "teacher@school.org") {
+		t.Fatalf("unexpected notifications: %+v", sink.events)
+	}
+	if body := get(loc, "192.0.2.1:1234").Body.String(); !strings.Contains(body, "Solicitud de revisión enviada. Referencia: <code>"+ref+"</code>") || strings.Contains(body, `name="token"`) {
+		t.Fatalf("confirmation missing: %s", body)
+	}
+
+	forged := url.Values{"domain": {"exampletool.com"}, "token": {token}, "justification": {"x"}}
+	for name, c := range map[string]struct {
+		form url.Values
+		set  func(*http.Request)
+		code int
+	}{
+		"cross-site":      {good, func(r *http.Request) { r.Header.Set("Sec-Fetch-Site", "cross-site") }, http.StatusForbidden},
+		"foreign origin":  {good, func(r *http.Request) { r.Header.Set("Origin", "https://evil.example") }, http.StatusForbidden},
+		"token for other": {forged, nil, http.StatusForbidden},
+		"no token":        {url.Values{"domain": {"exampletool.com"}, "justification": {"x"}}, nil, http.StatusForbidden},
+		"bad email":       {url.Values{"domain": good["domain"], "token": {token}, "justification": {"x"}, "email": {"nope"}}, nil, http.StatusBadRequest},
+	} {
+		if rr := post(c.form, c.set); rr.Code != c.code {
+			t.Errorf("%s: expected %d got %d %s", name, c.code, rr.Code, rr.Body.String())
+		}
+	}
+	// The bad email used this client's second (and last) submission this hour.
+	rr = post(good, nil)
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 429 with Retry-After, got %d", rr.Code)
+	}
+	// The error page follows the language the form was shown in.
+	if body := rr.Body.String(); !strings.Contains(body, `<html lang="es"`) || !strings.Contains(body, "Solicitud no enviada") || !strings.Contains(body, ">Volver</a>") {
+		t.Fatalf("expected a Spanish error page, got %s", body)
+	}
+	if rr := post(good, func(r *http.Request) { r.Header.Set("Accept", "application/json") }); rr.Code != http.StatusTooManyRequests ||
+		!strings.Contains(rr.Body.String(), `"error":"rate_limited"`) {
+		t.Fatalf("expected JSON error for an API client, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := get("/admin/requests", "203.0.113.5:4000"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for remote admin, got %d", rr.Code)
+	}
+	rr = get("/admin/requests", "127.0.0.1:4000")
+	var list struct{ Requests []reviews.Request }
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil || len(list.Requests) != 1 {
+		t.Fatalf("unexpected list: %d %s", rr.Code, rr.Body.String())
+	}
+	got := list.Requests[0]
+	if got.ID != ref || got.Domain != "a.trackingwidgets.io" || got.Classification != "EXPIRED_DPA" || got.PolicyVersion != "0.1.0" || got.Email != "teacher@school.org" {
+		t.Fatalf("unexpected request: %+v", got)
+	}
+	if strings.Contains(rr.Body.String(), "192.0.2.1") {
+		t.Fatalf("client address stored: %s", rr.Body.String())
+	}
+
+	closeReq := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/requests/close?"+query, nil)
+		req.RemoteAddr = "127.0.0.1:4000"
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := closeReq("id=" + ref + "&resolution=denied&note=use+the+approved+reader"); rr.Code != 200 || !strings.Contains(rr.Body.String(), `"status":"closed"`) {
+		t.Fatalf("close failed: %d %s", rr.Code, rr.Body.String())
+	}
+	for query, code := range map[string]int{
+		"id=" + ref + "&resolution=denied":  http.StatusConflict,
+		"id=000000000000&resolution=denied": http.StatusNotFound,
+		"id=" + ref + "&resolution=maybe":   http.StatusBadRequest,
+		"resolution=denied":                 http.StatusBadRequest,
+	} {
+		if rr := closeReq(query); rr.Code != code {
+			t.Errorf("%s: expected %d got %d", query, code, rr.Code)
+		}
+	}
+	if rr := get("/admin/requests", "127.0.0.1:4000"); !strings.Contains(rr.Body.String(), `"requests":[]`) {
+		t.Fatalf("expected no open requests: %s", rr.Body.String())
+	}
+	if rr := get("/admin/requests?status=closed", "127.0.0.1:4000"); !strings.Contains(rr.Body.String(), `"resolution":"denied"`) {
+		t.Fatalf("expected closed request: %s", rr.Body.String())
+	}
+}
+
+// recordingSink keeps delivered events; read it only after the dispatcher is closed.
+type recordingSink struct{ events []notify.Event }
+
+func (r *recordingSink) Name() string { return "recording" }
+func (r *recordingSink) Send(_ context.Context, e notify.Event) error {
+	r.events = append(r.events, e)
+	return nil
+}
+
+func TestReviewTokenExpiry(t *testing.T) {
+	d := &reviewDesk{key: []byte("k")}
+	now := time.Unix(1_700_000_000, 0)
+	tok := d.token("exampletool.com", now)
+	if !d.validToken(tok, "exampletool.com", now.Add(59*time.Minute)) {
+		t.Fatalf("fresh token rejected")
+	}
+	if d.validToken(tok, "exampletool.com", now.Add(61*time.Minute)) || d.validToken(tok, "other.com", now) || d.validToken("x.y", "exampletool.com", now) {
+		t.Fatalf("invalid token accepted")
+	}
+}
@@ -2,8 +2,12 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"net"
@@ -14,7 +18,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/hits"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/i18n"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/journal"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/notify"
 	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/reviews"
 )
 
 //go:embed templates/*.html templates/*.txt
@@ -49,6 +58,15 @@ code{background:var(--panel);padding:2px 6px;border-radius:6px;font-size:.875em}
 .chips{margin:.4rem 0 .2rem}
 .badge{display:inline-block;padding:.35rem .7rem;background:var(--badge);color:#fff;border-radius:999px;font-size:.75rem;font-weight:700;letter-spacing:.4px;text-transform:uppercase}
 .badge-lg{font-size:.85rem}
+.request-access{margin:1rem 0 0}
+.request-access summary{cursor:pointer;color:var(--accent)}
+.request-access form{display:grid;gap:.4rem;margin:.6rem 0 0}
+.request-access label{font-size:.9rem;color:var(--muted)}
+.request-access textarea,.request-access input{font:inherit;color:var(--fg);background:var(--bg);border:1px solid var(--ring);border-radius:8px;padding:.4rem .5rem}
+.request-access button{justify-self:start;font:inherit;padding:.4rem .9rem;border:0;border-radius:8px;background:var(--accent);color:#fff;cursor:pointer}
+.request-sent{margin:1rem 0 0}
+.languages{margin:.5rem 0 0;font-size:.85rem}
+.languages a,.languages strong{margin-right:.6rem}
 
 /* Larger viewports: show decorative ASCII, place beside card */
 @media (min-width: 720px){
@@ -67,15 +85,37 @@ type Server struct {
 	ohioASCII         string
 	lawURL            string
 	allowHostFallback bool
+	journal           *journal.Journal
+	target            PolicyTarget // rollbacks go through it; nil means the server itself
+	hits              *hits.Aggregator
+	auth              *Auth
+	reviews           *reviewDesk
+	notifier          *notify.Dispatcher
+	locales           *i18n.Bundle
 	mu                sync.RWMutex
 
+	// lifecycle: limits apply to the listeners created by Start; done closes on Shutdown.
+	limits      Limits
+	handler     http.Handler
+	handlerOnce sync.Once
+	tlsAddr     string
+	tlsConfig   *tls.Config
+	httpSrvs    []*http.Server
+	done        chan struct{}
+	doneOnce    sync.Once
+	stopOnce    sync.Once
+
 	// refresh/metrics fields
 	refreshMu         sync.RWMutex
 	lastRefreshTime   time.Time
 	lastRefreshSource string
 	refreshCount      int
 	refreshErrorCount int
+	refreshErrors     map[string]int // by reason
 	lastRefreshError  string
+
+	// request counters and latency for the Prometheus exposition
+	metrics requestMetrics
 }
 
 // New creates a new Server bound to addr using the supplied policy.
@@ -90,32 +130,189 @@ func New(addr string, p *policy.Policy) *Server {
 	if b, err := templateFS.ReadFile("templates/ohio.ascii-art.txt"); err == nil {
 		ascii = string(b)
 	}
-	// Determine law URL target (configurable via env var; stable default to ORC section)
-	law := os.Getenv("SB29_LAW_URL")
-	if strings.TrimSpace(law) == "" {
-		law = "https://search-prod.lis.state.oh.us/api/v2/general_assembly_135/legislation/sb29/05_EN/pdf/"
-	}
-	// Feature flag: allow Host header as last-resort fallback (default: false)
-	allowHost := strings.EqualFold(strings.TrimSpace(os.Getenv("SB29_ALLOW_HOST_FALLBACK")), "true")
-	return &Server{addr: addr, policy: p, tmpl: tmpl, inlineCSS: template.CSS(defaultCSS), ohioASCII: ascii, lawURL: law, allowHostFallback: allowHost}
+	s := &Server{addr: addr, policy: p, tmpl: tmpl, inlineCSS: template.CSS(defaultCSS), ohioASCII: ascii, limits: DefaultLimits}
+	s.applyEnv()
+	return s
 }
 
 // NewWithTemplates creates a new Server using caller-supplied templates and CSS.
 // tmpl must include templates named layout.html, explain.html, and root.html.
 func NewWithTemplates(addr string, p *policy.Policy, tmpl *template.Template, css string) *Server {
-	return &Server{addr: addr, policy: p, tmpl: tmpl, inlineCSS: template.CSS(css)}
+	s := &Server{addr: addr, policy: p, tmpl: tmpl, inlineCSS: template.CSS(css), limits: DefaultLimits}
+	s.applyEnv()
+	return s
+}
+
+// DefaultLawURL is the /law redirect target when none is configured.
+const DefaultLawURL = "https://search-prod.lis.state.oh.us/api/v2/general_assembly_135/legislation/sb29/05_EN/pdf/"
+
+// applyEnv reads SB29_LAW_URL, SB29_ALLOW_HOST_FALLBACK and the legacy admin user; the CLI
+// may override them from flags or the config file via SetLawURL, SetAllowHostFallback and SetAuth.
+func (s *Server) applyEnv() {
+	s.SetLawURL(os.Getenv("SB29_LAW_URL"))
+	// Feature flag: allow Host header as last-resort fallback (default: false)
+	s.allowHostFallback = strings.EqualFold(strings.TrimSpace(os.Getenv("SB29_ALLOW_HOST_FALLBACK")), "true")
+	s.auth = DefaultAuth()
+}
+
+// SetLawURL sets the /law redirect target; empty restores DefaultLawURL. Call before Start.
+func (s *Server) SetLawURL(u string) {
+	if strings.TrimSpace(u) == "" {
+		u = DefaultLawURL
+	}
+	s.lawURL = u
+}
+
+// SetNotifier sends server events (currently review request submissions) to d. Call before Start.
+func (s *Server) SetNotifier(d *notify.Dispatcher) { s.notifier = d }
+
+// SetAllowHostFallback enables the Host header as the last-resort source of the original
+// domain on /explain. Call before Start.
+func (s *Server) SetAllowHostFallback(on bool) { s.allowHostFallback = on }
+
+// Limits bounds how long a client may hold a connection and how large its request headers
+// may be. A zero duration or size means no limit (the net/http default).
+type Limits struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+}
+
+// DefaultLimits suit a small explain page behind a proxy: slow or idle clients are cut off
+// well before they can pile up connections.
+var DefaultLimits = Limits{
+	ReadHeaderTimeout: 5 * time.Second,
+	ReadTimeout:       10 * time.Second,
+	WriteTimeout:      15 * time.Second,
+	IdleTimeout:       60 * time.Second,
+	MaxHeaderBytes:    64 << 10,
+}
+
+// SetLimits replaces the connection limits used by Start. Call before Start.
+func (s *Server) SetLimits(l Limits) { s.limits = l }
+
+// Handler returns the server's routes on its own mux, so several Servers can share a
+// process (or be mounted under httptest) without touching http.DefaultServeMux.
+func (s *Server) Handler() http.Handler {
+	s.handlerOnce.Do(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/health", s.guard(GroupPublic, s.handleHealth))
+		mux.HandleFunc("/metrics", s.guard(GroupOps, s.handleMetrics))
+		mux.HandleFunc("/metrics/history", s.guard(GroupOps, s.handleHistory))
+		mux.HandleFunc("/admin/", s.guard(GroupAdmin, func(w http.ResponseWriter, r *http.Request) {
+			writeJSONError(w, http.StatusNotFound, "not_found", "no admin endpoint "+r.URL.Path)
+		}))
+		mux.HandleFunc("/admin/rollback", s.guard(GroupAdmin, s.handleAdminRollback))
+		mux.HandleFunc("/admin/summary", s.guard(GroupAdmin, s.handleAdminSummary))
+		mux.HandleFunc("/admin/requests", s.guard(GroupAdmin, s.handleAdminRequests))
+		mux.HandleFunc("/admin/requests/close", s.guard(GroupAdmin, s.handleAdminRequestClose))
+		mux.HandleFunc("/law", s.guard(GroupPublic, s.handleLaw))
+		mux.HandleFunc("/classify", s.guard(GroupPublic, s.instrument("classify", s.handleClassify)))
+		mux.HandleFunc("/domain-list", s.guard(GroupOps, s.handleDomainList))
+		mux.HandleFunc("/explain", s.guard(GroupPublic, s.instrument("explain", s.handleExplain)))
+		mux.HandleFunc("/request-access", s.guard(GroupPublic, s.handleRequestAccess))
+		mux.HandleFunc("/", s.guard(GroupPublic, s.handleRoot))
+		s.handler = mux
+	})
+	return s.handler
+}
+
+// SetTLS additionally serves HTTPS on addr using cfg (which must supply certificates, e.g.
+// via GetCertificate). The plain HTTP listener keeps running unless the server address is
+// empty. Call before Start.
+func (s *Server) SetTLS(addr string, cfg *tls.Config) {
+	s.tlsAddr, s.tlsConfig = addr, cfg
 }
 
-// Start begins serving HTTP until the listener stops.
+// Start serves HTTP on the configured address (and HTTPS when SetTLS was called) until
+// Shutdown, after which it returns nil. If any listener fails the others are closed and
+// the error is returned.
 func (s *Server) Start() error {
-	http.HandleFunc("/health", s.handleHealth)
-	http.HandleFunc("/metrics", s.handleMetrics)
-	http.HandleFunc("/law", s.handleLaw)
-	http.HandleFunc("/classify", s.handleClassify)
-	http.HandleFunc("/domain-list", s.handleDomainList)
-	http.HandleFunc("/explain", s.handleExplain)
-	http.HandleFunc("/", s.handleRoot)
-	return http.ListenAndServe(s.addr, nil)
+	var srvs []*http.Server
+	if s.addr != "" || s.tlsConfig == nil {
+		srvs = append(srvs, s.newHTTPServer(s.addr, nil))
+	}
+	if s.tlsConfig != nil {
+		srvs = append(srvs, s.newHTTPServer(s.tlsAddr, s.tlsConfig))
+	}
+	s.mu.Lock()
+	select {
+	case <-s.Done():
+		s.mu.Unlock()
+		return nil
+	default:
+	}
+	s.httpSrvs = srvs
+	s.mu.Unlock()
+	errc := make(chan error, len(srvs))
+	for _, hs := range srvs {
+		go func(hs *http.Server) {
+			var err error
+			if hs.TLSConfig != nil {
+				err = hs.ListenAndServeTLS("", "")
+			} else {
+				err = hs.ListenAndServe()
+			}
+			if errors.Is(err, http.ErrServerClosed) {
+				err = nil
+			}
+			errc <- err
+		}(hs)
+	}
+	var first error
+	for range srvs {
+		if err := <-errc; err != nil && first == nil {
+			first = err
+			for _, hs := range srvs {
+				_ = hs.Close()
+			}
+		}
+	}
+	return first
+}
+
+func (s *Server) newHTTPServer(addr string, cfg *tls.Config) *http.Server {
+	if pool := s.getAuth().ClientCAs(); cfg != nil && pool != nil {
+		// Ask for a client certificate without requiring one; routes decide via AuthCert.
+		cfg = cfg.Clone()
+		cfg.ClientCAs, cfg.ClientAuth = pool, tls.VerifyClientCertIfGiven
+	}
+	return &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		TLSConfig:         cfg,
+		ReadHeaderTimeout: s.limits.ReadHeaderTimeout,
+		ReadTimeout:       s.limits.ReadTimeout,
+		WriteTimeout:      s.limits.WriteTimeout,
+		IdleTimeout:       s.limits.IdleTimeout,
+		MaxHeaderBytes:    s.limits.MaxHeaderBytes,
+	}
+}
+
+// Shutdown stops accepting connections, closes Done (stopping refresh loops tied to it) and
+// waits for in-flight requests to finish or ctx to expire, like http.Server.Shutdown.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopOnce.Do(func() { close(s.doneChan()) })
+	srvs := s.httpSrvs
+	s.mu.Unlock()
+	var first error
+	for _, hs := range srvs {
+		if err := hs.Shutdown(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Done is closed when Shutdown begins. Background work such as policy refresh should stop then.
+func (s *Server) Done() <-chan struct{} { return s.doneChan() }
+
+func (s *Server) doneChan() chan struct{} {
+	s.doneOnce.Do(func() { s.done = make(chan struct{}) })
+	return s.done
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
@@ -124,13 +321,14 @@ func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	_, _ = fmt.Fprintf(w, `{"status":"ok","policy_version":%q}`, p.Version)
 }
 
-func (s *Server) handleRoot(w http.ResponseWriter, _ *http.Request) {
+func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	p := s.getPolicy()
+	cat, langs := s.pageLocale(w, r)
 	data := map[string]interface{}{
 		"CSS":             s.inlineCSS,
-		"Title":           "SB29 Guard",
-		"Header":          "SB29 Guard",
+		"Title":           cat.Messages["app_name"],
+		"Header":          cat.Messages["app_name"],
 		"ContentTemplate": "root_content",
 		"RecordCount":     len(p.Records),
 		"Year":            time.Now().Year(),
@@ -140,13 +338,19 @@ func (s *Server) handleRoot(w http.ResponseWriter, _ *http.Request) {
 		// Footer law link uses internal redirect for stability
 		"LawURL": "/law",
 	}
-	if err := s.tmpl.ExecuteTemplate(w, "layout.html", data); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_, _ = fmt.Fprintf(w, "template error: %v", err)
-	}
+	localeData(data, cat, langs)
+	s.render(w, r, cat, data)
 }
 
-func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+// handleMetrics reports policy and refresh state as JSON, or every metric in the Prometheus
+// text format when the client asks for it (see wantsPrometheus).
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+	if wantsPrometheus(r) {
+		w.Header().Set("Content-Type", promContentType)
+		s.writePrometheus(w)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	p := s.getPolicy()
 	s.refreshMu.RLock()
@@ -154,18 +358,94 @@ func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
 	lrSrc := s.lastRefreshSource
 	rCount := s.refreshCount
 	eCount := s.refreshErrorCount
+	byReason := make(map[string]int, len(s.refreshErrors))
+	for k, v := range s.refreshErrors {
+		byReason[k] = v
+	}
 	lastErr := s.lastRefreshError
 	s.refreshMu.RUnlock()
 	ts := ""
 	if !lrTime.IsZero() {
 		ts = lrTime.UTC().Format(time.RFC3339)
 	}
-	_, _ = fmt.Fprintf(w, `{"status":"ok","policy_version":%q,"records":%d,"last_refresh_time":%q,"last_refresh_source":%q,"refresh_count":%d,"refresh_error_count":%d,"last_refresh_error":%q}`,
-		p.Version, len(p.Records), ts, lrSrc, rCount, eCount, lastErr)
+	reasons, _ := json.Marshal(byReason)
+	_, _ = fmt.Fprintf(w, `{"status":"ok","policy_version":%q,"records":%d,"expired_records":%d,"last_refresh_time":%q,"last_refresh_source":%q,"refresh_count":%d,"refresh_error_count":%d,"refresh_errors_by_reason":%s,"last_refresh_error":%q}`,
+		p.Version, len(p.Records), p.ExpiredCount(time.Now()), ts, lrSrc, rCount, eCount, reasons, lastErr)
 }
 
-func (s *Server) handleExplain(w http.ResponseWriter, r *http.Request) {
+// render executes the page layout into a buffer first, so a template failure is answered
+// with a clean error page rather than half a page.
+func (s *Server) render(w http.ResponseWriter, r *http.Request, cat *i18n.Catalog, data map[string]interface{}) {
+	var buf bytes.Buffer
+	if err := s.tmpl.ExecuteTemplate(&buf, "layout.html", data); err != nil {
+		writePageError(w, r, cat, http.StatusInternalServerError, "template_error", "page could not be rendered",
+			cat.Messages["page_error_title"], cat.Messages["page_error"])
+		return
+	}
+	_, _ = buf.WriteTo(w)
+}
+
+// writePageError answers a failed page request with the negotiated error: the general JSON
+// shape with detail for clients asking for JSON (see wantsJSON), otherwise a minimal HTML
+// page in cat's language with title and the given paragraphs. None of these may carry
+// internal error text.
+func writePageError(w http.ResponseWriter, r *http.Request, cat *i18n.Catalog, code int, kind, detail, title string, paras ...string) {
+	if wantsJSON(r) {
+		writeJSONError(w, code, kind, detail)
+		return
+	}
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	var body strings.Builder
+	for _, p := range paras {
+		body.WriteString("<p>" + htmlEscape(p) + "</p>")
+	}
+	_, _ = fmt.Fprintf(w, "<html lang=\"%s\" dir=\"%s\"><body><h1>%s</h1>%s</body></html>",
+		htmlEscape(cat.Lang), htmlEscape(cat.Dir), htmlEscape(title), body.String())
+}
+
+// wantsJSON reports whether a page's client asked for JSON instead of HTML: ?format=json,
+// or an Accept header naming application/json but not text/html (browsers send the latter).
+func wantsJSON(r *http.Request) bool {
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "json":
+		return true
+	case "html":
+		return false
+	}
+	accept := strings.ToLower(r.Header.Get("Accept"))
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html")
+}
+
+// explainResponse is the JSON form of the explain page.
+type explainResponse struct {
+	Domain         string   `json:"domain"`
+	Normalized     string   `json:"normalized_domain"`
+	Rule           string   `json:"rule"`
+	Classification string   `json:"classification"`
+	Label          string   `json:"label"`
+	Description    string   `json:"description,omitempty"`
+	Severity       string   `json:"severity,omitempty"`
+	Rationale      string   `json:"rationale"`
+	Lang           string   `json:"lang"`
+	SourceRef      string   `json:"source_ref,omitempty"`
+	LastReview     string   `json:"last_review"`
+	Expires        string   `json:"expires,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	PolicyVersion  string   `json:"policy_version"`
+	PolicyHash     string   `json:"policy_hash"`
+}
+
+// handleExplain renders the explanation page for the original domain, or its JSON form when
+// the client asks for it (see wantsJSON).
+func (s *Server) handleExplain(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+	asJSON := wantsJSON(r)
+	if asJSON {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
 	q := r.URL.Query()
 	// Accept short alias 'd' (display-only param in redirect mode) as highest-precedence query value
 	orig := firstNonEmpty(q.Get("d"), q.Get("original_domain"), q.Get("original"), q.Get("domain"), q.Get("url"))
@@ -173,8 +453,9 @@ func (s *Server) handleExplain(w http.ResponseWriter, r *http.Request) {
 		// No query param provided—attempt to infer from headers (DNS redirect scenario)
 		orig = extractOriginalDomainFromHeaders(r, s.allowHostFallback)
 		if orig == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			_, _ = fmt.Fprint(w, "<p>Missing original domain (provide ?domain= or ensure X-Original-Host or X-Forwarded-Host headers are set)</p>")
+			cat, _ := s.pageLocale(w, r)
+			writePageError(w, r, cat, http.StatusBadRequest, "invalid_parameter", "original domain required (d or domain, or an X-Original-Host or X-Forwarded-Host header)",
+				cat.Messages["missing_domain_title"], cat.Messages["missing_domain"])
 			return
 		}
 	}
@@ -198,11 +479,14 @@ func (s *Server) handleExplain(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	lookupDomain := strings.TrimPrefix(orig, "www.")
+	// Browsers send internationalized hosts as punycode; show the Unicode form to people.
+	display := policy.DisplayDomain(orig)
 	p := s.getPolicy()
+	cat, langs := s.pageLocale(w, r)
 	rec, ok := p.Lookup(lookupDomain)
 	if !ok {
-		w.WriteHeader(http.StatusNotFound)
-		_, _ = fmt.Fprintf(w, "<html><body><h1>Not Classified</h1><p>The domain %s is not present in the active policy set.</p><p>Policy Version: %s</p></body></html>", orig, p.Version)
+		writePageError(w, r, cat, http.StatusNotFound, "not_found", lookupDomain+" is not classified in policy "+p.Version,
+			cat.Messages["not_classified_title"], cat.Format("not_classified", display), cat.Messages["policy_version"]+" "+p.Version)
 		return
 	}
 
@@ -213,27 +497,65 @@ func (s *Server) handleExplain(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Frame-Options", "DENY")
 	w.Header().Set("Content-Security-Policy", "default-src 'self'; style-src 'unsafe-inline'; frame-ancestors 'none'; base-uri 'none'; form-action 'self';")
 
+	noteClassification(w, rec.Classification)
+	class, _ := p.Classification(rec.Classification)
+	// Built-in and district labels are written in English; a catalog may translate them.
+	if t, ok := cat.Classification(rec.Classification); ok {
+		if t.Label != "" {
+			class.Label = t.Label
+		}
+		if t.Description != "" {
+			class.Description = t.Description
+		}
+	}
+	if asJSON {
+		// Tools looking a domain up are not blocked visits, so they are not counted as hits.
+		_ = json.NewEncoder(w).Encode(explainResponse{
+			Domain: orig, Normalized: lookupDomain, Rule: rec.Domain,
+			Classification: rec.Classification, Label: class.DisplayLabel(), Description: class.Description, Severity: class.Severity,
+			Rationale: rec.LocalizedRationale(cat.Lang), Lang: cat.Lang,
+			SourceRef: rec.SourceRef, LastReview: rec.LastReview, Expires: rec.Expires, Tags: rec.Tags,
+			PolicyVersion: p.Version, PolicyHash: s.metrics.policyHash(p),
+		})
+		return
+	}
+	if a := s.getHits(); a != nil {
+		a.Record(rec.Domain, rec.Classification, p.Version)
+	}
 	data := map[string]interface{}{
 		"CSS":             s.inlineCSS,
-		"Title":           fmt.Sprintf("Blocked: %s", orig),
-		"Header":          "Access Redirected",
+		"Title":           cat.Format("explain_title", display),
+		"Header":          cat.Messages["explain_header"],
 		"ContentTemplate": "explain_content",
-		"Original":        orig,
+		"Original":        display,
 		"Classification":  rec.Classification,
-		"Rationale":       htmlEscape(rec.Rationale),
-		"SourceRef":       htmlEscape(rec.SourceRef),
-		"PolicyVersion":   p.Version,
-		"Now":             time.Now().UTC().Format(time.RFC3339),
-		"Year":            time.Now().Year(),
-		"Page":            "explain",
-		"OhioASCII":       s.ohioASCII,
+		// Friendly presentation from the policy taxonomy (custom templates may keep using Classification).
+		"ClassificationLabel":       class.DisplayLabel(),
+		"ClassificationDescription": class.Description,
+		"BadgeColor":                class.Color,
+		"Severity":                  class.Severity,
+		"Rationale":                 htmlEscape(rec.LocalizedRationale(cat.Lang)),
+		"SourceRef":                 htmlEscape(rec.SourceRef),
+		"PolicyVersion":             p.Version,
+		"Now":                       time.Now().UTC().Format(time.RFC3339),
+		"Year":                      time.Now().Year(),
+		"Page":                      "explain",
+		"OhioASCII":                 s.ohioASCII,
 		// Footer law link uses internal redirect for stability
 		"LawURL": "/law",
 	}
-	if err := s.tmpl.ExecuteTemplate(w, "layout.html", data); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_, _ = fmt.Fprintf(w, "template error: %v", err)
+	// Opt-in review request form (see SetReviews); the form posts the looked-up domain back.
+	if d := s.getReviews(); d != nil {
+		data["RequestAccess"] = true
+		data["RequestDomain"] = lookupDomain
+		data["RequestToken"] = d.token(lookupDomain, time.Now())
+		data["RequestMaxLength"] = reviews.MaxJustification
+		if ref := q.Get("ref"); validRequestRef(ref) {
+			data["RequestRef"] = ref
+		}
 	}
+	localeData(data, cat, langs)
+	s.render(w, r, cat, data)
 }
 
 // handleLaw performs a simple redirect to the configured law URL target.
@@ -248,7 +570,8 @@ func (s *Server) handleLaw(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleClassify returns a small JSON response indicating whether a domain is classified
-// and, if so, the classification value. Query params: d|domain|original
+// and, if so, the classification value. Query params: d|domain|original, and optional scope
+// to answer for one audience (records without scopes apply to all).
 func (s *Server) handleClassify(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	q := r.URL.Query()
@@ -277,38 +600,68 @@ func (s *Server) handleClassify(w http.ResponseWriter, r *http.Request) {
 	}
 	norm := strings.TrimPrefix(d, "www.")
 	p := s.getPolicy()
-	rec, ok := p.Lookup(norm)
+	scope := policy.NormalizeScope(q.Get("scope"))
+	if !p.HasScope(scope) {
+		writeJSONError(w, http.StatusBadRequest, "invalid_parameter", "unknown scope: "+scope)
+		return
+	}
+	rec, ok := p.MatchScopedAt(norm, scope, time.Now())
 	type resp struct {
 		Domain         string `json:"domain"`
 		Normalized     string `json:"normalized_domain"`
+		Scope          string `json:"scope,omitempty"`
 		Found          bool   `json:"found"`
 		Classification string `json:"classification,omitempty"`
+		Label          string `json:"label,omitempty"`
+		Severity       string `json:"severity,omitempty"`
+		Blocking       *bool  `json:"blocking,omitempty"`
+		Exempt         bool   `json:"exempt,omitempty"`
+		ExemptedBy     string `json:"exempted_by,omitempty"`
 		PolicyVersion  string `json:"policy_version"`
 	}
-	out := resp{Domain: d, Normalized: norm, Found: ok, PolicyVersion: p.Version}
-	if ok {
-		out.Classification = rec.Classification
+	out := resp{Domain: d, Normalized: norm, Scope: scope, PolicyVersion: p.Version}
+	switch {
+	case ok && rec.Action == policy.ActionAllow:
+		// An exemption carves the name out of a broader block: not blocked.
+		out.Exempt, out.ExemptedBy = true, rec.Domain
+	case ok:
+		noteClassification(w, rec.Classification)
+		// Informational (non-blocking) classifications are found but report blocking=false.
+		class, _ := p.Classification(rec.Classification)
+		blocking := !rec.Exempt()
+		out.Found, out.Classification, out.Label, out.Severity, out.Blocking = true, rec.Classification, class.DisplayLabel(), class.Severity, &blocking
 	}
 	_ = json.NewEncoder(w).Encode(out)
 }
 
 // handleDomainList emits a plaintext list of domains from the active policy.
 // Wildcards ("*.example.com") are represented as two lines: ".example.com" and "example.com".
-func (s *Server) handleDomainList(w http.ResponseWriter, _ *http.Request) {
+// Exemptions are not listed and names they carve out are left off. The optional scope query
+// parameter limits the list to one audience.
+func (s *Server) handleDomainList(w http.ResponseWriter, r *http.Request) {
+	p := s.getPolicy()
+	scope := policy.NormalizeScope(r.URL.Query().Get("scope"))
+	if !p.HasScope(scope) {
+		writeJSONError(w, http.StatusBadRequest, "invalid_parameter", "unknown scope: "+scope)
+		return
+	}
+	p = p.ForScope(scope)
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	// modest caching can be enabled by operator; default to no-store for simplicity
 	w.Header().Set("Cache-Control", "no-store")
-	p := s.getPolicy()
-	for _, r := range p.Records {
-		if r.Status == "suspended" {
+	now := time.Now()
+	for _, r := range p.ActiveRecords(now) {
+		if r.Exempt() {
 			continue
 		}
 		d := strings.ToLower(strings.TrimSpace(r.Domain))
 		if strings.HasPrefix(d, "*.") {
 			base := strings.TrimPrefix(d, "*.")
-			_, _ = fmt.Fprintln(w, base)
+			if _, blocked := p.LookupAt(base, now); blocked {
+				_, _ = fmt.Fprintln(w, base)
+			}
 			_, _ = fmt.Fprintln(w, "."+base)
-		} else {
+		} else if _, blocked := p.LookupAt(d, now); blocked {
 			_, _ = fmt.Fprintln(w, d)
 		}
 	}
@@ -316,11 +669,17 @@ func (s *Server) handleDomainList(w http.ResponseWriter, _ *http.Request) {
 
 // UpdatePolicy swaps the in-memory policy used by the server.
 func (s *Server) UpdatePolicy(p *policy.Policy) {
+	p.BuildIndex()
 	s.mu.Lock()
 	s.policy = p
 	s.mu.Unlock()
 }
 
+// CurrentPolicy returns the policy currently in use.
+func (s *Server) CurrentPolicy() *policy.Policy {
+	return s.getPolicy()
+}
+
 // RecordRefreshSuccess records a successful policy refresh with the given source (e.g., "csv" or "csv-cache").
 func (s *Server) RecordRefreshSuccess(source string) {
 	s.refreshMu.Lock()
@@ -331,11 +690,16 @@ func (s *Server) RecordRefreshSuccess(source string) {
 	s.refreshMu.Unlock()
 }
 
-// RecordRefreshError records a refresh error message for metrics.
-func (s *Server) RecordRefreshError(msg string) {
+// RecordRefreshError records a refresh error for metrics. reason is a short code (e.g.,
+// "fetch_error" or "guard_rejected") that errors are counted by.
+func (s *Server) RecordRefreshError(reason, msg string) {
 	s.refreshMu.Lock()
 	s.lastRefreshTime = time.Now()
 	s.refreshErrorCount++
+	if s.refreshErrors == nil {
+		s.refreshErrors = make(map[string]int)
+	}
+	s.refreshErrors[reason]++
 	s.lastRefreshError = msg
 	s.refreshMu.Unlock()
 }
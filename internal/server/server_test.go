@@ -1,14 +1,22 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
 	"html/template"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/hits"
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/journal"
 	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
 )
 
@@ -78,6 +86,9 @@ func TestHandleExplainMissingParam(t *testing.T) {
 	if rr.Code != http.StatusBadRequest {
 		t.Fatalf("expected 400 got %d", rr.Code)
 	}
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html") || !strings.Contains(rr.Body.String(), "<h1>Missing Domain</h1>") {
+		t.Fatalf("browsers should get the HTML error page, got %q %s", rr.Header().Get("Content-Type"), rr.Body.String())
+	}
 }
 
 func TestHostFallbackIsOffByDefault(t *testing.T) {
@@ -319,8 +330,6 @@ func TestUpdatePolicyConcurrency(t *testing.T) {
 }
 
 func TestServerStartInvalidAddr(t *testing.T) {
-	// Reset default mux to avoid handler re-registration panics across tests
-	http.DefaultServeMux = http.NewServeMux()
 	srv := New("bad:addr", testPolicy())
 	if err := srv.Start(); err == nil {
 		t.Fatalf("expected error for invalid addr")
@@ -328,8 +337,6 @@ func TestServerStartInvalidAddr(t *testing.T) {
 }
 
 func TestServerStartPortInUse(t *testing.T) {
-	// Reset default mux
-	http.DefaultServeMux = http.NewServeMux()
 	// Grab a port and keep it open
 	ln, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
@@ -344,8 +351,6 @@ func TestServerStartPortInUse(t *testing.T) {
 }
 
 func TestServerStartAndServeHealth(t *testing.T) {
-	// Reset default mux
-	http.DefaultServeMux = http.NewServeMux()
 	// Preselect a free port (close it to free before starting)
 	ln, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
@@ -387,8 +392,10 @@ func TestHandleRootTemplateError(t *testing.T) {
 	if rr.Code != http.StatusInternalServerError {
 		t.Fatalf("expected 500 got %d", rr.Code)
 	}
-	if !strings.Contains(rr.Body.String(), "template error") {
-		t.Fatalf("expected template error message, got: %s", rr.Body.String())
+	// Browsers get the HTML error page, and the template's own error text stays out of it.
+	body := rr.Body.String()
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html") || !strings.Contains(body, "Page Unavailable") || strings.Contains(body, "layout.html") {
+		t.Fatalf("unexpected template error page: %q %s", rr.Header().Get("Content-Type"), body)
 	}
 }
 
@@ -402,15 +409,22 @@ func TestHandleExplainTemplateError(t *testing.T) {
 	if rr.Code != http.StatusInternalServerError {
 		t.Fatalf("expected 500 got %d", rr.Code)
 	}
-	if !strings.Contains(rr.Body.String(), "template error") {
-		t.Fatalf("expected template error message, got: %s", rr.Body.String())
+	if body := rr.Body.String(); !strings.Contains(body, "Page Unavailable") || strings.Contains(body, "layout.html") {
+		t.Fatalf("unexpected template error page: %s", body)
+	}
+	// JSON clients get the general error shape, still without the template's error text.
+	rr = httptest.NewRecorder()
+	srv.render(rr, httptest.NewRequest(http.MethodGet, "/?format=json", nil), srv.getLocales().Catalog("en"), nil)
+	var e map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &e); err != nil || e["error"] != "template_error" || strings.Contains(e["detail"], "layout.html") {
+		t.Fatalf("unexpected JSON template error: %v %s", err, rr.Body.String())
 	}
 }
 
 func TestMetricsEndpoint(t *testing.T) {
 	srv := newTestServer(t)
 	// Record an error then a success to populate metrics
-	srv.RecordRefreshError("network error")
+	srv.RecordRefreshError("fetch_error", "network error")
 	srv.RecordRefreshSuccess("csv-cache")
 	rr := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
@@ -425,6 +439,74 @@ func TestMetricsEndpoint(t *testing.T) {
 	if !strings.Contains(body, "csv-cache") {
 		t.Fatalf("expected last_refresh_source csv-cache in metrics: %s", body)
 	}
+	if !strings.Contains(body, `"refresh_error_count":1,"refresh_errors_by_reason":{"fetch_error":1}`) {
+		t.Fatalf("expected refresh errors by reason in metrics: %s", body)
+	}
+}
+
+func TestMetricsPrometheusExposition(t *testing.T) {
+	srv := newTestServer(t)
+	srv.RecordRefreshError("fetch_error", "network error")
+	srv.RecordRefreshSuccess("csv")
+	h := srv.Handler()
+	for _, target := range []string{"/classify?d=exampletool.com", "/classify?d=exampletool.com", "/classify?d=missing.example", "/classify?d=x.com&scope=nope", "/explain?d=exampletool.com", "/explain?d=missing.example"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	// /metrics is an ops route: scrape from loopback.
+	scrape := func(target string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.RemoteAddr = "127.0.0.1:4000"
+		return r
+	}
+	// JSON stays the default for plain clients.
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, scrape("/metrics"))
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("default content type %q", ct)
+	}
+
+	rr = httptest.NewRecorder()
+	req := scrape("/metrics")
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0;q=0.5,text/plain;version=0.0.4;q=0.3,*/*;q=0.1")
+	h.ServeHTTP(rr, req)
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("scrape content type %q", ct)
+	}
+	body := rr.Body.String()
+	want := []string{
+		"# TYPE sb29guard_requests_total counter",
+		`sb29guard_requests_total{handler="classify",classification="NO_DPA",code="200"} 2`,
+		`sb29guard_requests_total{handler="classify",classification="none",code="200"} 1`,
+		`sb29guard_requests_total{handler="classify",classification="none",code="400"} 1`,
+		`sb29guard_requests_total{handler="explain",classification="NO_DPA",code="200"} 1`,
+		`sb29guard_requests_total{handler="explain",classification="none",code="404"} 1`,
+		"# TYPE sb29guard_request_duration_seconds histogram",
+		`sb29guard_request_duration_seconds_bucket{handler="classify",le="+Inf"} 4`,
+		`sb29guard_request_duration_seconds_count{handler="explain"} 2`,
+		`sb29guard_policy_refresh_total{result="error"} 1`,
+		`sb29guard_policy_refresh_total{result="success"} 1`,
+		`sb29guard_policy_refresh_errors_total{reason="fetch_error"} 1`,
+		"sb29guard_policy_records 2",
+		`sb29guard_policy_info{version="0.1.0",hash="` + testPolicy().CanonicalHash() + `",source="csv"} 1`,
+	}
+	for _, w := range want {
+		if !strings.Contains(body, w+"\n") {
+			t.Fatalf("missing %q in exposition:\n%s", w, body)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, scrape("/metrics?format=prometheus"))
+	if !strings.Contains(rr.Body.String(), "sb29guard_policy_info{") {
+		t.Fatalf("format=prometheus not honoured: %s", rr.Body.String())
+	}
+}
+
+func TestLabelValueEscaping(t *testing.T) {
+	if got := labelValue("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Fatalf("labelValue = %s", got)
+	}
 }
 
 func TestHandleLawRedirect(t *testing.T) {
@@ -470,6 +552,68 @@ func TestClassifyEndpointFoundAndNotFound(t *testing.T) {
 	}
 }
 
+func TestClassifyAndDomainListHonourExemptions(t *testing.T) {
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "*.bigvendor.com", Classification: "NO_DPA", Rationale: "vendor unsigned", LastReview: "2025-08-01", Status: "active"},
+		{Domain: "classroom.bigvendor.com", Action: policy.ActionAllow, Rationale: "signed DPA", LastReview: "2025-08-01", Status: "active"},
+		{Domain: "exampletool.com", Action: policy.ActionAllow, Rationale: "signed DPA", LastReview: "2025-08-01", Status: "active"},
+		{Domain: "*.exampletool.com", Classification: "NO_DPA", Rationale: "vendor unsigned", LastReview: "2025-08-01", Status: "active"},
+	}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	srv := New(":0", p)
+	rr := httptest.NewRecorder()
+	srv.handleClassify(rr, httptest.NewRequest(http.MethodGet, "/classify?d=classroom.bigvendor.com", nil))
+	body := rr.Body.String()
+	if !strings.Contains(body, "\"found\":false") || !strings.Contains(body, "\"exempt\":true") || !strings.Contains(body, "\"exempted_by\":\"classroom.bigvendor.com\"") {
+		t.Fatalf("unexpected classify body for exemption: %s", body)
+	}
+	rr = httptest.NewRecorder()
+	srv.handleClassify(rr, httptest.NewRequest(http.MethodGet, "/classify?d=mail.bigvendor.com", nil))
+	if body := rr.Body.String(); !strings.Contains(body, "\"found\":true") || strings.Contains(body, "exempt") {
+		t.Fatalf("unexpected classify body for blocked sibling: %s", body)
+	}
+	rr = httptest.NewRecorder()
+	srv.handleDomainList(rr, httptest.NewRequest(http.MethodGet, "/domain-list", nil))
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	want := []string{"bigvendor.com", ".bigvendor.com", ".exampletool.com"}
+	if strings.Join(lines, ",") != strings.Join(want, ",") {
+		t.Fatalf("domain list = %q, want %q", lines, want)
+	}
+}
+
+func TestClassifyAndDomainListScope(t *testing.T) {
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "games.com", Classification: "OTHER", Rationale: "student devices only", LastReview: "2025-08-01", Status: "active", Scopes: []string{"students"}},
+		{Domain: "staff.example", Classification: "NO_DPA", Rationale: "staff devices only", LastReview: "2025-08-01", Status: "active", Scopes: []string{"staff"}},
+	}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	srv := New(":0", p)
+	rr := httptest.NewRecorder()
+	srv.handleClassify(rr, httptest.NewRequest(http.MethodGet, "/classify?d=games.com&scope=students", nil))
+	if body := rr.Body.String(); !strings.Contains(body, "\"found\":true") || !strings.Contains(body, "\"scope\":\"students\"") {
+		t.Fatalf("unexpected students answer: %s", body)
+	}
+	rr = httptest.NewRecorder()
+	srv.handleClassify(rr, httptest.NewRequest(http.MethodGet, "/classify?d=games.com&scope=staff", nil))
+	if body := rr.Body.String(); !strings.Contains(body, "\"found\":false") {
+		t.Fatalf("unexpected staff answer: %s", body)
+	}
+	rr = httptest.NewRecorder()
+	srv.handleClassify(rr, httptest.NewRequest(http.MethodGet, "/classify?d=games.com&scope=visitors", nil))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "invalid_parameter") {
+		t.Fatalf("expected 400 for unknown scope, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	srv.handleDomainList(rr, httptest.NewRequest(http.MethodGet, "/domain-list?scope=staff", nil))
+	if body := rr.Body.String(); body != "staff.example\n" {
+		t.Fatalf("unexpected staff domain list: %q", body)
+	}
+}
+
 func TestDomainListEndpoint(t *testing.T) {
 	srv := newTestServer(t)
 	rr := httptest.NewRecorder()
@@ -488,3 +632,438 @@ func TestDomainListEndpoint(t *testing.T) {
 		t.Fatalf("wildcard representation missing: %s", body)
 	}
 }
+
+func TestHistoryAndAdminRollback(t *testing.T) {
+	srv := newTestServer(t)
+	rr := httptest.NewRecorder()
+	srv.handleHistory(rr, httptest.NewRequest(http.MethodGet, "/metrics/history", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without journal, got %d", rr.Code)
+	}
+	j, err := journal.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	srv.SetJournal(j)
+	old := testPolicy()
+	if _, _, err := j.Record(old, "file"); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	cur := &policy.Policy{Version: "0.2.0", Updated: "2025-08-09", Records: old.Records[:1]}
+	srv.UpdatePolicy(cur)
+	if _, _, err := j.Record(cur, "csv"); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.handleHistory(rr, httptest.NewRequest(http.MethodGet, "/metrics/history", nil))
+	if rr.Code != 200 || !strings.Contains(rr.Body.String(), old.CanonicalHash()) || !strings.Contains(rr.Body.String(), `"current_hash":"`+cur.CanonicalHash()+`"`) {
+		t.Fatalf("unexpected history: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// Non-loopback clients are refused when no admin credentials are configured.
+	req := httptest.NewRequest(http.MethodPost, "/admin/rollback?to="+old.CanonicalHash()[:10], nil)
+	req.RemoteAddr = "203.0.113.5:4000"
+	rr = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for remote client, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/rollback?to="+old.CanonicalHash()[:10], nil)
+	req.RemoteAddr = "127.0.0.1:4000"
+	rr = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, req)
+	if rr.Code != 200 {
+		t.Fatalf("rollback failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if got := srv.getPolicy(); got.Version != "0.1.0" || len(got.Records) != 2 {
+		t.Fatalf("policy not rolled back: %#v", got)
+	}
+	if hist, _ := j.History(); len(hist) != 3 || hist[2].Source != "rollback" {
+		t.Fatalf("rollback not journaled: %#v", hist)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/rollback?to=deadbeefdead", nil)
+	req.RemoteAddr = "127.0.0.1:4000"
+	rr = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound || !strings.Contains(rr.Body.String(), `"error":"invalid_parameter"`) {
+		t.Fatalf("expected structured 404, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+// recordingTarget stands in for a wrapper around the server (e.g. notifications).
+type recordingTarget struct {
+	*Server
+	sources []string
+}
+
+func (r *recordingTarget) RecordRefreshSuccess(source string) {
+	r.Server.RecordRefreshSuccess(source)
+	r.sources = append(r.sources, source)
+}
+
+func TestAdminRollbackUsesPolicyTargetAndMapsErrors(t *testing.T) {
+	srv := newTestServer(t)
+	j, err := journal.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	srv.SetJournal(j)
+	old := testPolicy()
+	if _, _, err := j.Record(old, "file"); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	broken := &policy.Policy{Version: "0.3.0", Updated: "2025-08-10", Records: old.Records[:1]}
+	e, _, err := j.Record(broken, "csv")
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(j.Dir(), "snapshots", e.Snapshot+".json"), []byte("{"), 0o644); err != nil {
+		t.Fatalf("corrupt snapshot: %v", err)
+	}
+	target := &recordingTarget{Server: srv}
+	srv.SetPolicyTarget(target)
+	if err := srv.getPolicy().SetExpiredClassification("EXPIRED_DPA"); err != nil {
+		t.Fatalf("set grace: %v", err)
+	}
+	rollback := func(ref string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/rollback?to="+ref, nil)
+		req.RemoteAddr = "127.0.0.1:4000"
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := rollback(old.CanonicalHash()); rr.Code != http.StatusOK || len(target.sources) != 1 || target.sources[0] != "rollback" {
+		t.Fatalf("rollback should go through the target: %d %s %v", rr.Code, rr.Body.String(), target.sources)
+	}
+	if rr := rollback(broken.CanonicalHash()); rr.Code != http.StatusInternalServerError || !strings.Contains(rr.Body.String(), `"error":"journal_error"`) {
+		t.Fatalf("unreadable snapshot should be a 500, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := rollback("deadbeefdead"); rr.Code != http.StatusNotFound {
+		t.Fatalf("unknown snapshot should be a 404, got %d", rr.Code)
+	}
+	if p := srv.getPolicy(); p.Version != old.Version || p.ExpiredClassification() != "EXPIRED_DPA" {
+		t.Fatalf("failed rollbacks must leave the policy alone, and the rolled-back one keeps grace mode: %s %q", p.Version, p.ExpiredClassification())
+	}
+}
+
+func TestExplainHitsAndAdminSummary(t *testing.T) {
+	srv := newTestServer(t)
+	h := srv.Handler()
+	req := httptest.NewRequest(http.MethodGet, "/admin/summary", nil)
+	req.RemoteAddr = "127.0.0.1:4000"
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound || !strings.Contains(rr.Body.String(), "not_enabled") {
+		t.Fatalf("expected not_enabled without aggregator, got %d %s", rr.Code, rr.Body.String())
+	}
+	a, err := hits.Open(t.TempDir(), 30)
+	if err != nil {
+		t.Fatalf("open hits: %v", err)
+	}
+	srv.SetHits(a)
+	for _, target := range []string{"/explain?d=exampletool.com", "/explain?d=www.exampletool.com", "/explain?d=a.trackingwidgets.io", "/explain?d=missing.example"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("User-Agent", "secret-agent")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/summary", nil)
+	req.RemoteAddr = "203.0.113.5:4000"
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for remote client, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/summary", nil)
+	req.RemoteAddr = "127.0.0.1:4000"
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	body := rr.Body.String()
+	if rr.Code != 200 || !strings.Contains(body, `{"domain":"exampletool.com","classification":"NO_DPA","count":2}`) ||
+		!strings.Contains(body, `{"domain":"*.trackingwidgets.io","classification":"EXPIRED_DPA","count":1}`) ||
+		strings.Contains(body, "missing.example") || strings.Contains(body, "secret-agent") || strings.Contains(body, "192.0.2.1") {
+		t.Fatalf("unexpected summary: %d %s", rr.Code, body)
+	}
+
+	for target, code := range map[string]int{"/admin/summary?date=2001-01-01": http.StatusNotFound, "/admin/summary?date=today": http.StatusBadRequest} {
+		req = httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = "127.0.0.1:4000"
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != code {
+			t.Fatalf("%s: expected %d, got %d", target, code, rr.Code)
+		}
+	}
+}
+
+func TestAdminRollbackBasicAuth(t *testing.T) {
+	t.Setenv("SB29_ADMIN_USER", "admin")
+	t.Setenv("SB29_ADMIN_PASS", "s3cret")
+	srv := newTestServer(t)
+	req := httptest.NewRequest(http.MethodPost, "/admin/rollback?to=x", nil)
+	req.RemoteAddr = "127.0.0.1:4000"
+	rr := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without credentials even from loopback, got %d", rr.Code)
+	}
+	req.SetBasicAuth("admin", "s3cret")
+	rr = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound { // authorized, but no journal attached
+		t.Fatalf("expected 404 after auth without journal, got %d", rr.Code)
+	}
+}
+
+func TestHandleExplainShowsUnicodeForPunycodeHost(t *testing.T) {
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "bücher.de", Classification: "NO_DPA", Rationale: "Vendor has not signed", LastReview: "2025-08-01", Status: "active"},
+	}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	srv := New(":0", p)
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/explain", nil)
+	req.Header.Set("X-Original-Host", "www.xn--bcher-kva.de")
+	srv.handleExplain(rr, req)
+	if rr.Code != 200 {
+		t.Fatalf("expected 200 got %d: %s", rr.Code, rr.Body.String())
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "www.bücher.de") {
+		t.Fatalf("expected Unicode display name in body: %s", body)
+	}
+}
+
+func TestExplainAndClassifyUseTaxonomy(t *testing.T) {
+	no := false
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08",
+		Classifications: []policy.ClassificationDef{
+			{Key: "PARENT_CONSENT_REQUIRED", Label: "Parent Consent Required", Description: "A guardian must opt in first.", Color: "#1c7ed6", Severity: "medium"},
+			{Key: "MONITOR", Label: "Monitored", Blocks: &no},
+		},
+		Records: []policy.Record{
+			{Domain: "consent.com", Classification: "PARENT_CONSENT_REQUIRED", Rationale: "Needs consent", LastReview: "2025-08-01", Status: "active"},
+			{Domain: "watched.com", Classification: "MONITOR", Rationale: "Observed only", LastReview: "2025-08-01", Status: "active"},
+		}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	srv := New(":0", p)
+	rr := httptest.NewRecorder()
+	srv.handleExplain(rr, httptest.NewRequest(http.MethodGet, "/explain?domain=consent.com", nil))
+	body := rr.Body.String()
+	for _, want := range []string{"Parent Consent Required", "A guardian must opt in first.", "#1c7ed6", "badge-medium"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("explain page missing %q: %s", want, body)
+		}
+	}
+	rr = httptest.NewRecorder()
+	srv.handleClassify(rr, httptest.NewRequest(http.MethodGet, "/classify?d=consent.com", nil))
+	if body := rr.Body.String(); !strings.Contains(body, "\"label\":\"Parent Consent Required\"") || !strings.Contains(body, "\"blocking\":true") {
+		t.Fatalf("unexpected classify body: %s", body)
+	}
+	rr = httptest.NewRecorder()
+	srv.handleClassify(rr, httptest.NewRequest(http.MethodGet, "/classify?d=watched.com", nil))
+	if body := rr.Body.String(); !strings.Contains(body, "\"blocking\":false") || strings.Contains(body, "\"exempt\"") {
+		t.Fatalf("unexpected classify body for informational class: %s", body)
+	}
+}
+
+func TestExplainAndRootLocalized(t *testing.T) {
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08",
+		Classifications: []policy.ClassificationDef{{Key: "PARENT_CONSENT_REQUIRED", Label: "Parent Consent Required"}},
+		Records: []policy.Record{
+			{Domain: "exampletool.com", Classification: "NO_DPA", Rationale: "Vendor unsigned", LastReview: "2025-08-01", Status: "active",
+				RationaleTranslations: map[string]string{"es": "Proveedor sin firmar"}},
+			{Domain: "consent.com", Classification: "PARENT_CONSENT_REQUIRED", Rationale: "Needs consent", LastReview: "2025-08-01", Status: "active"},
+		}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	srv := New(":0", p)
+	get := func(target, acceptLanguage string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/explain?d=exampletool.com", "es-MX,es;q=0.9,en;q=0.5")
+	body := rr.Body.String()
+	for _, want := range []string{`<html lang="es" dir="ltr">`, "El acceso a", "Sin acuerdo de privacidad de datos", "Proveedor sin firmar", "Bloqueado: exampletool.com", `href="/explain?d=exampletool.com&amp;lang=so"`} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("Spanish explain page missing %q: %s", want, body)
+		}
+	}
+	if vary := strings.Join(rr.Header().Values("Vary"), ", "); strings.Contains(body, "Vendor unsigned") || vary != "Accept, Accept-Language" {
+		t.Fatalf("unexpected English rationale or Vary %q", vary)
+	}
+
+	// ?lang= wins over the header; without a translated rationale the English one is shown.
+	body = get("/explain?d=consent.com&lang=so", "es").Body.String()
+	for _, want := range []string{`<html lang="so"`, "waa xaddidan yahay", "Parent Consent Required", "Needs consent"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("Somali explain page missing %q: %s", want, body)
+		}
+	}
+	body = get("/explain?d=exampletool.com", "fr").Body.String()
+	if !strings.Contains(body, `<html lang="en"`) || !strings.Contains(body, "No Data Privacy Agreement") || !strings.Contains(body, "Vendor unsigned") {
+		t.Fatalf("unsupported language should fall back to English: %s", body)
+	}
+	body = get("/?lang=ne", "").Body.String()
+	if !strings.Contains(body, "लोड गरिएका डोमेनहरू:") || !strings.Contains(body, "नीति v0.1.0") {
+		t.Fatalf("Nepali landing page not translated: %s", body)
+	}
+	rr = get("/explain?d=unknown.example&lang=es", "")
+	if rr.Code != http.StatusNotFound || !strings.Contains(rr.Body.String(), "Sin clasificar") {
+		t.Fatalf("unexpected not-classified page %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestExplainJSON(t *testing.T) {
+	p := &policy.Policy{Version: "0.1.0", Updated: "2025-08-08", Records: []policy.Record{
+		{Domain: "*.exampletool.com", Classification: "NO_DPA", Rationale: "Vendor unsigned", LastReview: "2025-08-01", Status: "active",
+			SourceRef: "TICKET-1", Expires: "2099-01-01", Tags: []string{"math"}, RationaleTranslations: map[string]string{"es": "Proveedor sin firmar"}},
+	}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	srv := New(":0", p)
+	get := func(target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept", accept)
+		req.RemoteAddr = "127.0.0.1:1234" // loopback may use the admin routes
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/explain?d=https://www.App.ExampleTool.com:8443/x", "application/json")
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/json" || rr.Header().Values("Vary")[0] != "Accept" {
+		t.Fatalf("unexpected response %d %v", rr.Code, rr.Header())
+	}
+	var got explainResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := explainResponse{Domain: "www.app.exampletool.com", Normalized: "app.exampletool.com", Rule: "*.exampletool.com",
+		Classification: "NO_DPA", Label: "No Data Privacy Agreement", Description: got.Description, Severity: "high",
+		Rationale: "Vendor unsigned", Lang: "en", SourceRef: "TICKET-1", LastReview: "2025-08-01", Expires: "2099-01-01",
+		Tags: []string{"math"}, PolicyVersion: "0.1.0", PolicyHash: p.CanonicalHash()}
+	if got.Description == "" || !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected JSON:\n got %+v\nwant %+v", got, want)
+	}
+
+	// ?format=json wins over a browser's Accept header, and the rationale follows ?lang=.
+	rr = get("/explain?d=app.exampletool.com&format=json&lang=es", "text/html,application/xhtml+xml,*/*;q=0.8")
+	if !strings.Contains(rr.Body.String(), `"rationale":"Proveedor sin firmar","lang":"es"`) {
+		t.Fatalf("unexpected localized JSON: %s", rr.Body.String())
+	}
+	if rr = get("/explain?d=app.exampletool.com", "text/html,application/json;q=0.9"); !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("browsers should keep getting HTML, got %s", rr.Header().Get("Content-Type"))
+	}
+
+	for target, want := range map[string][2]string{
+		"/explain?format=json":                   {"invalid_parameter", "original domain required"},
+		"/explain?d=missing.example&format=json": {"not_found", "missing.example is not classified in policy 0.1.0"},
+		"/domain-list?scope=visitors":            {"invalid_parameter", "unknown scope: visitors"},
+		"/admin/nothing-here":                    {"not_found", "no admin endpoint /admin/nothing-here"},
+		"/request-access":                        {"not_enabled", "review requests not enabled"},
+	} {
+		rr := get(target, "")
+		var e map[string]string
+		if err := json.Unmarshal(rr.Body.Bytes(), &e); err != nil || rr.Code < 400 || rr.Header().Get("Content-Type") != "application/json" ||
+			e["error"] != want[0] || !strings.HasPrefix(e["detail"], want[1]) {
+			t.Errorf("%s: unexpected error response %d %q", target, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	srv := New(addr, testPolicy())
+	// Wrap the mux so one request is in flight when Shutdown starts.
+	inFlight, release := make(chan struct{}), make(chan struct{})
+	srv.handlerOnce.Do(func() {
+		srv.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(inFlight)
+			<-release
+			srv.handleHealth(w, r)
+		})
+	})
+	started := make(chan error, 1)
+	go func() { started <- srv.Start() }()
+	got := make(chan int, 1)
+	go func() {
+		for i := 0; i < 50; i++ {
+			resp, err := http.Get("http://" + addr + "/health")
+			if err == nil {
+				resp.Body.Close()
+				got <- resp.StatusCode
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		got <- 0
+	}()
+	<-inFlight
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- srv.Shutdown(context.Background()) }()
+	select {
+	case <-srv.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Done not closed after Shutdown")
+	}
+	select {
+	case <-shutdown:
+		t.Fatalf("Shutdown returned before the in-flight request finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	if code := <-got; code != 200 {
+		t.Fatalf("in-flight request got %d, want 200", code)
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if err := <-started; err != nil {
+		t.Fatalf("Start should return nil after Shutdown, got %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start after Shutdown should return nil, got %v", err)
+	}
+}
+
+func TestServersHaveIndependentHandlers(t *testing.T) {
+	a := New(":0", testPolicy())
+	other := testPolicy()
+	other.Version = "9.9.9"
+	b := New(":0", other)
+	b.SetLimits(Limits{ReadHeaderTimeout: time.Second})
+	for srv, want := range map[*Server]string{a: "0.1.0", b: "9.9.9"} {
+		ts := httptest.NewServer(srv.Handler())
+		resp, err := http.Get(ts.URL + "/health")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		ts.Close()
+		if !strings.Contains(string(body), want) {
+			t.Fatalf("expected version %s, got %s", want, body)
+		}
+	}
+}
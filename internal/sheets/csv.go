@@ -14,12 +14,15 @@ import (
 	"strings"
 	"time"
 
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/i18n"
 	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
 )
 
 // FetchCSVPolicy downloads a published Google Sheets CSV (public link) and converts it into a *policy.Policy.
 // Required headers (case-insensitive): domain, classification, rationale, last_review, status
-// Optional headers: source_ref, notes, expires, tags
+// Optional headers: source_ref, notes, expires, tags, action (block|allow; allow rows are exemptions),
+// scopes (comma-separated audiences, e.g. "students,staff"; empty = all),
+// rationale_<lang> (translated rationale, e.g. rationale_es, rationale_so, rationale_pt_br)
 // Extra columns are ignored.
 func FetchCSVPolicy(url string, client *http.Client) (*policy.Policy, error) {
 	if url == "" {
@@ -81,6 +84,14 @@ func backoff(attempt int) time.Duration {
 // It stores two files under cacheDir/sheets: <id>.csv and <id>.meta.json, where <id> is a hash of the URL.
 // Returns the policy and whether it was served from cache (true) or network (false).
 func FetchCSVPolicyCached(url, cacheDir string, client *http.Client) (*policy.Policy, bool, error) {
+	return FetchCSVPolicyAdmitted(url, cacheDir, client, nil)
+}
+
+// FetchCSVPolicyAdmitted is FetchCSVPolicyCached with an admission check. admit (when set)
+// sees every parsed policy, cached or fresh; when it returns an error that error is returned
+// and a fresh download is not written to the cache, so a rejected export is never served
+// from cache later (e.g. on a 304 after a restart).
+func FetchCSVPolicyAdmitted(url, cacheDir string, client *http.Client, admit func(*policy.Policy) error) (*policy.Policy, bool, error) {
 	if url == "" {
 		return nil, false, errors.New("csv url empty")
 	}
@@ -119,6 +130,11 @@ func FetchCSVPolicyCached(url, cacheDir string, client *http.Client) (*policy.Po
 				return nil, false, perr
 			}
 			p.Metadata.Source = "csv-cache"
+			if admit != nil {
+				if aerr := admit(p); aerr != nil {
+					return nil, true, aerr
+				}
+			}
 			return p, true, nil
 		}
 		// fallthrough to fetch fresh if cache missing
@@ -134,18 +150,23 @@ func FetchCSVPolicyCached(url, cacheDir string, client *http.Client) (*policy.Po
 	if err != nil {
 		return nil, false, fmt.Errorf("read csv: %w", err)
 	}
-	// write cache best-effort
+	p, perr := parseCSV(string(b))
+	if perr != nil {
+		return nil, false, perr
+	}
+	p.Metadata.Source = "csv"
+	if admit != nil {
+		if aerr := admit(p); aerr != nil {
+			return nil, false, aerr
+		}
+	}
+	// write cache best-effort, only for sheets that were accepted
 	_ = os.WriteFile(csvPath, b, 0o644)
 	// store meta
 	m := struct{ ETag, LastModified string }{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
 	if mb, jerr := json.Marshal(m); jerr == nil {
 		_ = os.WriteFile(metaPath, mb, 0o644)
 	}
-	p, perr := parseCSV(string(b))
-	if perr != nil {
-		return nil, false, perr
-	}
-	p.Metadata.Source = "csv"
 	return p, false, nil
 }
 
@@ -160,6 +181,12 @@ func urlHash(s string) string {
 	return fmt.Sprintf("%x", h)
 }
 
+// ParseCSVPolicy converts CSV bytes already on disk (e.g. a cached sheet export) into a
+// validated *policy.Policy using the same column rules as FetchCSVPolicy.
+func ParseCSVPolicy(data []byte) (*policy.Policy, error) {
+	return parseCSV(string(data))
+}
+
 func parseCSV(data string) (*policy.Policy, error) {
 	r := csv.NewReader(strings.NewReader(data))
 	r.FieldsPerRecord = -1
@@ -171,6 +198,12 @@ func parseCSV(data string) (*policy.Policy, error) {
 	for i, h := range head {
 		index[strings.ToLower(strings.TrimSpace(h))] = i
 	}
+	translated := map[string]int{} // language tag -> column of rationale_<lang>
+	for h, i := range index {
+		if tag, ok := strings.CutPrefix(h, "rationale_"); ok && tag != "" {
+			translated[i18n.NormalizeTag(tag)] = i
+		}
+	}
 	req := []string{"domain", "classification", "rationale", "last_review", "status"}
 	for _, k := range req {
 		if _, ok := index[k]; !ok {
@@ -186,36 +219,50 @@ func parseCSV(data string) (*policy.Policy, error) {
 		if err != nil {
 			return nil, fmt.Errorf("read row: %w", err)
 		}
+		// Trimmed exports drop trailing empty cells, so any column may be missing from a row.
+		cell := func(col string) string {
+			if i, ok := index[col]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
 		// Skip empty domain rows
-		if strings.TrimSpace(row[index["domain"]]) == "" {
+		if cell("domain") == "" {
 			continue
 		}
 		rec := policy.Record{
-			Domain:         strings.TrimSpace(row[index["domain"]]),
-			Classification: strings.TrimSpace(row[index["classification"]]),
-			Rationale:      strings.TrimSpace(row[index["rationale"]]),
-			LastReview:     strings.TrimSpace(row[index["last_review"]]),
-			Status:         strings.TrimSpace(row[index["status"]]),
-		}
-		if i, ok := index["source_ref"]; ok {
-			rec.SourceRef = strings.TrimSpace(row[i])
-		}
-		if i, ok := index["notes"]; ok {
-			rec.Notes = strings.TrimSpace(row[i])
+			Domain:         cell("domain"),
+			Classification: cell("classification"),
+			Rationale:      cell("rationale"),
+			LastReview:     cell("last_review"),
+			Status:         cell("status"),
+			SourceRef:      cell("source_ref"),
+			Notes:          cell("notes"),
+			Expires:        cell("expires"),
+			Action:         strings.ToLower(cell("action")),
 		}
-		if i, ok := index["expires"]; ok {
-			rec.Expires = strings.TrimSpace(row[i])
+		for _, s := range strings.Split(cell("scopes"), ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				rec.Scopes = append(rec.Scopes, s)
+			}
 		}
-		if i, ok := index["tags"]; ok {
-			raw := strings.TrimSpace(row[i])
-			if raw != "" {
-				parts := strings.Split(raw, ",")
-				for _, p := range parts {
-					rec.Tags = append(rec.Tags, strings.TrimSpace(p))
+		for tag, i := range translated {
+			if i < len(row) {
+				if v := strings.TrimSpace(row[i]); v != "" {
+					if rec.RationaleTranslations == nil {
+						rec.RationaleTranslations = map[string]string{}
+					}
+					rec.RationaleTranslations[tag] = v
 				}
-				sort.Strings(rec.Tags)
 			}
 		}
+		if raw := cell("tags"); raw != "" {
+			parts := strings.Split(raw, ",")
+			for _, p := range parts {
+				rec.Tags = append(rec.Tags, strings.TrimSpace(p))
+			}
+			sort.Strings(rec.Tags)
+		}
 		records = append(records, rec)
 	}
 	p := &policy.Policy{
@@ -1,6 +1,7 @@
 package sheets
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -9,6 +10,8 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/RiceC-at-MasonHS/SB29-guard/internal/policy"
 )
 
 func sampleCSV() string {
@@ -137,3 +140,39 @@ func TestURLHashStability(t *testing.T) {
 		t.Fatalf("expected different hash for different input")
 	}
 }
+
+func TestFetchCSVPolicyAdmitted_RejectedSheetIsNotCached(t *testing.T) {
+	dir := t.TempDir()
+	body := sampleCSV()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == "\"v1\"" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", "\"v1\"")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer ts.Close()
+	client := &http.Client{Timeout: 5 * time.Second}
+	reject := errors.New("rejected")
+	if _, _, err := FetchCSVPolicyAdmitted(ts.URL, dir, client, func(*policy.Policy) error { return reject }); err != reject {
+		t.Fatalf("expected the admission error, got %v", err)
+	}
+	id := urlHash(ts.URL)
+	for _, name := range []string{id + ".csv", id + ".meta.json"} {
+		if _, err := os.Stat(filepath.Join(dir, "sheets", name)); !os.IsNotExist(err) {
+			t.Fatalf("rejected sheet should leave no %s: %v", name, err)
+		}
+	}
+	// Without stored meta the next fetch downloads (and is checked) again rather than getting a 304.
+	p, fromCache, err := FetchCSVPolicyAdmitted(ts.URL, dir, client, func(*policy.Policy) error { return nil })
+	if err != nil || fromCache || len(p.Records) == 0 {
+		t.Fatalf("admitted fetch: %v fromCache=%v", err, fromCache)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sheets", id+".csv")); err != nil {
+		t.Fatalf("admitted sheet should be cached: %v", err)
+	}
+	if _, _, err := FetchCSVPolicyAdmitted(ts.URL, dir, client, func(*policy.Policy) error { return reject }); err != reject {
+		t.Fatalf("cached sheet should be checked too, got %v", err)
+	}
+}
@@ -21,6 +21,40 @@ func TestParseCSV(t *testing.T) {
 	}
 }
 
+func TestParseCSV_ActionColumn(t *testing.T) {
+	csv := "domain,classification,rationale,last_review,status,action\n" +
+		"*.bigvendor.com,NO_DPA,Vendor unsigned,2025-08-01,active,\n" +
+		"classroom.bigvendor.com,,Signed DPA,2025-08-01,active,Allow\n"
+	p, err := parseCSV(csv)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if p.Records[0].Exempt() || !p.Records[1].Exempt() {
+		t.Fatalf("unexpected actions: %+v", p.Records)
+	}
+	if _, blocked := p.Lookup("classroom.bigvendor.com"); blocked {
+		t.Fatalf("exempted name should not be blocked")
+	}
+}
+
+func TestParseCSV_ShortRows(t *testing.T) {
+	// Trimmed exports drop trailing empty cells; missing cells read as empty.
+	csv := "domain,classification,rationale,last_review,status,source_ref,notes,expires,action,scopes,tags\n" +
+		"example.com,NO_DPA,Reason,2025-08-01,active,TCK-1,,,,students,EDTECH\n" +
+		"short.example.org,NO_DPA,Reason,2025-08-01,active\n" +
+		"shorter.example.org,NO_DPA,Reason,2025-08-01\n"
+	p, err := parseCSV(csv)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(p.Records) != 3 || len(p.Records[0].Scopes) != 1 || p.Records[1].SourceRef != "" || p.Records[1].Scopes != nil || p.Records[2].Status != "" {
+		t.Fatalf("unexpected records: %+v", p.Records)
+	}
+	if _, blocked := p.Lookup("shorter.example.org"); !blocked {
+		t.Fatalf("row without a status cell should be active")
+	}
+}
+
 func TestParseCSV_MissingColumn(t *testing.T) {
 	csv := "domain,classification,rationale,last_review\nexample.com,NO_DPA,Reason,2025-08-01\n"
 	_, err := parseCSV(csv)
@@ -36,3 +70,19 @@ func TestParseCSV_InvalidClassification(t *testing.T) {
 		t.Fatalf("expected invalid classification error")
 	}
 }
+
+func TestParseCSV_RationaleTranslationColumns(t *testing.T) {
+	csv := "domain,classification,rationale,Rationale_ES,rationale_pt_br,last_review,status\n" +
+		"example.com,NO_DPA,Vendor unsigned,Proveedor sin firmar,,2025-08-01,active\n" +
+		"other.com,NO_DPA,Vendor unsigned,,Fornecedor sem assinatura,2025-08-01,active\n"
+	p, err := parseCSV(csv)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if got := p.Records[0].RationaleTranslations; len(got) != 1 || got["es"] != "Proveedor sin firmar" {
+		t.Fatalf("unexpected translations: %v", got)
+	}
+	if got := p.Records[1].LocalizedRationale("pt-BR"); got != "Fornecedor sem assinatura" {
+		t.Fatalf("unexpected pt-br rationale: %q", got)
+	}
+}